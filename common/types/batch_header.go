@@ -127,6 +127,16 @@ func (b *BatchHeader) TotalL1MessagePopped() uint64 {
 	return b.totalL1MessagePopped
 }
 
+// DataHash returns the data hash in the BatchHeader.
+func (b *BatchHeader) DataHash() common.Hash {
+	return b.dataHash
+}
+
+// ParentBatchHash returns the parent batch hash in the BatchHeader.
+func (b *BatchHeader) ParentBatchHash() common.Hash {
+	return b.parentBatchHash
+}
+
 // SkippedL1MessageBitmap returns the skipped L1 message bitmap in the BatchHeader.
 func (b *BatchHeader) SkippedL1MessageBitmap() []byte {
 	return b.skippedL1MessageBitmap
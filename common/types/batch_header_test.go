@@ -248,4 +248,5 @@ func TestBatchHeaderDecode(t *testing.T) {
 	decoded, err := DecodeBatchHeader(encoded)
 	assert.NoError(t, err)
 	assert.Equal(t, header, decoded)
+	assert.Equal(t, common.HexToHash("0x02"), decoded.ParentBatchHash())
 }
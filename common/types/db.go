@@ -188,7 +188,7 @@ func (s ChunkProofsStatus) String() string {
 	}
 }
 
-// RollupStatus block_batch rollup_status (pending, committing, committed, commit_failed, finalizing, finalized, finalize_skipped, finalize_failed)
+// RollupStatus block_batch rollup_status (pending, committing, committed, commit_failed, finalizing, finalized, finalize_skipped, finalize_failed, finalize_discrepancy, proof_missing, proof_rejected, proof_mismatch)
 type RollupStatus int
 
 const (
@@ -208,6 +208,16 @@ const (
 	RollupCommitFailed
 	// RollupFinalizeFailed : rollup finalize transaction is confirmed but failed
 	RollupFinalizeFailed
+	// RollupFinalizeDiscrepancy : rollup finalize transaction is confirmed but the expected finalize event is missing
+	RollupFinalizeDiscrepancy
+	// RollupProofMissing : batch proving is verified but the proof is missing
+	RollupProofMissing
+	// RollupProofRejected : the simulation of finalize transaction reverted, e.g. the proof is rejected by the verifier
+	RollupProofRejected
+	// RollupProofMismatch : the public inputs of the proof don't match the roots of the batch, e.g. it's proved for another batch
+	RollupProofMismatch
+	// RollupProofMalformed : the proof or instances buffer of the verified proof is malformed, e.g. its length isn't a multiple of 32
+	RollupProofMalformed
 )
 
 func (s RollupStatus) String() string {
@@ -226,11 +236,64 @@ func (s RollupStatus) String() string {
 		return "RollupCommitFailed"
 	case RollupFinalizeFailed:
 		return "RollupFinalizeFailed"
+	case RollupFinalizeDiscrepancy:
+		return "RollupFinalizeDiscrepancy"
+	case RollupProofMissing:
+		return "RollupProofMissing"
+	case RollupProofRejected:
+		return "RollupProofRejected"
+	case RollupProofMismatch:
+		return "RollupProofMismatch"
+	case RollupProofMalformed:
+		return "RollupProofMalformed"
 	default:
 		return fmt.Sprintf("Undefined RollupStatus (%d)", int32(s))
 	}
 }
 
+// FinalizeSkipReason block_batch finalize_skip_reason, why the committed batch was last skipped by finalization
+type FinalizeSkipReason int
+
+const (
+	// FinalizeSkipReasonUndefined : the batch has never been skipped by finalization
+	FinalizeSkipReasonUndefined FinalizeSkipReason = iota
+	// FinalizeSkipReasonTimePolicy : the proof is not ready and the batch is not due to be finalized without proof
+	FinalizeSkipReasonTimePolicy
+	// FinalizeSkipReasonProofFailed : the batch proving failed
+	FinalizeSkipReasonProofFailed
+	// FinalizeSkipReasonUploadFailed : the batch proving is verified but the proof is not uploaded
+	FinalizeSkipReasonUploadFailed
+	// FinalizeSkipReasonProofRejected : the simulation of finalize transaction reverted
+	FinalizeSkipReasonProofRejected
+	// FinalizeSkipReasonProofMismatch : the public inputs of the proof don't match the roots of the batch
+	FinalizeSkipReasonProofMismatch
+	// FinalizeSkipReasonProofMalformed : the proof or instances buffer of the verified proof is malformed
+	FinalizeSkipReasonProofMalformed
+	// FinalizeSkipReasonParentNotFinalized : the finalize transaction of the parent batch is not sent yet
+	FinalizeSkipReasonParentNotFinalized
+)
+
+func (r FinalizeSkipReason) String() string {
+	switch r {
+	case FinalizeSkipReasonTimePolicy:
+		return "time-policy"
+	case FinalizeSkipReasonProofFailed:
+		return "proof-failed"
+	case FinalizeSkipReasonUploadFailed:
+		return "upload-failed"
+	case FinalizeSkipReasonProofRejected:
+		return "proof-rejected"
+	case FinalizeSkipReasonProofMismatch:
+		return "proof-mismatch"
+	case FinalizeSkipReasonProofMalformed:
+		return "proof-malformed"
+	case FinalizeSkipReasonParentNotFinalized:
+		return "parent-not-finalized"
+	default:
+		return fmt.Sprintf("Undefined FinalizeSkipReason (%d)", int32(r))
+	}
+}
+
 // SenderType defines the various types of senders sending the transactions.
 type SenderType int
 
@@ -132,6 +132,31 @@ func TestRollupStatus(t *testing.T) {
 			RollupFinalizeFailed,
 			"RollupFinalizeFailed",
 		},
+		{
+			"RollupFinalizeDiscrepancy",
+			RollupFinalizeDiscrepancy,
+			"RollupFinalizeDiscrepancy",
+		},
+		{
+			"RollupProofMissing",
+			RollupProofMissing,
+			"RollupProofMissing",
+		},
+		{
+			"RollupProofRejected",
+			RollupProofRejected,
+			"RollupProofRejected",
+		},
+		{
+			"RollupProofMismatch",
+			RollupProofMismatch,
+			"RollupProofMismatch",
+		},
+		{
+			"RollupProofMalformed",
+			RollupProofMalformed,
+			"RollupProofMalformed",
+		},
 		{
 			"Invalid Value",
 			RollupStatus(999),
@@ -146,6 +171,66 @@ func TestRollupStatus(t *testing.T) {
 	}
 }
 
+func TestFinalizeSkipReason(t *testing.T) {
+	tests := []struct {
+		name string
+		r    FinalizeSkipReason
+		want string
+	}{
+		{
+			"FinalizeSkipReasonUndefined",
+			FinalizeSkipReasonUndefined,
+			"Undefined FinalizeSkipReason (0)",
+		},
+		{
+			"FinalizeSkipReasonTimePolicy",
+			FinalizeSkipReasonTimePolicy,
+			"time-policy",
+		},
+		{
+			"FinalizeSkipReasonProofFailed",
+			FinalizeSkipReasonProofFailed,
+			"proof-failed",
+		},
+		{
+			"FinalizeSkipReasonUploadFailed",
+			FinalizeSkipReasonUploadFailed,
+			"upload-failed",
+		},
+		{
+			"FinalizeSkipReasonProofRejected",
+			FinalizeSkipReasonProofRejected,
+			"proof-rejected",
+		},
+		{
+			"FinalizeSkipReasonProofMismatch",
+			FinalizeSkipReasonProofMismatch,
+			"proof-mismatch",
+		},
+		{
+			"FinalizeSkipReasonProofMalformed",
+			FinalizeSkipReasonProofMalformed,
+			"proof-malformed",
+		},
+		{
+			"FinalizeSkipReasonParentNotFinalized",
+			FinalizeSkipReasonParentNotFinalized,
+			"parent-not-finalized",
+		},
+		{
+			"Invalid Value",
+			FinalizeSkipReason(999),
+			"Undefined FinalizeSkipReason (999)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.r.String())
+		})
+	}
+}
+
 func TestSenderType(t *testing.T) {
 	tests := []struct {
 		name string
@@ -26,4 +26,6 @@ const (
 	ErrCoordinatorHandleZkProofFailure = 20003
 	// ErrCoordinatorEmptyProofData get empty proof data
 	ErrCoordinatorEmptyProofData = 20004
+	// ErrCoordinatorDraining asks the provers to stop fetching new tasks, e.g. for maintenance
+	ErrCoordinatorDraining = 20005
 )
@@ -3,12 +3,14 @@ package message
 import (
 	"crypto/ecdsa"
 	"crypto/rand"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
 
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/crypto"
 	"github.com/scroll-tech/go-ethereum/rlp"
 )
@@ -146,6 +148,51 @@ func (i *Identity) Hash() ([]byte, error) {
 	return hash[:], nil
 }
 
+// TaskAssignment contains the fields of a task assigned by the coordinator to be signed by the coordinator,
+// so that the provers can verify the authenticity of the task before proving it.
+type TaskAssignment struct {
+	UUID     string `json:"uuid"`
+	TaskID   string `json:"task_id"`
+	TaskType uint64 `json:"task_type"`
+	TaskData string `json:"task_data"`
+}
+
+// Hash returns the hash of the task assignment, which should be the message used to construct the signature.
+func (t *TaskAssignment) Hash() ([]byte, error) {
+	byt, err := rlp.EncodeToBytes(t)
+	if err != nil {
+		return nil, err
+	}
+	hash := crypto.Keccak256Hash(byt)
+	return hash[:], nil
+}
+
+// Sign signs the task assignment with the private key of the coordinator.
+func (t *TaskAssignment) Sign(priv *ecdsa.PrivateKey) (string, error) {
+	hash, err := t.Hash()
+	if err != nil {
+		return "", err
+	}
+	sig, err := crypto.Sign(hash, priv)
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(sig), nil
+}
+
+// Verify verifies the signature of the task assignment is signed by the given compressed public key.
+func (t *TaskAssignment) Verify(signature string, publicKey string) (bool, error) {
+	hash, err := t.Hash()
+	if err != nil {
+		return false, err
+	}
+	sig := common.FromHex(signature)
+	if len(sig) != crypto.SignatureLength {
+		return false, fmt.Errorf("invalid signature length: %d", len(sig))
+	}
+	return crypto.VerifySignature(common.FromHex(publicKey), hash, sig[:len(sig)-1]), nil
+}
+
 // ProofMsg is the data structure sent to the coordinator.
 type ProofMsg struct {
 	*ProofDetail `json:"zkProof"`
@@ -221,6 +268,14 @@ type TaskMsg struct {
 // ChunkTaskDetail is a type containing ChunkTask detail.
 type ChunkTaskDetail struct {
 	BlockHashes []common.Hash `json:"block_hashes"`
+	// BlockTraces optionally carries the pre-fetched traces of the blocks.
+	BlockTraces []*types.BlockTrace `json:"block_traces,omitempty"`
+	// TracesURL optionally points to the pre-fetched traces of the blocks, used if BlockTraces is empty.
+	TracesURL string `json:"traces_url,omitempty"`
+	// StartBlockNumber and EndBlockNumber optionally give the contiguous block range of the block hashes,
+	// so that the traces can be fetched by the range.
+	StartBlockNumber uint64 `json:"start_block_number,omitempty"`
+	EndBlockNumber   uint64 `json:"end_block_number,omitempty"`
 }
 
 // BatchTaskDetail is a type containing BatchTask detail.
@@ -229,6 +284,15 @@ type BatchTaskDetail struct {
 	ChunkProofs []*ChunkProof `json:"chunk_proofs"`
 }
 
+// SubTask identifies the sub-chunk of a chunk task split by a prover whose capacity is lower than the chunk,
+// the proof of each sub-chunk is submitted separately under the id of the chunk task.
+type SubTask struct {
+	Index            int    `json:"index"`
+	Count            int    `json:"count"`
+	StartBlockNumber uint64 `json:"start_block_number"`
+	EndBlockNumber   uint64 `json:"end_block_number"`
+}
+
 // ProofDetail is the message received from provers that contains zk proof, the status of
 // the proof generation succeeded, and an error message if proof generation failed.
 type ProofDetail struct {
@@ -282,6 +346,46 @@ type BatchProof struct {
 	GitVersion string `json:"git_version,omitempty"`
 }
 
+const (
+	// batchProofAccumulatorLen is the number of the accumulator field elements leading the instances of a batch proof.
+	batchProofAccumulatorLen = 12
+	// batchProofInstancesLen is the length of the instances of a batch proof, the accumulator followed by
+	// the public input hash with one byte per 32-byte field element.
+	batchProofInstancesLen = (batchProofAccumulatorLen + common.HashLength) * 32
+)
+
+// PublicInputHash decodes the public input hash the batch proof is computed for from its instances.
+func (ap *BatchProof) PublicInputHash() (common.Hash, error) {
+	if ap == nil {
+		return common.Hash{}, errors.New("agg_proof is nil")
+	}
+	if len(ap.Instances) != batchProofInstancesLen {
+		return common.Hash{}, fmt.Errorf("instances buffer has wrong length, expected: %d, got: %d", batchProofInstancesLen, len(ap.Instances))
+	}
+
+	var piHash common.Hash
+	for i := range piHash {
+		element := ap.Instances[(batchProofAccumulatorLen+i)*32 : (batchProofAccumulatorLen+i+1)*32]
+		for _, b := range element[:31] {
+			if b != 0 {
+				return common.Hash{}, fmt.Errorf("public input hash byte %d overflows its field element", i)
+			}
+		}
+		piHash[i] = element[31]
+	}
+	return piHash, nil
+}
+
+// BatchPublicInputHash computes the public input hash of a batch as the rollup contract does for finalizeBatchWithProof.
+func BatchPublicInputHash(chainID uint64, prevStateRoot, postStateRoot, withdrawRoot, dataHash common.Hash) common.Hash {
+	var chainIDBytes [8]byte
+	binary.BigEndian.PutUint64(chainIDBytes[:], chainID)
+	return crypto.Keccak256Hash(chainIDBytes[:], prevStateRoot[:], postStateRoot[:], withdrawRoot[:], dataHash[:])
+}
+
+// ErrMalformedProof is returned by SanityCheck when the proof or instances buffer isn't made of 32-byte words.
+var ErrMalformedProof = errors.New("malformed proof")
+
 // SanityCheck checks whether an BatchProof is in a legal format
 // TODO: change to check Proof&Instance when upgrading to snark verifier v0.4
 func (ap *BatchProof) SanityCheck() error {
@@ -293,7 +397,10 @@ func (ap *BatchProof) SanityCheck() error {
 		return errors.New("proof not ready")
 	}
 	if len(ap.Proof)%32 != 0 {
-		return fmt.Errorf("proof buffer has wrong length, expected: 32, got: %d", len(ap.Proof))
+		return fmt.Errorf("%w: proof buffer has wrong length, expected a multiple of 32, got: %d", ErrMalformedProof, len(ap.Proof))
+	}
+	if len(ap.Instances)%32 != 0 {
+		return fmt.Errorf("%w: instances buffer has wrong length, expected a multiple of 32, got: %d", ErrMalformedProof, len(ap.Instances))
 	}
 
 	return nil
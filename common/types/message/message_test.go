@@ -91,6 +91,38 @@ func TestProofMessageSignVerifyPublicKey(t *testing.T) {
 	assert.Equal(t, true, ok)
 }
 
+func TestTaskAssignmentSignAndVerify(t *testing.T) {
+	privkey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	pubkey := common.Bytes2Hex(crypto.CompressPubkey(&privkey.PublicKey))
+
+	task := &TaskAssignment{UUID: "uuid-1", TaskID: "task-1", TaskType: uint64(ProofTypeBatch), TaskData: `{"chunk_proofs":[]}`}
+	sig, err := task.Sign(privkey)
+	assert.NoError(t, err)
+
+	ok, err := task.Verify(sig, pubkey)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// the tampered task fails the verification.
+	tampered := *task
+	tampered.TaskData = `{"chunk_proofs":null}`
+	ok, err = tampered.Verify(sig, pubkey)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// the task signed by another key fails the verification.
+	otherKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	ok, err = task.Verify(sig, common.Bytes2Hex(crypto.CompressPubkey(&otherKey.PublicKey)))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// the unsigned task fails the verification.
+	_, err = task.Verify("", pubkey)
+	assert.Error(t, err)
+}
+
 func TestProofDetailHash(t *testing.T) {
 	proofDetail := &ProofDetail{
 		ID:     "testID",
@@ -156,3 +188,49 @@ func TestProofMsgPublicKey(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, common.Bytes2Hex(crypto.CompressPubkey(&privkey.PublicKey)), pk)
 }
+
+func TestBatchProofPublicInputHash(t *testing.T) {
+	piHash := BatchPublicInputHash(534352, common.HexToHash("0x01"), common.HexToHash("0x02"), common.HexToHash("0x03"), common.HexToHash("0x04"))
+	assert.Equal(t, crypto.Keccak256Hash(
+		common.FromHex("0x0000000000082750"),
+		common.HexToHash("0x01").Bytes(), common.HexToHash("0x02").Bytes(), common.HexToHash("0x03").Bytes(), common.HexToHash("0x04").Bytes(),
+	), piHash)
+
+	instances := make([]byte, (12+32)*32)
+	for i := range instances[:12*32] {
+		instances[i] = 0xff
+	}
+	for i, b := range piHash {
+		instances[(12+i)*32+31] = b
+	}
+	proof := &BatchProof{Instances: instances}
+	decoded, err := proof.PublicInputHash()
+	assert.NoError(t, err)
+	assert.Equal(t, piHash, decoded)
+
+	instances[(12+1)*32] = 1
+	_, err = proof.PublicInputHash()
+	assert.Error(t, err)
+
+	_, err = (&BatchProof{Instances: instances[:100]}).PublicInputHash()
+	assert.Error(t, err)
+	_, err = (*BatchProof)(nil).PublicInputHash()
+	assert.Error(t, err)
+}
+
+func TestBatchProofSanityCheck(t *testing.T) {
+	assert.Error(t, (*BatchProof)(nil).SanityCheck())
+
+	err := (&BatchProof{}).SanityCheck()
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrMalformedProof)
+
+	for _, length := range []int{31, 33} {
+		err = (&BatchProof{Proof: make([]byte, length)}).SanityCheck()
+		assert.ErrorIs(t, err, ErrMalformedProof)
+		err = (&BatchProof{Proof: make([]byte, 32), Instances: make([]byte, length)}).SanityCheck()
+		assert.ErrorIs(t, err, ErrMalformedProof)
+	}
+
+	assert.NoError(t, (&BatchProof{Proof: make([]byte, 64), Instances: make([]byte, 32)}).SanityCheck())
+}
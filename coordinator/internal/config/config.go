@@ -36,6 +36,9 @@ type Auth struct {
 	Secret                     string `json:"secret"`
 	ChallengeExpireDurationSec int    `json:"challenge_expire_duration_sec"`
 	LoginExpireDurationSec     int    `json:"login_expire_duration_sec"`
+	// TaskSigningKey is the private key in hex, without the 0x prefix, the assigned tasks are signed with. The provers
+	// verify the tasks with its compressed public key set as their coordinator.public_key. Empty means the tasks aren't signed.
+	TaskSigningKey string `json:"task_signing_key,omitempty"`
 }
 
 // Config load configuration items.
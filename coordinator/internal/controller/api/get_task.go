@@ -1,11 +1,14 @@
 package api
 
 import (
+	"crypto/ecdsa"
 	"fmt"
 	"math/rand"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/log"
 	"gorm.io/gorm"
 
 	"scroll-tech/common/types"
@@ -20,6 +23,8 @@ import (
 // GetTaskController the get prover task api controller
 type GetTaskController struct {
 	proverTasks map[message.ProofType]provertask.ProverTask
+	// signingKey signs the assigned tasks, nil means the tasks aren't signed.
+	signingKey *ecdsa.PrivateKey
 }
 
 // NewGetTaskController create a get prover task controller
@@ -34,6 +39,14 @@ func NewGetTaskController(cfg *config.Config, db *gorm.DB, vf *verifier.Verifier
 	ptc.proverTasks[message.ProofTypeChunk] = chunkProverTask
 	ptc.proverTasks[message.ProofTypeBatch] = batchProverTask
 
+	if cfg.Auth != nil && cfg.Auth.TaskSigningKey != "" {
+		signingKey, err := crypto.HexToECDSA(cfg.Auth.TaskSigningKey)
+		if err != nil {
+			log.Crit("invalid task signing key", "error", err)
+		}
+		ptc.signingKey = signingKey
+	}
+
 	return ptc
 }
 
@@ -67,9 +80,34 @@ func (ptc *GetTaskController) GetTasks(ctx *gin.Context) {
 		return
 	}
 
+	if err = ptc.sign(result); err != nil {
+		nerr := fmt.Errorf("sign prover task err:%w", err)
+		types.RenderFailure(ctx, types.ErrCoordinatorGetTaskFailure, nerr)
+		return
+	}
+
 	types.RenderSuccess(ctx, result)
 }
 
+// sign sets the signature of the task, so that the provers can verify the task is assigned by this coordinator.
+func (ptc *GetTaskController) sign(task *coordinatorType.GetTaskSchema) error {
+	if ptc.signingKey == nil {
+		return nil
+	}
+	assignment := &message.TaskAssignment{
+		UUID:     task.UUID,
+		TaskID:   task.TaskID,
+		TaskType: uint64(task.TaskType),
+		TaskData: task.TaskData,
+	}
+	signature, err := assignment.Sign(ptc.signingKey)
+	if err != nil {
+		return err
+	}
+	task.Signature = signature
+	return nil
+}
+
 func (ptc *GetTaskController) proofType(para *coordinatorType.GetTaskParameter) message.ProofType {
 	proofType := message.ProofType(para.TaskType)
 
@@ -155,7 +155,9 @@ func (cp *ChunkProverTask) formatProverTask(ctx context.Context, task *orm.Prove
 	}
 
 	taskDetail := message.ChunkTaskDetail{
-		BlockHashes: blockHashes,
+		BlockHashes:      blockHashes,
+		StartBlockNumber: wrappedBlocks[0].Header.Number.Uint64(),
+		EndBlockNumber:   wrappedBlocks[len(wrappedBlocks)-1].Header.Number.Uint64(),
 	}
 	blockHashesBytes, err := json.Marshal(taskDetail)
 	if err != nil {
@@ -13,4 +13,6 @@ type GetTaskSchema struct {
 	TaskID   string `json:"task_id"`
 	TaskType int    `json:"task_type"`
 	TaskData string `json:"task_data"`
+	// Signature is the signature over the task, only set if auth.task_signing_key is configured.
+	Signature string `json:"signature,omitempty"`
 }
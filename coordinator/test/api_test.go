@@ -14,6 +14,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 
@@ -49,6 +51,9 @@ var (
 	chunk         *types.Chunk
 
 	tokenTimeout int
+
+	// taskSigningPubKey is the compressed public key the coordinator signs the tasks with.
+	taskSigningPubKey string
 )
 
 func TestMain(m *testing.M) {
@@ -71,6 +76,9 @@ func setupCoordinator(t *testing.T, proversPerSession uint8, coordinatorURL stri
 	assert.NoError(t, migrate.ResetDB(sqlDB))
 
 	tokenTimeout = 6
+	taskSigningKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	taskSigningPubKey = common.Bytes2Hex(crypto.CompressPubkey(&taskSigningKey.PublicKey))
 	conf = &config.Config{
 		L2: &config.L2{
 			ChainID: 111,
@@ -86,6 +94,7 @@ func setupCoordinator(t *testing.T, proversPerSession uint8, coordinatorURL stri
 		Auth: &config.Auth{
 			ChallengeExpireDurationSec: tokenTimeout,
 			LoginExpireDurationSec:     tokenTimeout,
+			TaskSigningKey:             common.Bytes2Hex(crypto.FromECDSA(taskSigningKey)),
 		},
 	}
 
@@ -159,6 +159,16 @@ func (r *mockProver) getProverTask(t *testing.T, proofType message.ProofType) *t
 	assert.NotEmpty(t, result.Data.TaskID)
 	assert.NotEmpty(t, result.Data.TaskType)
 	assert.NotEmpty(t, result.Data.TaskData)
+
+	assignment := &message.TaskAssignment{
+		UUID:     result.Data.UUID,
+		TaskID:   result.Data.TaskID,
+		TaskType: uint64(result.Data.TaskType),
+		TaskData: result.Data.TaskData,
+	}
+	ok, err := assignment.Verify(result.Data.Signature, taskSigningPubKey)
+	assert.NoError(t, err)
+	assert.True(t, ok)
 	return &result.Data
 }
 
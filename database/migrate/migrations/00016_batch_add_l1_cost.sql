@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE batch
+ADD COLUMN commit_l1_gas_used BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN commit_l1_fee DECIMAL(78, 0) NOT NULL DEFAULT 0,
+ADD COLUMN finalize_l1_gas_used BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN finalize_l1_fee DECIMAL(78, 0) NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN batch.commit_l1_fee IS 'fee paid in wei by the confirmed commit tx, including the blob fee';
+COMMENT ON COLUMN batch.finalize_l1_fee IS 'fee paid in wei by the confirmed finalize tx';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE IF EXISTS batch
+DROP COLUMN commit_l1_gas_used,
+DROP COLUMN commit_l1_fee,
+DROP COLUMN finalize_l1_gas_used,
+DROP COLUMN finalize_l1_fee;
+
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE batch
+ADD COLUMN finalize_skip_reason SMALLINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN batch.finalize_skip_reason IS 'undefined, time-policy, proof-failed, upload-failed, proof-rejected, proof-mismatch, proof-malformed, parent-not-finalized';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE IF EXISTS batch
+DROP COLUMN finalize_skip_reason;
+
+-- +goose StatementEnd
@@ -0,0 +1,54 @@
+package prover
+
+import (
+	"runtime"
+
+	"github.com/scroll-tech/go-ethereum/log"
+
+	"scroll-tech/prover/config"
+)
+
+// applyGoMaxProcs overrides GOMAXPROCS if configured.
+func applyGoMaxProcs(cfg *config.AffinityConfig) {
+	if cfg == nil || cfg.GoMaxProcs <= 0 {
+		return
+	}
+	prev := runtime.GOMAXPROCS(cfg.GoMaxProcs)
+	log.Info("set GOMAXPROCS", "previous", prev, "current", cfg.GoMaxProcs)
+}
+
+// runPinned runs fn on a dedicated os thread bound to the given cpu set and waits for it to return.
+// fn is run on the calling goroutine when cpus is empty.
+func runPinned(cpus []int, fn func()) {
+	if len(cpus) == 0 {
+		fn()
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// The thread is never unlocked, so that the runtime discards it once the goroutine exits
+		// instead of handing a thread with a narrowed cpu set back to the scheduler.
+		runtime.LockOSThread()
+		if err := setThreadAffinity(cpus); err != nil {
+			log.Warn("failed to set thread affinity, running unpinned", "cpus", cpus, "err", err)
+		}
+		fn()
+	}()
+	<-done
+}
+
+func (r *Prover) traceFetchCPUs() []int {
+	if r.cfg.Affinity == nil {
+		return nil
+	}
+	return r.cfg.Affinity.TraceFetchCPUs
+}
+
+func (r *Prover) proveCPUs() []int {
+	if r.cfg.Affinity == nil {
+		return nil
+	}
+	return r.cfg.Affinity.ProveCPUs
+}
@@ -0,0 +1,14 @@
+package prover
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// setThreadAffinity binds the current os thread to the given cpu set.
+func setThreadAffinity(cpus []int) error {
+	var set unix.CPUSet
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+	return unix.SchedSetaffinity(0, &set)
+}
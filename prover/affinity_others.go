@@ -0,0 +1,12 @@
+//go:build !linux
+
+package prover
+
+import (
+	"errors"
+)
+
+// setThreadAffinity is not supported on this platform.
+func setThreadAffinity(cpus []int) error {
+	return errors.New("thread affinity is only supported on linux")
+}
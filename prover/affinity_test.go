@@ -0,0 +1,68 @@
+//go:build linux
+
+package prover
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+
+	"scroll-tech/prover/config"
+)
+
+func currentThreadCPUs(t *testing.T) []int {
+	var set unix.CPUSet
+	assert.NoError(t, unix.SchedGetaffinity(0, &set))
+	var cpus []int
+	for cpu := 0; cpu < runtime.NumCPU(); cpu++ {
+		if set.IsSet(cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus
+}
+
+func TestApplyGoMaxProcs(t *testing.T) {
+	prev := runtime.GOMAXPROCS(0)
+	defer runtime.GOMAXPROCS(prev)
+
+	// unconfigured, keep the runtime default.
+	applyGoMaxProcs(nil)
+	assert.Equal(t, prev, runtime.GOMAXPROCS(0))
+	applyGoMaxProcs(&config.AffinityConfig{})
+	assert.Equal(t, prev, runtime.GOMAXPROCS(0))
+
+	applyGoMaxProcs(&config.AffinityConfig{GoMaxProcs: prev + 1})
+	assert.Equal(t, prev+1, runtime.GOMAXPROCS(0))
+}
+
+func TestRunPinned(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	callerCPUs := currentThreadCPUs(t)
+
+	// unconfigured, fn runs on the caller's thread untouched.
+	var cpus []int
+	runPinned(nil, func() {
+		cpus = currentThreadCPUs(t)
+	})
+	assert.Equal(t, callerCPUs, cpus)
+
+	pinned := []int{runtime.NumCPU() - 1}
+	runPinned(pinned, func() {
+		cpus = currentThreadCPUs(t)
+	})
+	assert.Equal(t, pinned, cpus)
+
+	// the caller's thread keeps its cpu set.
+	assert.Equal(t, callerCPUs, currentThreadCPUs(t))
+
+	p := &Prover{cfg: &config.Config{}}
+	assert.Nil(t, p.traceFetchCPUs())
+	assert.Nil(t, p.proveCPUs())
+	p.cfg.Affinity = &config.AffinityConfig{TraceFetchCPUs: []int{0}, ProveCPUs: []int{1, 2}}
+	assert.Equal(t, []int{0}, p.traceFetchCPUs())
+	assert.Equal(t, []int{1, 2}, p.proveCPUs())
+}
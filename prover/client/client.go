@@ -4,10 +4,13 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"fmt"
+	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/scroll-tech/go-ethereum/log"
 
 	"scroll-tech/prover/config"
@@ -23,23 +26,40 @@ type CoordinatorClient struct {
 
 	proverName string
 	priv       *ecdsa.PrivateKey
+	// the proof types and the hardware of the prover reported at login.
+	proverTypes []message.ProofType
+	hardware    string
+
+	// re-login when the token is about to expire within refreshMargin.
+	refreshMargin time.Duration
+	tokenExpiry   time.Time
+
+	// bounds the number of concurrent GetTask and SubmitProof requests, nil means no limit.
+	requestSlots chan struct{}
 
 	mu sync.Mutex
+
+	metrics *clientMetrics
 }
 
 // NewCoordinatorClient constructs a new CoordinatorClient.
-func NewCoordinatorClient(cfg *config.CoordinatorConfig, proverName string, priv *ecdsa.PrivateKey) (*CoordinatorClient, error) {
+func NewCoordinatorClient(cfg *config.CoordinatorConfig, proverName string, priv *ecdsa.PrivateKey, reg prometheus.Registerer) (*CoordinatorClient, error) {
 	client := resty.New().
 		SetTimeout(time.Duration(cfg.ConnectionTimeoutSec) * time.Second).
 		SetRetryCount(cfg.RetryCount).
 		SetRetryWaitTime(time.Duration(cfg.RetryWaitTimeSec) * time.Second).
 		SetBaseURL(cfg.BaseURL).
-		AddRetryAfterErrorCondition().
+		// retry on the error responses unless the coordinator hints when to retry, the conditions are ORed
+		// so this replaces AddRetryAfterErrorCondition.
 		AddRetryCondition(func(response *resty.Response, err error) bool {
 			if err != nil {
 				log.Warn("Encountered an error while sending the request. Retrying...", "error", err)
 				return true
 			}
+			// leave it to the caller to wait as long as the coordinator asks for.
+			if retryAfter(response) > 0 {
+				return false
+			}
 			return response.IsError()
 		})
 
@@ -47,13 +67,50 @@ func NewCoordinatorClient(cfg *config.CoordinatorConfig, proverName string, priv
 		"base url", cfg.BaseURL,
 		"connection timeout (second)", cfg.ConnectionTimeoutSec,
 		"retry count", cfg.RetryCount,
-		"retry wait time (second)", cfg.RetryWaitTimeSec)
+		"retry wait time (second)", cfg.RetryWaitTimeSec,
+		"token refresh margin (second)", cfg.TokenRefreshMarginSec,
+		"max concurrent requests", cfg.MaxConcurrentRequests)
+
+	c := &CoordinatorClient{
+		client:        client,
+		proverName:    proverName,
+		priv:          priv,
+		refreshMargin: time.Duration(cfg.TokenRefreshMarginSec) * time.Second,
+		metrics:       initClientMetrics(reg),
+	}
+	if cfg.MaxConcurrentRequests > 0 {
+		c.requestSlots = make(chan struct{}, cfg.MaxConcurrentRequests)
+	}
+	return c, nil
+}
+
+// acquireRequestSlot waits for a free slot under the request concurrency cap,
+// the returned function releases the slot.
+func (c *CoordinatorClient) acquireRequestSlot(ctx context.Context) (func(), error) {
+	if c.requestSlots == nil {
+		return func() {}, nil
+	}
 
-	return &CoordinatorClient{
-		client:     client,
-		proverName: proverName,
-		priv:       priv,
-	}, nil
+	start := time.Now()
+	defer func() {
+		c.metrics.coordinatorRequestWaitSeconds.Observe(time.Since(start).Seconds())
+	}()
+
+	select {
+	case c.requestSlots <- struct{}{}:
+		return func() { <-c.requestSlots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("wait for coordinator request slot: %w", ctx.Err())
+	}
+}
+
+// SetProverInfo sets the proof types and the hardware of the prover reported at the next login.
+func (c *CoordinatorClient) SetProverInfo(proverTypes []message.ProofType, hardware string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.proverTypes = proverTypes
+	c.hardware = hardware
 }
 
 // Login completes the entire login process in one function call.
@@ -61,6 +118,57 @@ func (c *CoordinatorClient) Login(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	return c.login(ctx)
+}
+
+// token returns the current token, waiting for the ongoing login if any.
+func (c *CoordinatorClient) token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client.Token
+}
+
+// relogin logs in again since the coordinator rejected the token, unless the token is already replaced by a
+// concurrent request re-logging in.
+func (c *CoordinatorClient) relogin(ctx context.Context, rejectedToken string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client.Token != rejectedToken {
+		return nil
+	}
+	return c.login(ctx)
+}
+
+// isAuthError reports whether the coordinator rejected the token of the request, either expired or invalid,
+// e.g. the coordinator is restarted with another jwt secret.
+func isAuthError(errCode int) bool {
+	return errCode == types.ErrJWTTokenExpired || errCode == types.ErrJWTCommonErr
+}
+
+// refreshTokenIfNeeded re-logins if the token expires within the refresh margin.
+// Concurrent callers wait for the ongoing refresh instead of refreshing again.
+func (c *CoordinatorClient) refreshTokenIfNeeded(ctx context.Context) {
+	if c.refreshMargin <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tokenExpiry.IsZero() || time.Until(c.tokenExpiry) > c.refreshMargin {
+		return
+	}
+	log.Info("JWT is about to expire, attempting to refresh", "expiry", c.tokenExpiry)
+	if err := c.login(ctx); err != nil {
+		// keep using the current token, it's re-logged in once it's expired.
+		log.Warn("failed to refresh JWT", "expiry", c.tokenExpiry, "error", err)
+		return
+	}
+	log.Info("refresh JWT success", "expiry", c.tokenExpiry)
+}
+
+func (c *CoordinatorClient) login(ctx context.Context) error {
 	var challengeResult ChallengeResponse
 
 	// Get random string
@@ -93,14 +201,12 @@ func (c *CoordinatorClient) Login(ctx context.Context) error {
 
 	// Login to coordinator
 	loginReq := &LoginRequest{
-		Message: struct {
-			Challenge     string `json:"challenge"`
-			ProverName    string `json:"prover_name"`
-			ProverVersion string `json:"prover_version"`
-		}{
+		Message: LoginMessage{
 			Challenge:     authMsg.Identity.Challenge,
 			ProverName:    authMsg.Identity.ProverName,
 			ProverVersion: authMsg.Identity.ProverVersion,
+			ProverTypes:   c.proverTypes,
+			Hardware:      c.hardware,
 		},
 		Signature: authMsg.Signature,
 	}
@@ -130,14 +236,58 @@ func (c *CoordinatorClient) Login(ctx context.Context) error {
 	// store JWT token for future requests
 	c.client.SetAuthToken(loginResult.Data.Token)
 
+	expiry, err := time.Parse(time.RFC3339, loginResult.Data.Time)
+	if err != nil {
+		// the token is only re-logged in once it's expired.
+		log.Warn("failed to parse JWT expiry", "time", loginResult.Data.Time, "error", err)
+		expiry = time.Time{}
+	}
+	c.tokenExpiry = expiry
+
 	return nil
 }
 
+// retryAfter parses the Retry-After header of a rate limited response, in either delay seconds or an http date.
+// It returns 0 if the response isn't rate limited or has no valid hint.
+func retryAfter(resp *resty.Response) time.Duration {
+	if resp == nil || (resp.StatusCode() != http.StatusTooManyRequests && resp.StatusCode() != http.StatusServiceUnavailable) {
+		return 0
+	}
+	value := resp.Header().Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
 // GetTask sends a request to the coordinator to get prover task.
 func (c *CoordinatorClient) GetTask(ctx context.Context, req *GetTaskRequest) (*GetTaskResponse, error) {
+	release, err := c.acquireRequestSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return c.getTask(ctx, req, false)
+}
+
+// getTask sends the request, re-logging in and retrying once if the coordinator rejects the token.
+func (c *CoordinatorClient) getTask(ctx context.Context, req *GetTaskRequest, relogged bool) (*GetTaskResponse, error) {
+	c.refreshTokenIfNeeded(ctx)
+
 	var result GetTaskResponse
 
+	token := c.token()
 	resp, err := c.client.R().
+		SetAuthToken(token).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
 		SetResult(&result).
@@ -151,13 +301,16 @@ func (c *CoordinatorClient) GetTask(ctx context.Context, req *GetTaskRequest) (*
 		return nil, fmt.Errorf("failed to get task, status code: %v", resp.StatusCode())
 	}
 
-	if result.ErrCode == types.ErrJWTTokenExpired {
-		log.Info("JWT expired, attempting to re-login")
-		if err := c.Login(ctx); err != nil {
-			return nil, fmt.Errorf("JWT expired, re-login failed: %w", err)
+	if isAuthError(result.ErrCode) && !relogged {
+		log.Info("JWT rejected, attempting to re-login", "error code", result.ErrCode, "error message", result.ErrMsg)
+		if err := c.relogin(ctx, token); err != nil {
+			return nil, fmt.Errorf("JWT rejected, re-login failed: %w", err)
 		}
 		log.Info("re-login success")
-		return c.GetTask(ctx, req)
+		return c.getTask(ctx, req, true)
+	}
+	if result.ErrCode == types.ErrCoordinatorDraining {
+		return nil, fmt.Errorf("%w: %v", ErrCoordinatorDraining, result.ErrMsg)
 	}
 	if result.ErrCode != types.Success {
 		return nil, fmt.Errorf("error code: %v, error message: %v", result.ErrCode, result.ErrMsg)
@@ -168,9 +321,24 @@ func (c *CoordinatorClient) GetTask(ctx context.Context, req *GetTaskRequest) (*
 
 // SubmitProof sends a request to the coordinator to submit proof.
 func (c *CoordinatorClient) SubmitProof(ctx context.Context, req *SubmitProofRequest) error {
+	release, err := c.acquireRequestSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return c.submitProof(ctx, req, false)
+}
+
+// submitProof sends the request, re-logging in and retrying once if the coordinator rejects the token.
+func (c *CoordinatorClient) submitProof(ctx context.Context, req *SubmitProofRequest, relogged bool) error {
+	c.refreshTokenIfNeeded(ctx)
+
 	var result SubmitProofResponse
 
+	token := c.token()
 	resp, err := c.client.R().
+		SetAuthToken(token).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
 		SetResult(&result).
@@ -183,17 +351,67 @@ func (c *CoordinatorClient) SubmitProof(ctx context.Context, req *SubmitProofReq
 
 	if resp.StatusCode() != 200 {
 		log.Error("failed to submit proof", "status code", resp.StatusCode())
-		return fmt.Errorf("failed to submit proof, status code not 200: %w", ErrCoordinatorConnect)
+		err = fmt.Errorf("failed to submit proof, status code not 200: %w", ErrCoordinatorConnect)
+		if wait := retryAfter(resp); wait > 0 {
+			return &RetryAfterError{Wait: wait, Err: err}
+		}
+		return err
+	}
+
+	if isAuthError(result.ErrCode) && !relogged {
+		log.Info("JWT rejected, attempting to re-login", "error code", result.ErrCode, "error message", result.ErrMsg)
+		if err := c.relogin(ctx, token); err != nil {
+			log.Error("JWT rejected, re-login failed", "error", err)
+			return fmt.Errorf("JWT rejected, re-login failed: %w", ErrCoordinatorConnect)
+		}
+		log.Info("re-login success")
+		return c.submitProof(ctx, req, true)
+	}
+
+	if result.ErrCode != types.Success {
+		return fmt.Errorf("error code: %v, error message: %v", result.ErrCode, result.ErrMsg)
+	}
+
+	return nil
+}
+
+// RenewLease sends a request to the coordinator to renew the lease of the task the prover is proving,
+// so that the task isn't reassigned to another prover. It isn't bounded by MaxConcurrentRequests
+// since it's sent while the prover is busy proving.
+func (c *CoordinatorClient) RenewLease(ctx context.Context, req *RenewLeaseRequest) error {
+	return c.renewLease(ctx, req, false)
+}
+
+// renewLease sends the request, re-logging in and retrying once if the coordinator rejects the token.
+func (c *CoordinatorClient) renewLease(ctx context.Context, req *RenewLeaseRequest, relogged bool) error {
+	c.refreshTokenIfNeeded(ctx)
+
+	var result RenewLeaseResponse
+
+	token := c.token()
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetAuthToken(token).
+		SetHeader("Content-Type", "application/json").
+		SetBody(req).
+		SetResult(&result).
+		Post("/coordinator/v1/renew_lease")
+
+	if err != nil {
+		return fmt.Errorf("renew lease request failed: %w", ErrCoordinatorConnect)
+	}
+
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("failed to renew lease, status code: %v", resp.StatusCode())
 	}
 
-	if result.ErrCode == types.ErrJWTTokenExpired {
-		log.Info("JWT expired, attempting to re-login")
-		if err := c.Login(ctx); err != nil {
-			log.Error("JWT expired, re-login failed", "error", err)
-			return fmt.Errorf("JWT expired, re-login failed: %w", ErrCoordinatorConnect)
+	if isAuthError(result.ErrCode) && !relogged {
+		log.Info("JWT rejected, attempting to re-login", "error code", result.ErrCode, "error message", result.ErrMsg)
+		if err := c.relogin(ctx, token); err != nil {
+			return fmt.Errorf("JWT rejected, re-login failed: %w", err)
 		}
 		log.Info("re-login success")
-		return c.SubmitProof(ctx, req)
+		return c.renewLease(ctx, req, true)
 	}
 
 	if result.ErrCode != types.Success {
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type clientMetrics struct {
+	coordinatorRequestWaitSeconds prometheus.Histogram
+}
+
+var (
+	initClientMetricOnce sync.Once
+	clientMetric         *clientMetrics
+)
+
+func initClientMetrics(reg prometheus.Registerer) *clientMetrics {
+	initClientMetricOnce.Do(func() {
+		clientMetric = &clientMetrics{
+			coordinatorRequestWaitSeconds: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+				Name:    "prover_coordinator_request_wait_seconds",
+				Help:    "The time spent waiting for a free slot under the coordinator request concurrency cap",
+				Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+			}),
+		}
+	})
+	return clientMetric
+}
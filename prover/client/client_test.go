@@ -0,0 +1,341 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"scroll-tech/common/types"
+	"scroll-tech/common/types/message"
+	"scroll-tech/common/version"
+
+	"scroll-tech/prover/config"
+)
+
+// mockCoordinator issues a new token on every login, the n-th token expires after tokenTTL(n).
+type mockCoordinator struct {
+	tokenTTL func(n int64) time.Duration
+
+	logins       int64
+	loginReq     atomic.Value
+	currentToken atomic.Value
+	expiredCalls int64
+	// the coordinator rejects the next request, or all the requests, as if it's restarted with another jwt secret.
+	rejectNext    int64
+	rejectAll     int64
+	rejectedCalls int64
+
+	// the time taken to serve a task or proof request, and the number of such requests in flight.
+	requestDelay time.Duration
+	inFlight     int64
+	maxInFlight  int64
+}
+
+func (m *mockCoordinator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/coordinator/v1/challenge":
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"errcode": types.Success,
+			"data":    map[string]interface{}{"token": "challenge"},
+		})
+	case "/coordinator/v1/login":
+		var req LoginRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		m.loginReq.Store(&req)
+		n := atomic.AddInt64(&m.logins, 1)
+		token := fmt.Sprintf("token-%d", n)
+		m.currentToken.Store(token)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"errcode": types.Success,
+			"data":    map[string]interface{}{"token": token, "time": time.Now().Add(m.tokenTTL(n))},
+		})
+	default:
+		if atomic.LoadInt64(&m.rejectAll) == 1 || atomic.CompareAndSwapInt64(&m.rejectNext, 1, 0) {
+			atomic.AddInt64(&m.rejectedCalls, 1)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": types.ErrJWTCommonErr, "errmsg": "token is invalid"})
+			return
+		}
+		if strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") != m.currentToken.Load() {
+			atomic.AddInt64(&m.expiredCalls, 1)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": types.ErrJWTTokenExpired})
+			return
+		}
+		inFlight := atomic.AddInt64(&m.inFlight, 1)
+		for {
+			maxInFlight := atomic.LoadInt64(&m.maxInFlight)
+			if inFlight <= maxInFlight || atomic.CompareAndSwapInt64(&m.maxInFlight, maxInFlight, inFlight) {
+				break
+			}
+		}
+		time.Sleep(m.requestDelay)
+		atomic.AddInt64(&m.inFlight, -1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"errcode": types.Success,
+			"data":    map[string]interface{}{"uuid": "uuid", "task_id": "task"},
+		})
+	}
+}
+
+func newTestClient(t *testing.T, url string, refreshMarginSec int) *CoordinatorClient {
+	return newTestClientWithConfig(t, &config.CoordinatorConfig{
+		BaseURL:               url,
+		ConnectionTimeoutSec:  5,
+		TokenRefreshMarginSec: refreshMarginSec,
+	})
+}
+
+func newTestClientWithConfig(t *testing.T, cfg *config.CoordinatorConfig) *CoordinatorClient {
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	c, err := NewCoordinatorClient(cfg, "test-prover", priv, nil)
+	assert.NoError(t, err)
+	return c
+}
+
+func TestTokenRefresh(t *testing.T) {
+	// the first token is about to expire, the refreshed one lasts long.
+	coordinator := &mockCoordinator{tokenTTL: func(n int64) time.Duration {
+		if n == 1 {
+			return 30 * time.Second
+		}
+		return time.Hour
+	}}
+	server := httptest.NewServer(coordinator)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL, 60)
+	ctx := context.Background()
+	assert.NoError(t, c.Login(ctx))
+	assert.EqualValues(t, 1, atomic.LoadInt64(&coordinator.logins))
+
+	// the token is refreshed before the request is sent.
+	_, err := c.GetTask(ctx, &GetTaskRequest{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt64(&coordinator.logins))
+
+	// the refreshed token is far from expiry.
+	assert.NoError(t, c.SubmitProof(ctx, &SubmitProofRequest{}))
+	assert.EqualValues(t, 2, atomic.LoadInt64(&coordinator.logins))
+	assert.EqualValues(t, 0, atomic.LoadInt64(&coordinator.expiredCalls))
+}
+
+func TestTokenRefreshConcurrent(t *testing.T) {
+	coordinator := &mockCoordinator{tokenTTL: func(n int64) time.Duration {
+		if n == 1 {
+			return 30 * time.Second
+		}
+		return time.Hour
+	}}
+	server := httptest.NewServer(coordinator)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL, 60)
+	ctx := context.Background()
+	assert.NoError(t, c.Login(ctx))
+
+	// concurrent workers refresh the token only once.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetTask(ctx, &GetTaskRequest{})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 2, atomic.LoadInt64(&coordinator.logins))
+	assert.EqualValues(t, 0, atomic.LoadInt64(&coordinator.expiredCalls))
+}
+
+func TestTokenRefreshDisabled(t *testing.T) {
+	coordinator := &mockCoordinator{tokenTTL: func(n int64) time.Duration {
+		return 30 * time.Second
+	}}
+	server := httptest.NewServer(coordinator)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL, 0)
+	ctx := context.Background()
+	assert.NoError(t, c.Login(ctx))
+
+	_, err := c.GetTask(ctx, &GetTaskRequest{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt64(&coordinator.logins))
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	coordinator := &mockCoordinator{
+		tokenTTL:     func(n int64) time.Duration { return time.Hour },
+		requestDelay: 50 * time.Millisecond,
+	}
+	server := httptest.NewServer(coordinator)
+	defer server.Close()
+
+	c := newTestClientWithConfig(t, &config.CoordinatorConfig{
+		BaseURL:               server.URL,
+		ConnectionTimeoutSec:  5,
+		MaxConcurrentRequests: 2,
+	})
+	ctx := context.Background()
+
+	// the re-login on an expired token doesn't take another slot.
+	assert.NoError(t, c.SubmitProof(ctx, &SubmitProofRequest{}))
+	assert.EqualValues(t, 1, atomic.LoadInt64(&coordinator.logins))
+	assert.EqualValues(t, 1, atomic.LoadInt64(&coordinator.expiredCalls))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				assert.NoError(t, c.SubmitProof(ctx, &SubmitProofRequest{}))
+				return
+			}
+			_, err := c.GetTask(ctx, &GetTaskRequest{})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 2, atomic.LoadInt64(&coordinator.maxInFlight))
+
+	// a request waiting for a slot gives up once the context is done.
+	c.requestSlots <- struct{}{}
+	c.requestSlots <- struct{}{}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err := c.SubmitProof(timeoutCtx, &SubmitProofRequest{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSubmitProofRetryAfter(t *testing.T) {
+	var submitCalls int64
+	var retryAfter atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&submitCalls, 1)
+		if value := retryAfter.Load().(string); value != "" {
+			w.Header().Set("Retry-After", value)
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	c := newTestClientWithConfig(t, &config.CoordinatorConfig{
+		BaseURL:              server.URL,
+		ConnectionTimeoutSec: 5,
+		RetryCount:           2,
+		RetryWaitTimeSec:     1,
+	})
+	ctx := context.Background()
+
+	// the hint is surfaced to the caller rather than retried right away.
+	retryAfter.Store("3")
+	err := c.SubmitProof(ctx, &SubmitProofRequest{})
+	assert.ErrorIs(t, err, ErrCoordinatorConnect)
+	wait, ok := RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, wait)
+	assert.EqualValues(t, 1, atomic.LoadInt64(&submitCalls))
+
+	// the hint can be an http date.
+	retryAfter.Store(time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat))
+	wait, ok = RetryAfter(c.SubmitProof(ctx, &SubmitProofRequest{}))
+	assert.True(t, ok)
+	assert.Greater(t, wait, 5*time.Second)
+	assert.LessOrEqual(t, wait, 10*time.Second)
+
+	// the existing retries apply without a hint.
+	atomic.StoreInt64(&submitCalls, 0)
+	retryAfter.Store("")
+	err = c.SubmitProof(ctx, &SubmitProofRequest{})
+	assert.ErrorIs(t, err, ErrCoordinatorConnect)
+	_, ok = RetryAfter(err)
+	assert.False(t, ok)
+	assert.EqualValues(t, 3, atomic.LoadInt64(&submitCalls))
+}
+
+func TestReloginOnAuthError(t *testing.T) {
+	coordinator := &mockCoordinator{tokenTTL: func(n int64) time.Duration { return time.Hour }}
+	server := httptest.NewServer(coordinator)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL, 0)
+	ctx := context.Background()
+	assert.NoError(t, c.Login(ctx))
+
+	// the rejected token is replaced by a re-login, then the request is retried.
+	atomic.StoreInt64(&coordinator.rejectNext, 1)
+	_, err := c.GetTask(ctx, &GetTaskRequest{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt64(&coordinator.logins))
+	assert.EqualValues(t, 1, atomic.LoadInt64(&coordinator.rejectedCalls))
+
+	atomic.StoreInt64(&coordinator.rejectNext, 1)
+	assert.NoError(t, c.SubmitProof(ctx, &SubmitProofRequest{}))
+	assert.EqualValues(t, 3, atomic.LoadInt64(&coordinator.logins))
+
+	atomic.StoreInt64(&coordinator.rejectNext, 1)
+	assert.NoError(t, c.RenewLease(ctx, &RenewLeaseRequest{}))
+	assert.EqualValues(t, 4, atomic.LoadInt64(&coordinator.logins))
+
+	// the expired token of the concurrent requests is replaced by a single re-login.
+	coordinator.currentToken.Store("rotated")
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, getErr := c.GetTask(ctx, &GetTaskRequest{})
+			assert.NoError(t, getErr)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 5, atomic.LoadInt64(&coordinator.logins))
+
+	// the request fails rather than re-logging in forever if the new token is rejected too.
+	atomic.StoreInt64(&coordinator.rejectAll, 1)
+	_, err = c.GetTask(ctx, &GetTaskRequest{})
+	assert.ErrorContains(t, err, "token is invalid")
+	assert.EqualValues(t, 6, atomic.LoadInt64(&coordinator.logins))
+}
+
+func TestLoginProverInfo(t *testing.T) {
+	coordinator := &mockCoordinator{tokenTTL: func(int64) time.Duration { return time.Hour }}
+	server := httptest.NewServer(coordinator)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL, 0)
+	c.SetProverInfo([]message.ProofType{message.ProofTypeBatch}, "linux/amd64, 8 cpus")
+	assert.NoError(t, c.Login(context.Background()))
+
+	req := coordinator.loginReq.Load().(*LoginRequest)
+	assert.Equal(t, "test-prover", req.Message.ProverName)
+	assert.Equal(t, version.Version, req.Message.ProverVersion)
+	assert.Equal(t, []message.ProofType{message.ProofTypeBatch}, req.Message.ProverTypes)
+	assert.Equal(t, "linux/amd64, 8 cpus", req.Message.Hardware)
+
+	// the prover info isn't signed, the signature still covers the signed fields only.
+	authMsg := &message.AuthMsg{
+		Identity: &message.Identity{
+			Challenge:     req.Message.Challenge,
+			ProverName:    req.Message.ProverName,
+			ProverVersion: req.Message.ProverVersion,
+		},
+		Signature: req.Signature,
+	}
+	ok, err := authMsg.Verify()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
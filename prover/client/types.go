@@ -2,12 +2,42 @@ package client
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"scroll-tech/common/types/message"
 )
 
-// ErrCoordinatorConnect connect to coordinator error
-var ErrCoordinatorConnect = errors.New("connect coordinator error")
+var (
+	// ErrCoordinatorConnect connect to coordinator error
+	ErrCoordinatorConnect = errors.New("connect coordinator error")
+	// ErrCoordinatorDraining the coordinator asks the prover to stop fetching new tasks
+	ErrCoordinatorDraining = errors.New("coordinator is draining")
+)
+
+// RetryAfterError is returned when the coordinator rate limits a request with a retry-after hint,
+// Wait is how long the prover should wait before sending the request again.
+type RetryAfterError struct {
+	Wait time.Duration
+	Err  error
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("retry after %v: %v", e.Wait, e.Err)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter returns the wait hinted by the coordinator if err carries one.
+func RetryAfter(err error) (time.Duration, bool) {
+	var retryAfterErr *RetryAfterError
+	if errors.As(err, &retryAfterErr) && retryAfterErr.Wait > 0 {
+		return retryAfterErr.Wait, true
+	}
+	return 0, false
+}
 
 // ChallengeResponse defines the response structure for random API
 type ChallengeResponse struct {
@@ -21,12 +51,19 @@ type ChallengeResponse struct {
 
 // LoginRequest defines the request structure for login API
 type LoginRequest struct {
-	Message struct {
-		Challenge     string `json:"challenge"`
-		ProverName    string `json:"prover_name"`
-		ProverVersion string `json:"prover_version"`
-	} `json:"message"`
-	Signature string `json:"signature"`
+	Message   LoginMessage `json:"message"`
+	Signature string       `json:"signature"`
+}
+
+// LoginMessage defines the message of the login request, only the challenge, the prover name and
+// the prover version are signed.
+type LoginMessage struct {
+	Challenge     string `json:"challenge"`
+	ProverName    string `json:"prover_name"`
+	ProverVersion string `json:"prover_version"`
+	// ProverTypes and Hardware describe the prover for the fleet management of the coordinator.
+	ProverTypes []message.ProofType `json:"prover_types,omitempty"`
+	Hardware    string              `json:"hardware,omitempty"`
 }
 
 // LoginResponse defines the response structure for login API
@@ -55,6 +92,8 @@ type GetTaskResponse struct {
 		TaskID   string `json:"task_id"`
 		TaskType int    `json:"task_type"`
 		TaskData string `json:"task_data"`
+		// Signature is the signature of the coordinator over the task, only set if the coordinator has auth.task_signing_key configured.
+		Signature string `json:"signature,omitempty"`
 	} `json:"data"`
 }
 
@@ -67,6 +106,11 @@ type SubmitProofRequest struct {
 	Proof       string `json:"proof"`
 	FailureType int    `json:"failure_type,omitempty"`
 	FailureMsg  string `json:"failure_msg,omitempty"`
+	// ParamsHash is the hash of the circuit params the proof is produced with, see core.ProverCore.ParamsHash.
+	// It's informational only, the coordinator ignores it.
+	ParamsHash string `json:"params_hash,omitempty"`
+	// SubTask identifies the sub-chunk the proof is of if the prover split the chunk task, nil means the whole task.
+	SubTask *message.SubTask `json:"sub_task,omitempty"`
 }
 
 // SubmitProofResponse defines the response structure for the SubmitProof API.
@@ -74,3 +118,16 @@ type SubmitProofResponse struct {
 	ErrCode int    `json:"errcode"`
 	ErrMsg  string `json:"errmsg"`
 }
+
+// RenewLeaseRequest defines the request structure for the RenewLease API.
+type RenewLeaseRequest struct {
+	UUID     string `json:"uuid"`
+	TaskID   string `json:"task_id"`
+	TaskType int    `json:"task_type"`
+}
+
+// RenewLeaseResponse defines the response structure for the RenewLease API.
+type RenewLeaseResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
@@ -2,10 +2,16 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/scroll-tech/go-ethereum/log"
 	"github.com/urfave/cli/v2"
 
@@ -44,12 +50,13 @@ func action(ctx *cli.Context) error {
 	}
 
 	// Create prover
-	r, err := prover.NewProver(context.Background(), cfg)
+	r, err := prover.NewProver(context.Background(), cfg, prometheus.DefaultRegisterer)
 	if err != nil {
 		return err
 	}
 	// Start prover.
 	r.Start()
+	startMetricsServer(ctx)
 
 	defer r.Stop()
 	log.Info(
@@ -62,12 +69,45 @@ func action(ctx *cli.Context) error {
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt)
 
-	// Wait until the interrupt signal is received from an OS signal.
-	<-interrupt
+	// Wait until the interrupt signal is received from an OS signal, or the prover stops on a fatal error or once drained.
+	select {
+	case <-interrupt:
+		if cfg.GracefulStopTimeoutSec > 0 {
+			log.Info("stop prover gracefully", "timeout sec", cfg.GracefulStopTimeoutSec)
+			if err = r.StopGracefully(time.Duration(cfg.GracefulStopTimeoutSec) * time.Second); err != nil {
+				log.Warn("failed to stop prover gracefully", "error", err)
+			}
+		}
+	case <-r.Done():
+		return r.Err()
+	}
 
 	return nil
 }
 
+// startMetricsServer serves the metrics of the prover if enabled, the prover has no db for the probes of observability.Server.
+func startMetricsServer(ctx *cli.Context) {
+	if !ctx.Bool(utils.MetricsEnabled.Name) {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	address := net.JoinHostPort(ctx.String(utils.MetricsAddr.Name), ctx.String(utils.MetricsPort.Name))
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: time.Minute,
+	}
+	log.Info("Starting metrics server", "address", address)
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Crit("run metrics http server failure", "error", err)
+		}
+	}()
+}
+
 // Run the prover cmd func.
 func Run() {
 	if err := app.Run(os.Args); err != nil {
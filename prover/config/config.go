@@ -13,13 +13,45 @@ import (
 
 // Config loads prover configuration items.
 type Config struct {
-	ProverName       string             `json:"prover_name"`
-	KeystorePath     string             `json:"keystore_path"`
-	KeystorePassword string             `json:"keystore_password"`
-	Core             *ProverCoreConfig  `json:"core"`
-	DBPath           string             `json:"db_path"`
-	Coordinator      *CoordinatorConfig `json:"coordinator"`
-	L2Geth           *L2GethConfig      `json:"l2geth,omitempty"` // only for chunk_prover
+	ProverName           string               `json:"prover_name"`
+	KeystorePath         string               `json:"keystore_path"`
+	KeystorePassword     string               `json:"keystore_password"`
+	Core                 *ProverCoreConfig    `json:"core"`
+	DBPath               string               `json:"db_path"`
+	DBCompactIntervalSec uint64               `json:"db_compact_interval_sec,omitempty"` // 0 means never compact the db
+	Coordinator          *CoordinatorConfig   `json:"coordinator"`
+	L2Geth               *L2GethConfig        `json:"l2geth,omitempty"` // only for chunk_prover
+	ProofLimit           *ProofLimitConfig    `json:"proof_limit,omitempty"`
+	Affinity             *AffinityConfig      `json:"affinity,omitempty"`
+	Watchdog             *WatchdogConfig      `json:"watchdog,omitempty"`
+	ResourceGuard        *ResourceGuardConfig `json:"resource_guard,omitempty"`
+	MaxSubmitRetries     int                  `json:"max_submit_retries,omitempty"`      // 0 means retry submitting a proof until the coordinator accepts or rejects it
+	ExitOnFatalError     bool                 `json:"exit_on_fatal_error,omitempty"`     // stop the prover on the errors it can't recover from, e.g. a corrupted stack db
+	ExitOnDrain          bool                 `json:"exit_on_drain,omitempty"`           // stop the prover once it's drained on the request of the coordinator
+	EpochBoundaries      []uint64             `json:"epoch_boundaries,omitempty"`        // the first block numbers of the epochs, e.g. hardforks, a chunk spanning a boundary is rejected
+	CheckChunkProofOrder bool                 `json:"check_chunk_proof_order,omitempty"` // reorder the chunk proofs of a batch task by the chunk infos, rejecting the task on a mismatch
+	// MaxChunkBlocks splits a chunk task of more blocks into sub-chunks of at most this many blocks, proved and submitted
+	// separately, 0 means never split. It requires the coordinator to accept the proofs of sub-chunks.
+	MaxChunkBlocks uint64 `json:"max_chunk_blocks,omitempty"`
+	// MaxProveRetry is the number of times a task is proved again after the prover crashed proving it, e.g. a circuit
+	// panic, before the task is given up as a proving panic. nil means the default of 2, 0 gives up on the first crash.
+	MaxProveRetry *int `json:"max_prove_retry,omitempty"`
+	// Concurrency is the number of tasks proved concurrently, e.g. one for each gpu, sharing the coordinator connection
+	// and the stack. 0 means 1.
+	Concurrency int `json:"concurrency,omitempty"`
+	// AsyncSubmit submits the proofs in the background, so that proving the next task isn't blocked on a slow coordinator.
+	// The proofs are kept in the submit queue of the db until they're accepted or given up after MaxSubmitRetries.
+	AsyncSubmit bool `json:"async_submit,omitempty"`
+	// OrderedSubmit submits the proofs of the concurrent workers in the order their tasks are taken, a worker done
+	// proving waits for the workers of the earlier tasks to submit theirs first. It can't be used with AsyncSubmit.
+	OrderedSubmit bool `json:"ordered_submit,omitempty"`
+	// GracefulStopTimeoutSec waits this long for the tasks being proved to be proved and submitted once interrupted,
+	// 0 means stop right away.
+	GracefulStopTimeoutSec int `json:"graceful_stop_timeout_sec,omitempty"`
+	// ProveTimeoutSec fails the task once the prover core takes longer than this to prove it, 0 means no timeout.
+	// The prover core can't be interrupted, so the timed out proving keeps running in the background until it's done,
+	// and no new proving is started in its place until then.
+	ProveTimeoutSec uint64 `json:"prove_timeout_sec,omitempty"`
 }
 
 // ProverCoreConfig load zk prover config.
@@ -28,6 +60,7 @@ type ProverCoreConfig struct {
 	AssetsPath string            `json:"assets_path"`
 	ProofType  message.ProofType `json:"proof_type,omitempty"` // 1: chunk prover (default type), 2: batch prover
 	DumpDir    string            `json:"dump_dir,omitempty"`
+	Seed       *uint64           `json:"seed,omitempty"` // fixed randomness seed, unsupported by the rust prover yet and rejected at startup, nil means random
 }
 
 // CoordinatorConfig represents the configuration for the Coordinator client.
@@ -36,12 +69,67 @@ type CoordinatorConfig struct {
 	RetryCount           int    `json:"retry_count"`
 	RetryWaitTimeSec     int    `json:"retry_wait_time_sec"`
 	ConnectionTimeoutSec int    `json:"connection_timeout_sec"`
+	// TokenRefreshMarginSec re-logins this many seconds before the login token expires, 0 means only re-login once it's expired.
+	TokenRefreshMarginSec int `json:"token_refresh_margin_sec,omitempty"`
+	// PublicKey is the compressed public key in hex the coordinator signs the tasks with, i.e. the public key of its
+	// auth.task_signing_key, the tasks without a valid signature are rejected. Empty means the tasks aren't verified.
+	PublicKey string `json:"public_key,omitempty"`
+	// MaxConcurrentRequests caps the number of concurrent GetTask and SubmitProof requests, 0 means no limit.
+	MaxConcurrentRequests int `json:"max_concurrent_requests,omitempty"`
+	// LeaseRenewIntervalSec renews the lease of the task being proved this often, 0 means the lease isn't renewed.
+	LeaseRenewIntervalSec int `json:"lease_renew_interval_sec,omitempty"`
+	// MaxLeaseRenewFailures abandons the task after the lease failed to be renewed this many times in a row,
+	// assuming the task is reassigned. 0 means the default of 3.
+	MaxLeaseRenewFailures int `json:"max_lease_renew_failures,omitempty"`
 }
 
 // L2GethConfig represents the configuration for the l2geth client.
 type L2GethConfig struct {
 	Endpoint      string          `json:"endpoint"`
 	Confirmations rpc.BlockNumber `json:"confirmations"`
+	StreamTraces  bool            `json:"stream_traces,omitempty"` // fetch and feed the traces to the prover core one block at a time
+	// fetch the traces of a task giving its block range in one batch request rather than one request per block hash
+	FetchTracesByRange bool `json:"fetch_traces_by_range,omitempty"`
+	// TraceCacheSize keeps the traces of this many blocks fetched by hash, so that a retried task doesn't fetch them
+	// again, 0 means no cache.
+	TraceCacheSize int `json:"trace_cache_size,omitempty"`
+	// TraceFetchParallelism is the max number of traces of a task fetched by hash concurrently, 0 means 1.
+	TraceFetchParallelism int `json:"trace_fetch_parallelism,omitempty"`
+}
+
+// ProofLimitConfig caps the number of proofs the prover produces in a time window.
+type ProofLimitConfig struct {
+	// MaxProofs is the max number of proofs produced in a window, 0 means no limit.
+	MaxProofs uint64 `json:"max_proofs"`
+	// WindowSec is the length of the window in seconds, windows are aligned to the unix epoch.
+	WindowSec uint64 `json:"window_sec"`
+}
+
+// AffinityConfig pins trace fetching and proving to separate cpu sets, only supported on linux.
+type AffinityConfig struct {
+	// GoMaxProcs overrides GOMAXPROCS, 0 means keep the runtime default.
+	GoMaxProcs int `json:"go_max_procs,omitempty"`
+	// TraceFetchCPUs is the cpu set used to fetch and decode block traces, empty means no pinning.
+	TraceFetchCPUs []int `json:"trace_fetch_cpus,omitempty"`
+	// ProveCPUs is the cpu set used to dispatch proving to the prover core, empty means no pinning.
+	ProveCPUs []int `json:"prove_cpus,omitempty"`
+}
+
+// WatchdogConfig detects a stalled prove loop from its heartbeat.
+type WatchdogConfig struct {
+	// StaleThresholdSec is how long the prove loop may go without a heartbeat before it's considered stalled,
+	// it must be longer than the longest proving time. 0 disables the watchdog.
+	StaleThresholdSec uint64 `json:"stale_threshold_sec"`
+	// ExitOnStale exits the process once the prove loop is stalled, so that it's restarted by the supervisor.
+	ExitOnStale bool `json:"exit_on_stale,omitempty"`
+}
+
+// ResourceGuardConfig skips fetching new tasks while the free resources are critically low.
+type ResourceGuardConfig struct {
+	// MinFreeDiskBytes is the low-watermark of the free disk space of the db directory, 0 means no check.
+	MinFreeDiskBytes uint64 `json:"min_free_disk_bytes,omitempty"`
+	// MinFreeMemoryBytes is the low-watermark of the available memory, 0 means no check.
+	MinFreeMemoryBytes uint64 `json:"min_free_memory_bytes,omitempty"`
 }
 
 // NewConfig returns a new instance of Config.
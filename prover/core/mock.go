@@ -15,13 +15,21 @@ import (
 
 // ProverCore sends block-traces to rust-prover through socket and get back the zk-proof.
 type ProverCore struct {
-	cfg *config.ProverCoreConfig
-	VK  string
+	cfg        *config.ProverCoreConfig
+	VK         string
+	paramsHash string
 }
 
 // NewProverCore inits a ProverCore object.
 func NewProverCore(cfg *config.ProverCoreConfig) (*ProverCore, error) {
-	return &ProverCore{cfg: cfg}, nil
+	if err := checkSeed(cfg.Seed); err != nil {
+		return nil, err
+	}
+	paramsHash, err := hashParams(cfg.ParamsPath, cfg.AssetsPath)
+	if err != nil {
+		return nil, err
+	}
+	return &ProverCore{cfg: cfg, paramsHash: paramsHash}, nil
 }
 
 func (p *ProverCore) ProveChunk(taskID string, traces []*types.BlockTrace) (*message.ChunkProof, error) {
@@ -35,6 +43,13 @@ func (p *ProverCore) ProveChunk(taskID string, traces []*types.BlockTrace) (*mes
 	}, nil
 }
 
+func (p *ProverCore) ProveChunkStream(taskID string, next TraceIterator) (*message.ChunkProof, error) {
+	if _, err := encodeTraces(next); err != nil {
+		return nil, err
+	}
+	return p.ProveChunk(taskID, nil)
+}
+
 func (p *ProverCore) ProveBatch(taskID string, chunkInfos []*message.ChunkInfo, chunkProofs []*message.ChunkProof) (*message.BatchProof, error) {
 	_empty := common.BigToHash(big.NewInt(0))
 	return &message.BatchProof{
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// hashParams returns the hex sha256 of the files in the circuit params and assets directories,
+// covering the relative paths and the contents of the files so that renamed or replaced files change it.
+// Empty directories in dirs are skipped.
+func hashParams(dirs ...string) (string, error) {
+	hasher := sha256.New()
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		// filepath.WalkDir walks the files in lexical order, so the hash doesn't depend on the file system.
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			if _, err = fmt.Fprintf(hasher, "%s\x00", filepath.ToSlash(rel)); err != nil {
+				return err
+			}
+			f, err := os.Open(filepath.Clean(path))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(hasher, f)
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to hash circuit params in %s: %w", dir, err)
+		}
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// ParamsHash returns the hash of the circuit params and assets the prover core was initialized with.
+func (p *ProverCore) ParamsHash() string {
+	return p.paramsHash
+}
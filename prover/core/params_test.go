@@ -0,0 +1,40 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashParams(t *testing.T) {
+	paramsPath := t.TempDir()
+	assetsPath := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(paramsPath, "params20"), []byte("params20"), 0600))
+	assert.NoError(t, os.MkdirAll(filepath.Join(assetsPath, "layer1"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(assetsPath, "layer1", "vk.vkey"), []byte("vk"), 0600))
+
+	hash, err := hashParams(paramsPath, assetsPath)
+	assert.NoError(t, err)
+	assert.Len(t, hash, 64)
+
+	// the hash is stable, and skips empty directories.
+	again, err := hashParams(paramsPath, "", assetsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, hash, again)
+
+	// the hash changes with the file contents and names.
+	assert.NoError(t, os.WriteFile(filepath.Join(assetsPath, "layer1", "vk.vkey"), []byte("vk2"), 0600))
+	changed, err := hashParams(paramsPath, assetsPath)
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, changed)
+
+	assert.NoError(t, os.Rename(filepath.Join(paramsPath, "params20"), filepath.Join(paramsPath, "params21")))
+	renamed, err := hashParams(paramsPath, assetsPath)
+	assert.NoError(t, err)
+	assert.NotEqual(t, changed, renamed)
+
+	_, err = hashParams(filepath.Join(paramsPath, "missing"))
+	assert.Error(t, err)
+}
@@ -27,12 +27,23 @@ import (
 
 // ProverCore sends block-traces to rust-prover through ffi and get back the zk-proof.
 type ProverCore struct {
-	cfg *config.ProverCoreConfig
-	VK  string
+	cfg        *config.ProverCoreConfig
+	VK         string
+	paramsHash string
 }
 
 // NewProverCore inits a ProverCore object.
 func NewProverCore(cfg *config.ProverCoreConfig) (*ProverCore, error) {
+	// Reject the seed before the rust prover is initialized.
+	if err := checkSeed(cfg.Seed); err != nil {
+		return nil, err
+	}
+
+	paramsHash, err := hashParams(cfg.ParamsPath, cfg.AssetsPath)
+	if err != nil {
+		return nil, err
+	}
+
 	paramsPathStr := C.CString(cfg.ParamsPath)
 	assetsPathStr := C.CString(cfg.AssetsPath)
 	defer func() {
@@ -63,7 +74,7 @@ func NewProverCore(cfg *config.ProverCoreConfig) (*ProverCore, error) {
 		log.Info("Enabled dump_proof", "dir", cfg.DumpDir)
 	}
 
-	return &ProverCore{cfg: cfg, VK: vk}, nil
+	return &ProverCore{cfg: cfg, VK: vk, paramsHash: paramsHash}, nil
 }
 
 // ProveBatch call rust ffi to generate batch proof.
@@ -114,6 +125,24 @@ func (p *ProverCore) ProveChunk(taskID string, traces []*types.BlockTrace) (*mes
 	if err != nil {
 		return nil, err
 	}
+	return p.proveChunkTraces(taskID, tracesByt)
+}
+
+// ProveChunkStream call rust ffi to generate chunk proof with the traces fed one at a time by next,
+// only the encoded traces rather than all the decoded ones are held in memory.
+func (p *ProverCore) ProveChunkStream(taskID string, next TraceIterator) (*message.ChunkProof, error) {
+	if p.cfg.ProofType != message.ProofTypeChunk {
+		return nil, fmt.Errorf("prover is not a chunk-prover (type: %v), but is trying to prove a chunk", p.cfg.ProofType)
+	}
+
+	tracesByt, err := encodeTraces(next)
+	if err != nil {
+		return nil, err
+	}
+	return p.proveChunkTraces(taskID, tracesByt)
+}
+
+func (p *ProverCore) proveChunkTraces(taskID string, tracesByt []byte) (*message.ChunkProof, error) {
 	proofByt, err := p.proveChunk(tracesByt)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,17 @@
+package core
+
+import (
+	"errors"
+)
+
+// ErrSeedUnsupported is returned when a fixed randomness seed is configured, the rust prover linked into this
+// build always proves with fresh randomness and has no way to take a seed.
+var ErrSeedUnsupported = errors.New("a fixed prover seed is unsupported by this build")
+
+// checkSeed rejects a configured seed rather than silently proving with fresh randomness, a nil seed is accepted.
+func checkSeed(seed *uint64) error {
+	if seed != nil {
+		return ErrSeedUnsupported
+	}
+	return nil
+}
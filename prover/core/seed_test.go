@@ -0,0 +1,22 @@
+//go:build mock_prover
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"scroll-tech/common/types/message"
+
+	"scroll-tech/prover/config"
+)
+
+func TestProverCoreSeed(t *testing.T) {
+	_, err := NewProverCore(&config.ProverCoreConfig{ProofType: message.ProofTypeChunk})
+	assert.NoError(t, err)
+
+	seed := uint64(42)
+	_, err = NewProverCore(&config.ProverCoreConfig{ProofType: message.ProofTypeChunk, Seed: &seed})
+	assert.ErrorIs(t, err, ErrSeedUnsupported)
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/scroll-tech/go-ethereum/core/types"
+)
+
+// TraceIterator yields the block traces of a chunk one at a time in block order,
+// it returns io.EOF after the last trace.
+type TraceIterator func() (*types.BlockTrace, error)
+
+// encodeTraces encodes the traces yielded by next into a JSON array, each trace is
+// released once encoded so that only one decoded trace is held in memory at a time.
+func encodeTraces(next TraceIterator) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i := 0; ; i++ {
+		trace, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		traceByt, err := json.Marshal(trace)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(traceByt)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
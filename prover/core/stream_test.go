@@ -0,0 +1,42 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeTraces(t *testing.T) {
+	var traces []*types.BlockTrace
+	for _, file := range []string{"../../common/testdata/blockTrace_02.json", "../../common/testdata/blockTrace_03.json"} {
+		data, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		trace := &types.BlockTrace{}
+		assert.NoError(t, json.Unmarshal(data, trace))
+		traces = append(traces, trace)
+	}
+
+	i := 0
+	tracesByt, err := encodeTraces(func() (*types.BlockTrace, error) {
+		if i >= len(traces) {
+			return nil, io.EOF
+		}
+		i++
+		return traces[i-1], nil
+	})
+	assert.NoError(t, err)
+	expected, err := json.Marshal(traces)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, tracesByt)
+
+	targetErr := errors.New("fetch trace error")
+	_, err = encodeTraces(func() (*types.BlockTrace, error) {
+		return nil, targetErr
+	})
+	assert.ErrorIs(t, err, targetErr)
+}
@@ -0,0 +1,94 @@
+package prover
+
+import (
+	"context"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/log"
+
+	"scroll-tech/prover/client"
+	"scroll-tech/prover/store"
+)
+
+// defaultMaxLeaseRenewFailures is the number of failed lease renewals in a row after which the task is abandoned.
+const defaultMaxLeaseRenewFailures = 3
+
+// taskLease renews the lease of the task being proved periodically, so that the coordinator doesn't reassign it.
+// The lease is considered lost once it failed to be renewed MaxLeaseRenewFailures times in a row.
+type taskLease struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	lost   chan struct{}
+}
+
+// startLease starts renewing the lease of the task, the lease must be released once the task is proved or failed.
+// Submitting the proof or the failure ends the lease on the coordinator.
+func (r *Prover) startLease(task *store.ProvingTask) *taskLease {
+	ctx, cancel := context.WithCancel(r.ctx)
+	lease := &taskLease{
+		cancel: cancel,
+		done:   make(chan struct{}),
+		lost:   make(chan struct{}),
+	}
+	if r.cfg.Coordinator == nil || r.cfg.Coordinator.LeaseRenewIntervalSec <= 0 {
+		close(lease.done)
+		return lease
+	}
+
+	maxFailures := r.cfg.Coordinator.MaxLeaseRenewFailures
+	if maxFailures <= 0 {
+		maxFailures = defaultMaxLeaseRenewFailures
+	}
+	req := &client.RenewLeaseRequest{
+		UUID:     task.Task.UUID,
+		TaskID:   task.Task.ID,
+		TaskType: int(task.Task.Type),
+	}
+	go func() {
+		defer close(lease.done)
+		ticker := time.NewTicker(time.Duration(r.cfg.Coordinator.LeaseRenewIntervalSec) * time.Second)
+		defer ticker.Stop()
+
+		var failures int
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			err := r.coordinatorClient.RenewLease(ctx, req)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil {
+				failures = 0
+				continue
+			}
+			failures++
+			log.Warn("failed to renew task lease", "task-id", task.Task.ID, "failures", failures, "err", err)
+			if failures >= maxFailures {
+				r.metrics.proverTaskLeaseLostTotal.Inc()
+				log.Error("task lease is lost, the task is probably reassigned", "task-id", task.Task.ID, "failures", failures)
+				close(lease.lost)
+				return
+			}
+		}
+	}()
+	return lease
+}
+
+// release stops renewing the lease.
+func (l *taskLease) release() {
+	l.cancel()
+	<-l.done
+}
+
+// isLost reports whether the lease failed to be renewed too many times, the task should then be abandoned.
+func (l *taskLease) isLost() bool {
+	select {
+	case <-l.lost:
+		return true
+	default:
+		return false
+	}
+}
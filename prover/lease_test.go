@@ -0,0 +1,116 @@
+package prover
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"scroll-tech/prover/client"
+	"scroll-tech/prover/config"
+	"scroll-tech/prover/core"
+	"scroll-tech/prover/store"
+
+	ctypes "scroll-tech/common/types"
+	"scroll-tech/common/types/message"
+)
+
+func TestTaskLease(t *testing.T) {
+	// the coordinator renews the leases until it's marked to have reassigned the task.
+	var renewCalls, submitCalls, reassigned int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/submit_proof") {
+			atomic.AddInt64(&submitCalls, 1)
+		}
+		if strings.HasSuffix(r.URL.Path, "/renew_lease") {
+			var req client.RenewLeaseRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "task-1", req.TaskID)
+			atomic.AddInt64(&renewCalls, 1)
+			if atomic.LoadInt64(&reassigned) != 0 {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.ErrCoordinatorGetTaskFailure, "errmsg": "task is reassigned"})
+				return
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer server.Close()
+
+	path, err := os.MkdirTemp("/tmp/", "prover_lease_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	defer stack.Close()
+
+	coordinatorCfg := &config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5, LeaseRenewIntervalSec: 1, MaxLeaseRenewFailures: 2}
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(coordinatorCfg, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	r := &Prover{
+		ctx:               context.Background(),
+		cfg:               &config.Config{Coordinator: coordinatorCfg, MaxChunkBlocks: 1},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		proverCore:        &core.ProverCore{},
+		metrics:           initProverMetrics(prometheus.NewRegistry()),
+	}
+
+	// a chunk of the contiguous blocks 10 and 11, split into a sub-chunk per block.
+	header10 := &types.Header{Number: big.NewInt(10), Difficulty: big.NewInt(0)}
+	header11 := &types.Header{Number: big.NewInt(11), ParentHash: header10.Hash(), Difficulty: big.NewInt(0)}
+	task := &store.ProvingTask{Task: &message.TaskMsg{
+		UUID:            "uuid-1",
+		ID:              "task-1",
+		Type:            message.ProofTypeChunk,
+		ChunkTaskDetail: &message.ChunkTaskDetail{BlockTraces: []*types.BlockTrace{{Header: header10}, {Header: header11}}},
+	}}
+
+	t.Run("renew lease", func(t *testing.T) {
+		atomic.StoreInt64(&renewCalls, 0)
+		lease := r.startLease(task)
+		time.Sleep(2500 * time.Millisecond)
+		lease.release()
+		assert.False(t, lease.isLost())
+		calls := atomic.LoadInt64(&renewCalls)
+		assert.EqualValues(t, 2, calls)
+
+		// the lease isn't renewed once it's released.
+		time.Sleep(1500 * time.Millisecond)
+		assert.Equal(t, calls, atomic.LoadInt64(&renewCalls))
+	})
+
+	t.Run("abandon task", func(t *testing.T) {
+		atomic.StoreInt64(&renewCalls, 0)
+		atomic.StoreInt64(&reassigned, 1)
+		defer atomic.StoreInt64(&reassigned, 0)
+
+		lease := r.startLease(task)
+		defer lease.release()
+		assert.Eventually(t, lease.isLost, 5*time.Second, 100*time.Millisecond)
+		assert.EqualValues(t, 2, atomic.LoadInt64(&renewCalls))
+
+		// the task is dropped without proving the rest of it or submitting anything.
+		assert.NoError(t, stack.Push(task))
+		err := r.proveAndSubmitSubChunks(task, lease)
+		assert.ErrorIs(t, err, ErrTaskLeaseLost)
+		assert.Zero(t, atomic.LoadInt64(&submitCalls))
+		_, err = stack.Peek()
+		assert.ErrorIs(t, err, store.ErrEmpty)
+	})
+}
@@ -0,0 +1,60 @@
+package prover
+
+import (
+	"time"
+
+	"scroll-tech/prover/config"
+	"scroll-tech/prover/store"
+)
+
+// proofLimiter caps the number of proofs produced in a window, the count is
+// persisted in the stack db so that it survives restarts within the window.
+type proofLimiter struct {
+	cfg     *config.ProofLimitConfig
+	stack   *store.Stack
+	metrics *proverMetrics
+}
+
+func newProofLimiter(cfg *config.ProofLimitConfig, stack *store.Stack, metrics *proverMetrics) *proofLimiter {
+	return &proofLimiter{cfg: cfg, stack: stack, metrics: metrics}
+}
+
+func (l *proofLimiter) enabled() bool {
+	return l.cfg != nil && l.cfg.MaxProofs > 0 && l.cfg.WindowSec > 0
+}
+
+// window returns the start and the end of the window the given time is in.
+func (l *proofLimiter) window(now time.Time) (time.Time, time.Time) {
+	windowSec := int64(l.cfg.WindowSec)
+	start := now.Unix() / windowSec * windowSec
+	return time.Unix(start, 0), time.Unix(start+windowSec, 0)
+}
+
+// allow reports whether a new task can be fetched at the given time,
+// if not, it also returns the time the next window starts.
+func (l *proofLimiter) allow(now time.Time) (bool, time.Time, error) {
+	if !l.enabled() {
+		return true, time.Time{}, nil
+	}
+	start, end := l.window(now)
+	count, err := l.stack.GetProofCount(start.Unix())
+	if err != nil {
+		return false, end, err
+	}
+	l.metrics.proverProofCountInWindow.Set(float64(count))
+	return count < l.cfg.MaxProofs, end, nil
+}
+
+// record counts a proof produced at the given time.
+func (l *proofLimiter) record(now time.Time) error {
+	if !l.enabled() {
+		return nil
+	}
+	start, _ := l.window(now)
+	count, err := l.stack.IncreaseProofCount(start.Unix())
+	if err != nil {
+		return err
+	}
+	l.metrics.proverProofCountInWindow.Set(float64(count))
+	return nil
+}
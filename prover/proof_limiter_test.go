@@ -0,0 +1,68 @@
+package prover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+
+	"scroll-tech/prover/config"
+	"scroll-tech/prover/store"
+)
+
+func TestProofLimiter(t *testing.T) {
+	path, err := os.MkdirTemp("/tmp/", "proof_limiter_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	dbPath := filepath.Join(path, "test-stack")
+
+	s, err := store.NewStack(dbPath)
+	assert.NoError(t, err)
+
+	cfg := &config.ProofLimitConfig{MaxProofs: 2, WindowSec: 3600}
+	limiter := newProofLimiter(cfg, s, initProverMetrics(prometheus.NewRegistry()))
+
+	now := time.Unix(7200, 0)
+	for i := 0; i < 2; i++ {
+		allow, _, allowErr := limiter.allow(now)
+		assert.NoError(t, allowErr)
+		assert.True(t, allow)
+		assert.NoError(t, limiter.record(now))
+	}
+
+	// fetching pauses once the cap is reached
+	allow, nextWindow, err := limiter.allow(now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, allow)
+	assert.Equal(t, time.Unix(10800, 0), nextWindow)
+
+	// the count survives restarts within the window
+	assert.NoError(t, s.Close())
+	s, err = store.NewStack(dbPath)
+	assert.NoError(t, err)
+	defer s.Close()
+	limiter.stack = s
+	allow, _, err = limiter.allow(now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, allow)
+
+	// fetching resumes at window rollover
+	allow, _, err = limiter.allow(nextWindow)
+	assert.NoError(t, err)
+	assert.True(t, allow)
+	assert.NoError(t, limiter.record(nextWindow))
+	count, err := s.GetProofCount(nextWindow.Unix())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+}
+
+func TestProofLimiterDisabled(t *testing.T) {
+	limiter := newProofLimiter(nil, nil, initProverMetrics(prometheus.NewRegistry()))
+	allow, _, err := limiter.allow(time.Now())
+	assert.NoError(t, err)
+	assert.True(t, allow)
+	assert.NoError(t, limiter.record(time.Now()))
+}
@@ -6,15 +6,25 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"runtime"
 	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/go-resty/resty/v2"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
 	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/crypto"
 	"github.com/scroll-tech/go-ethereum/ethclient"
 	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/rpc"
+	"golang.org/x/sync/errgroup"
 
 	"scroll-tech/prover/client"
 	"scroll-tech/prover/config"
@@ -26,11 +36,47 @@ import (
 	"scroll-tech/common/utils"
 )
 
+// ErrChunkSpansEpochs is returned when the blocks of a chunk straddle a configured epoch boundary.
+var ErrChunkSpansEpochs = errors.New("chunk spans an epoch boundary")
+
+// ErrChunkProofsMismatch is returned when the chunk proofs of a batch task don't match its chunk infos.
+var ErrChunkProofsMismatch = errors.New("chunk proofs mismatch chunk infos")
+
+// defaultMaxProveRetry is the number of times a task is proved again after the prover crashed proving it by default.
+const defaultMaxProveRetry = 2
+
+// ErrTaskLeaseLost is returned when a task is abandoned since its lease failed to be renewed.
+var ErrTaskLeaseLost = errors.New("task lease lost")
+
+// ErrProveTimeout is returned when the prover core doesn't finish proving a task within the prove timeout.
+var ErrProveTimeout = errors.New("prove timed out")
+
+// errProveAborted is returned when proving a task is aborted since the prover is stopping.
+var errProveAborted = errors.New("prove aborted, prover is stopping")
+
 var (
 	// retry connecting to coordinator
 	retryWait = time.Second * 10
+	// the cap of the backoff of fetching tasks on consecutive failures
+	maxFetchBackoff = time.Minute * 5
+	// ask the coordinator for a task again after it asked to drain
+	drainRetryWait = time.Minute
 )
 
+// FatalError is returned by proveAndSubmit for the errors the prove loop can't recover from
+// by retrying, e.g. the stack db is corrupted.
+type FatalError struct {
+	Err error
+}
+
+func (e *FatalError) Error() string {
+	return fmt.Sprintf("fatal error: %v", e.Err)
+}
+
+func (e *FatalError) Unwrap() error {
+	return e.Err
+}
+
 // Prover contains websocket conn to coordinator, and task stack.
 type Prover struct {
 	ctx               context.Context
@@ -38,42 +84,112 @@ type Prover struct {
 	coordinatorClient *client.CoordinatorClient
 	stack             *store.Stack
 	l2GethClient      *ethclient.Client // only applicable for a chunk_prover
+	l2GethRPCClient   *rpc.Client       // the rpc client of l2GethClient, used for batch requests
+	traceCache        *lru.Cache        // the block traces fetched from l2geth by hash, nil means no cache
 	proverCore        *core.ProverCore
+	proofLimiter      *proofLimiter
+	resourceGuard     *resourceGuard
 
 	isClosed int64
 	stopChan chan struct{}
+	// the goroutines using the stack, i.e. the workers of the prove loop, the submit loop and the compact loop,
+	// waited for by Stop before the stack is closed. stopMu orders starting them against stopping the prover.
+	stopMu    sync.Mutex
+	workers   sync.WaitGroup
+	closeOnce sync.Once
+	// set by StopGracefully once no new task is taken, inFlight counts the tasks still being proved.
+	// gracefulMu orders taking a task against waiting for inFlight.
+	gracefulMu   sync.Mutex
+	gracefulStop int32
+	inFlight     sync.WaitGroup
+	// the fatal error stopping the prove loop.
+	fatalErr atomic.Value
+
+	// acquireMu serializes the workers of the prove loop taking a task, i.e. resubmitting the pending proofs,
+	// peeking the stack and fetching new tasks from the coordinator. It guards drainingSince and fetchBackoff.
+	acquireMu sync.Mutex
+	// claimMu guards claimed, the tasks being proved by the workers by id.
+	claimMu sync.Mutex
+	claimed map[string]claimedTask
+	// the order the proofs are submitted in if OrderedSubmit is set.
+	order submitOrder
+	// proveSlots bounds the prover core calls running at a time to the concurrency. A call frees its slot once it
+	// returns rather than once it's abandoned, so a call timed out keeps its slot and new proving waits for it.
+	proveSlots     chan struct{}
+	proveSlotsOnce sync.Once
+	// submitMu guards submitNotBefore.
+	submitMu sync.Mutex
+	// submitCh wakes up the submit loop once a proof is queued, only used with AsyncSubmit.
+	submitCh chan struct{}
+
+	// unix nano timestamp of the latest prove loop iteration.
+	heartbeat int64
+	// the time the coordinator last asked to drain, zero if it's not draining.
+	drainingSince time.Time
+	// the coordinator asked to hold off submitting proofs until then.
+	submitNotBefore time.Time
+	// the backoff of fetching tasks after the latest failure, zero once a fetch succeeds.
+	fetchBackoff time.Duration
+	// called by the watchdog once the prove loop is stalled.
+	onStalled func(stale time.Duration)
 
 	priv *ecdsa.PrivateKey
+
+	metrics *proverMetrics
+}
+
+// claimedTask is a task being proved by a worker.
+type claimedTask struct {
+	proofType message.ProofType
+	startedAt time.Time
 }
 
 // NewProver new a Prover object.
-func NewProver(ctx context.Context, cfg *config.Config) (*Prover, error) {
+func NewProver(ctx context.Context, cfg *config.Config, reg prometheus.Registerer) (*Prover, error) {
 	// load or create wallet
 	priv, err := utils.LoadOrCreateKey(cfg.KeystorePath, cfg.KeystorePassword)
 	if err != nil {
 		return nil, err
 	}
 
+	if cfg.OrderedSubmit && cfg.AsyncSubmit {
+		return nil, errors.New("ordered_submit can't be used with async_submit, the submit queue isn't kept in task order")
+	}
+
 	// Get stack db handler
 	stackDb, err := store.NewStack(cfg.DBPath)
 	if err != nil {
 		return nil, err
 	}
+	// the tasks pushed before the tasks were typed are the ones of this prover.
+	if err = stackDb.MigrateUntypedTasks(cfg.Core.ProofType); err != nil {
+		return nil, err
+	}
 
 	var l2GethClient *ethclient.Client
+	var l2GethRPCClient *rpc.Client
+	var traceCache *lru.Cache
 	if cfg.Core.ProofType == message.ProofTypeChunk {
 		if cfg.L2Geth == nil || cfg.L2Geth.Endpoint == "" {
 			return nil, errors.New("Missing l2geth config for chunk prover")
 		}
 		// Connect l2geth node. Only applicable for a chunk_prover.
-		l2GethClient, err = ethclient.DialContext(ctx, cfg.L2Geth.Endpoint)
+		l2GethRPCClient, err = rpc.DialContext(ctx, cfg.L2Geth.Endpoint)
 		if err != nil {
 			return nil, err
 		}
+		l2GethClient = ethclient.NewClient(l2GethRPCClient)
 		// Use gzip compression.
 		l2GethClient.SetHeader("Accept-Encoding", "gzip")
+		if cfg.L2Geth.TraceCacheSize > 0 {
+			if traceCache, err = lru.New(cfg.L2Geth.TraceCacheSize); err != nil {
+				return nil, err
+			}
+		}
 	}
 
+	applyGoMaxProcs(cfg.Affinity)
+
 	// Create prover_core instance
 	log.Info("init prover_core")
 	newProverCore, err := core.NewProverCore(cfg.Core)
@@ -82,23 +198,38 @@ func NewProver(ctx context.Context, cfg *config.Config) (*Prover, error) {
 	}
 	log.Info("init prover_core successfully!")
 
-	coordinatorClient, err := client.NewCoordinatorClient(cfg.Coordinator, cfg.ProverName, priv)
+	coordinatorClient, err := client.NewCoordinatorClient(cfg.Coordinator, cfg.ProverName, priv, reg)
 	if err != nil {
 		return nil, err
 	}
+	coordinatorClient.SetProverInfo([]message.ProofType{cfg.Core.ProofType}, hardwareInfo())
+
+	metrics := initProverMetrics(reg)
 
 	return &Prover{
 		ctx:               ctx,
 		cfg:               cfg,
 		coordinatorClient: coordinatorClient,
 		l2GethClient:      l2GethClient,
+		l2GethRPCClient:   l2GethRPCClient,
+		traceCache:        traceCache,
 		stack:             stackDb,
 		proverCore:        newProverCore,
+		proofLimiter:      newProofLimiter(cfg.ProofLimit, stackDb, metrics),
+		resourceGuard:     newResourceGuard(cfg.ResourceGuard, cfg.DBPath, metrics),
 		stopChan:          make(chan struct{}),
+		submitCh:          make(chan struct{}, 1),
 		priv:              priv,
+		metrics:           metrics,
+		onStalled:         exitOnStalled(cfg.Watchdog),
 	}, nil
 }
 
+// hardwareInfo describes the machine the prover runs on.
+func hardwareInfo() string {
+	return fmt.Sprintf("%s/%s, %d cpus", runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+}
+
 // Type returns prover type.
 func (r *Prover) Type() message.ProofType {
 	return r.cfg.Core.ProofType
@@ -117,63 +248,368 @@ func (r *Prover) Start() {
 	}
 	log.Info("login to coordinator successfully!")
 
+	r.beat()
 	go r.ProveLoop()
+	if r.cfg.AsyncSubmit {
+		r.goWorker(r.submitLoop)
+	}
+
+	if r.cfg.Watchdog != nil && r.cfg.Watchdog.StaleThresholdSec > 0 {
+		threshold := time.Duration(r.cfg.Watchdog.StaleThresholdSec) * time.Second
+		go r.watchdogLoop(threshold, threshold/4)
+	}
+
+	if r.cfg.DBCompactIntervalSec > 0 {
+		r.goWorker(func() { r.compactLoop(time.Duration(r.cfg.DBCompactIntervalSec) * time.Second) })
+	}
 }
 
-// ProveLoop keep popping the block-traces from Stack and sends it to rust-prover for loop.
+// ProveLoop keeps proving the tasks with Concurrency workers until the prover is stopped.
 func (r *Prover) ProveLoop() {
+	concurrency := r.cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		if !r.goWorker(func() {
+			defer wg.Done()
+			r.proveLoop()
+		}) {
+			wg.Done()
+			break
+		}
+	}
+	wg.Wait()
+}
+
+// goWorker runs fn in a goroutine waited for by Stop before the stack is closed, it returns false without running fn
+// if the prover is stopped already.
+func (r *Prover) goWorker(fn func()) bool {
+	r.stopMu.Lock()
+	defer r.stopMu.Unlock()
+	if atomic.LoadInt64(&r.isClosed) == 1 {
+		return false
+	}
+	r.workers.Add(1)
+	go func() {
+		defer r.workers.Done()
+		fn()
+	}()
+	return true
+}
+
+// sleep waits for the duration, or until the prover is stopped.
+func (r *Prover) sleep(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-r.stopChan:
+	case <-r.ctx.Done():
+	}
+}
+
+// proveLoop keep popping the block-traces from Stack and sends it to rust-prover for loop.
+func (r *Prover) proveLoop() {
 	for {
 		select {
 		case <-r.stopChan:
 			return
+		case <-r.ctx.Done():
+			return
 		default:
+			if atomic.LoadInt32(&r.gracefulStop) == 1 {
+				return
+			}
+			r.beat()
 			if err := r.proveAndSubmit(); err != nil {
+				var fatalErr *FatalError
+				if r.cfg.ExitOnFatalError && errors.As(err, &fatalErr) {
+					log.Error("stop prover on fatal error", "prover type", r.cfg.Core.ProofType, "error", err)
+					r.fatalErr.Store(fatalErr)
+					r.signalStop()
+					return
+				}
 				log.Error("proveAndSubmit", "prover type", r.cfg.Core.ProofType, "error", err)
 			}
 		}
 	}
 }
 
+// compactLoop compacts the stack db periodically to reclaim the space of the deleted tasks.
+func (r *Prover) compactLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stopChan:
+			return
+		case <-ticker.C:
+			before, after, err := r.stack.Compact()
+			if err != nil {
+				log.Error("failed to compact stack db", "error", err)
+				continue
+			}
+			r.metrics.proverStackDBSizeBytes.Set(float64(after))
+			if before > after {
+				r.metrics.proverStackDBReclaimedBytes.Add(float64(before - after))
+			}
+			log.Info("compacted stack db", "size before", before, "size after", after)
+		}
+	}
+}
+
 func (r *Prover) proveAndSubmit() error {
-	task, err := r.stack.Peek()
+	task, err := r.acquireTask()
+	if err != nil || task == nil {
+		return err
+	}
+	defer r.releaseTask(task)
+	// the task is left in the stack to be proved after the restart if the prover is stopping.
+	if !r.beginTask() {
+		return nil
+	}
+	defer r.inFlight.Done()
+	return r.proveAndSubmitTask(task)
+}
+
+// beginTask counts the task as in flight, unless the prover is stopping gracefully.
+func (r *Prover) beginTask() bool {
+	r.gracefulMu.Lock()
+	defer r.gracefulMu.Unlock()
+	if atomic.LoadInt32(&r.gracefulStop) == 1 {
+		return false
+	}
+	r.inFlight.Add(1)
+	return true
+}
+
+// acquireTask claims the top task of the stack not being proved by another worker, fetching a new task from the
+// coordinator if there is none. It returns a nil task if there is nothing to prove for now, e.g. a pending proof
+// is resubmitted instead.
+func (r *Prover) acquireTask() (*store.ProvingTask, error) {
+	r.acquireMu.Lock()
+	defer r.acquireMu.Unlock()
+
+	// take no new task once the prover is stopping gracefully.
+	if atomic.LoadInt32(&r.gracefulStop) == 1 {
+		return nil, nil
+	}
+
+	// skip the proofs being submitted by the other workers.
+	pendingProof, err := r.stack.PeekPendingProofExcept(r.claimedTasks())
+	if err != nil && !errors.Is(err, store.ErrEmpty) {
+		return nil, &FatalError{Err: fmt.Errorf("failed to peek from submit queue: %v", err)}
+	}
+	hasPendingProof := err == nil
+	// resubmit the proofs failed to be submitted before proving new tasks, unless they're submitted by the submit loop.
+	if hasPendingProof && !r.cfg.AsyncSubmit {
+		// honor the backpressure of the coordinator before resubmitting.
+		if wait := r.submitWait(); wait > 0 {
+			r.sleep(wait)
+		}
+		if err = r.resubmitPendingProof(pendingProof); err != nil && r.submitWait() <= 0 {
+			r.sleep(retryWait)
+		}
+		return nil, err
+	}
+
+	task, err := r.stack.PeekByTypeExcept(r.Type(), r.claimedTasks())
 	if err != nil {
 		if !errors.Is(err, store.ErrEmpty) {
-			return fmt.Errorf("failed to peek from stack: %v", err)
+			return nil, &FatalError{Err: fmt.Errorf("failed to peek from stack: %v", err)}
+		}
+		// the stack and the submit queue are drained, stop fetching on the request of the coordinator.
+		if !r.drainingSince.IsZero() {
+			if r.cfg.ExitOnDrain {
+				// wait for the other workers to finish proving their tasks, and the queued proofs to be submitted.
+				if len(r.claimedTasks()) > 0 || hasPendingProof {
+					r.sleep(retryWait)
+					return nil, nil
+				}
+				log.Info("prover is drained, stop prover", "prover type", r.Type())
+				r.signalStop()
+				return nil, nil
+			}
+			if time.Since(r.drainingSince) < drainRetryWait {
+				r.sleep(retryWait)
+				return nil, nil
+			}
+		}
+		// pause fetching once the proof limit of the current window is reached.
+		allow, nextWindow, limitErr := r.proofLimiter.allow(time.Now())
+		if limitErr != nil {
+			return nil, fmt.Errorf("failed to check proof limit: %v", limitErr)
 		}
+		if !allow {
+			r.metrics.proverProofLimitReachedTotal.Inc()
+			log.Warn("proof limit reached, pause fetching tasks until the next window",
+				"max proofs", r.cfg.ProofLimit.MaxProofs, "next window", nextWindow)
+			wait := time.Until(nextWindow)
+			if wait > retryWait {
+				wait = retryWait
+			}
+			r.sleep(wait)
+			return nil, nil
+		}
+		// skip fetching while the free disk space or memory is critically low.
+		if !r.resourceGuard.allow() {
+			r.sleep(retryWait)
+			return nil, nil
+		}
+
 		// fetch new proving task.
 		task, err = r.fetchTaskFromCoordinator()
+		if errors.Is(err, client.ErrCoordinatorDraining) {
+			if r.drainingSince.IsZero() {
+				log.Warn("coordinator asked to drain, stop fetching new tasks", "prover type", r.Type(), "error", err)
+			}
+			r.drainingSince = time.Now()
+			return nil, nil
+		}
 		if err != nil {
-			time.Sleep(retryWait)
-			return fmt.Errorf("failed to fetch task from coordinator: %v", err)
+			wait := r.nextFetchBackoff()
+			r.sleep(wait)
+			return nil, fmt.Errorf("failed to fetch task from coordinator, waited %v: %v", wait, err)
+		}
+		r.fetchBackoff = 0
+		r.metrics.proverTasksFetchedTotal.Inc()
+		if !r.drainingSince.IsZero() {
+			log.Info("coordinator ended draining, resume fetching tasks", "prover type", r.Type())
+			r.drainingSince = time.Time{}
 		}
 
 		// Push the new task into the stack
 		if err = r.stack.Push(task); err != nil {
-			return fmt.Errorf("failed to push task into stack: %v", err)
+			return nil, &FatalError{Err: fmt.Errorf("failed to push task into stack: %v", err)}
 		}
 	}
 
-	var proofMsg *message.ProofDetail
-	if task.Times <= 2 {
-		// If tried times <= 2, try to proof the task.
-		if err = r.stack.UpdateTimes(task, task.Times+1); err != nil {
-			return fmt.Errorf("failed to update times on stack: %v", err)
+	r.claimTask(task)
+	return task, nil
+}
+
+// proveAndSubmitTask proves the claimed task and submits the proof.
+func (r *Prover) proveAndSubmitTask(task *store.ProvingTask) error {
+	if task.Times <= r.maxProveRetry() {
+		// If tried times <= MaxProveRetry, try to proof the task.
+		if err := r.stack.UpdateTimes(task, task.Times+1); err != nil {
+			return &FatalError{Err: fmt.Errorf("failed to update times on stack: %v", err)}
 		}
 
 		log.Info("start to prove task", "task-type", task.Task.Type, "task-id", task.Task.ID)
-		proofMsg, err = r.prove(task)
+		lease := r.startLease(task)
+		defer lease.release()
+		if r.splitChunk(task) {
+			return r.proveAndSubmitSubChunks(task, lease)
+		}
+		proofMsg, err := r.prove(task)
+		lease.release()
+		if errors.Is(err, errProveAborted) {
+			return r.restoreAbortedTask(task, err)
+		}
+		if lease.isLost() {
+			return r.abandonTask(task)
+		}
 		if err != nil { // handling error from prove
 			log.Error("failed to prove task", "task_type", task.Task.Type, "task-id", task.Task.ID, "err", err)
 			return r.submitErr(task, message.ProofFailureNoPanic, err)
 		}
+		if err = r.proofLimiter.record(time.Now()); err != nil {
+			log.Error("failed to record proof count", "task-id", task.Task.ID, "err", err)
+		}
+		if r.cfg.AsyncSubmit {
+			return r.queueProof(proofMsg, task.Task.UUID)
+		}
 		return r.submitProof(proofMsg, task.Task.UUID)
 	}
 
-	// if tried times >= 3, it's probably due to circuit proving panic
+	// if tried times > MaxProveRetry, it's probably due to circuit proving panic
 	log.Error("zk proving panic for task", "task-type", task.Task.Type, "task-id", task.Task.ID)
+	r.metrics.proverProvePanicTotal.Inc()
 	return r.submitErr(task, message.ProofFailurePanic, errors.New("zk proving panic for task"))
 }
 
+// claimedTasks returns a copy of the ids of the tasks being proved.
+func (r *Prover) claimedTasks() map[string]struct{} {
+	r.claimMu.Lock()
+	defer r.claimMu.Unlock()
+	claimed := make(map[string]struct{}, len(r.claimed))
+	for id := range r.claimed {
+		claimed[id] = struct{}{}
+	}
+	return claimed
+}
+
+// claimTask marks the task as being proved, so that the other workers skip it.
+func (r *Prover) claimTask(task *store.ProvingTask) {
+	r.claimMu.Lock()
+	defer r.claimMu.Unlock()
+	if r.claimed == nil {
+		r.claimed = make(map[string]claimedTask)
+	}
+	r.claimed[task.Task.ID] = claimedTask{proofType: task.Task.Type, startedAt: time.Now()}
+	if r.cfg.OrderedSubmit {
+		r.order.enqueue(task.Task.ID)
+	}
+}
+
+// releaseTask unmarks the task once the worker is done with it, either proved or left in the stack to be retried.
+func (r *Prover) releaseTask(task *store.ProvingTask) {
+	r.claimMu.Lock()
+	defer r.claimMu.Unlock()
+	delete(r.claimed, task.Task.ID)
+	if r.cfg.OrderedSubmit {
+		r.order.done(task.Task.ID)
+	}
+}
+
+// waitSubmitTurn holds the proof of the task until the proofs of the tasks claimed before are submitted,
+// if OrderedSubmit is set.
+func (r *Prover) waitSubmitTurn(taskID string) {
+	if r.cfg.OrderedSubmit {
+		r.order.waitTurn(taskID)
+	}
+}
+
+// CurrentTask returns the task being proved, the earliest started one if several tasks are proved concurrently.
+// ok is false if the prover is idle.
+func (r *Prover) CurrentTask() (taskID string, proofType message.ProofType, startedAt time.Time, ok bool) {
+	r.claimMu.Lock()
+	defer r.claimMu.Unlock()
+	for id, claimed := range r.claimed {
+		if !ok || claimed.startedAt.Before(startedAt) {
+			taskID, proofType, startedAt, ok = id, claimed.proofType, claimed.startedAt, true
+		}
+	}
+	return taskID, proofType, startedAt, ok
+}
+
+// nextFetchBackoff returns the wait before fetching a task again after a failed fetch. The backoff starts from
+// retryWait and doubles on each consecutive failure up to maxFetchBackoff, the wait is jittered by up to a half
+// of the backoff so that the provers don't retry in lockstep once the coordinator is back.
+func (r *Prover) nextFetchBackoff() time.Duration {
+	if r.fetchBackoff == 0 {
+		r.fetchBackoff = retryWait
+	} else {
+		r.fetchBackoff *= 2
+	}
+	if r.fetchBackoff > maxFetchBackoff {
+		r.fetchBackoff = maxFetchBackoff
+	}
+	return r.fetchBackoff - time.Duration(rand.Int63n(int64(r.fetchBackoff/2)+1))
+}
+
+// maxProveRetry returns the times a task is proved again after the prover crashed proving it.
+func (r *Prover) maxProveRetry() int {
+	if r.cfg.MaxProveRetry == nil {
+		return defaultMaxProveRetry
+	}
+	return *r.cfg.MaxProveRetry
+}
+
 // fetchTaskFromCoordinator fetches a new task from the server
 func (r *Prover) fetchTaskFromCoordinator() (*store.ProvingTask, error) {
 	// prepare the request
@@ -200,7 +636,11 @@ func (r *Prover) fetchTaskFromCoordinator() (*store.ProvingTask, error) {
 	// send the request
 	resp, err := r.coordinatorClient.GetTask(r.ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get task, req: %v, err: %v", req, err)
+		return nil, fmt.Errorf("failed to get task, req: %v, err: %w", req, err)
+	}
+
+	if err = r.verifyTask(resp); err != nil {
+		return nil, err
 	}
 
 	// create a new TaskMsg
@@ -217,6 +657,11 @@ func (r *Prover) fetchTaskFromCoordinator() (*store.ProvingTask, error) {
 		if err = json.Unmarshal([]byte(resp.Data.TaskData), taskMsg.BatchTaskDetail); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal batch task detail: %v", err)
 		}
+		// the chunk infos are passed to the prover core along with the chunk proofs, one for each chunk.
+		if len(taskMsg.BatchTaskDetail.ChunkInfos) != len(taskMsg.BatchTaskDetail.ChunkProofs) {
+			return nil, fmt.Errorf("batch task has %d chunk infos but %d chunk proofs",
+				len(taskMsg.BatchTaskDetail.ChunkInfos), len(taskMsg.BatchTaskDetail.ChunkProofs))
+		}
 	case message.ProofTypeChunk:
 		taskMsg.ChunkTaskDetail = &message.ChunkTaskDetail{}
 		if err = json.Unmarshal([]byte(resp.Data.TaskData), taskMsg.ChunkTaskDetail); err != nil {
@@ -243,15 +688,67 @@ func (r *Prover) fetchTaskFromCoordinator() (*store.ProvingTask, error) {
 	return provingTask, nil
 }
 
+// verifyTask verifies the task is signed by the coordinator, if the public key of the coordinator is configured.
+func (r *Prover) verifyTask(resp *client.GetTaskResponse) error {
+	if r.cfg.Coordinator == nil || r.cfg.Coordinator.PublicKey == "" {
+		return nil
+	}
+	if resp.Data.Signature == "" {
+		return fmt.Errorf("task %s is not signed by the coordinator", resp.Data.TaskID)
+	}
+	task := &message.TaskAssignment{
+		UUID:     resp.Data.UUID,
+		TaskID:   resp.Data.TaskID,
+		TaskType: uint64(resp.Data.TaskType),
+		TaskData: resp.Data.TaskData,
+	}
+	ok, err := task.Verify(resp.Data.Signature, r.cfg.Coordinator.PublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to verify the signature of task %s: %v", resp.Data.TaskID, err)
+	}
+	if !ok {
+		return fmt.Errorf("invalid signature of task %s", resp.Data.TaskID)
+	}
+	return nil
+}
+
 // prove function tries to prove a task. It returns an error if the proof fails.
 func (r *Prover) prove(task *store.ProvingTask) (*message.ProofDetail, error) {
+	start := time.Now()
+	detail, err := r.proveTask(task)
+	r.observeProve(task.Task.Type, start, err)
+	return detail, err
+}
+
+// observeProve records the outcome and the duration of proving a task, the proving aborted on shutdown isn't recorded.
+func (r *Prover) observeProve(proofType message.ProofType, start time.Time, err error) {
+	if errors.Is(err, errProveAborted) {
+		return
+	}
+	r.metrics.proverProveDurationSeconds.WithLabelValues(proofTypeLabel(proofType)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		r.metrics.proverProofsFailedTotal.Inc()
+		return
+	}
+	r.metrics.proverProofsSucceededTotal.Inc()
+}
+
+func (r *Prover) proveTask(task *store.ProvingTask) (*message.ProofDetail, error) {
 	detail := &message.ProofDetail{
 		ID:     task.Task.ID,
 		Type:   task.Task.Type,
 		Status: message.StatusOk,
 	}
 
-	switch r.Type() {
+	// the prover core only proves the tasks of its own proof type.
+	if task.Task.Type != r.Type() {
+		err := fmt.Errorf("task type %v mismatches the prover type %v", task.Task.Type, r.Type())
+		detail.Status = message.StatusProofError
+		detail.Error = err.Error()
+		return detail, err
+	}
+
+	switch task.Task.Type {
 	case message.ProofTypeChunk:
 		proof, err := r.proveChunk(task)
 		if err != nil {
@@ -276,6 +773,8 @@ func (r *Prover) prove(task *store.ProvingTask) (*message.ProofDetail, error) {
 
 	default:
 		err := fmt.Errorf("invalid task type: %v", task.Task.Type)
+		detail.Status = message.StatusProofError
+		detail.Error = err.Error()
 		return detail, err
 	}
 }
@@ -284,27 +783,502 @@ func (r *Prover) proveChunk(task *store.ProvingTask) (*message.ChunkProof, error
 	if task.Task.ChunkTaskDetail == nil {
 		return nil, fmt.Errorf("ChunkTaskDetail is empty")
 	}
-	traces, err := r.getSortedTracesByHashes(task.Task.ChunkTaskDetail.BlockHashes)
+	if r.streamTraces(task.Task.ChunkTaskDetail) {
+		return r.proveChunkStream(task)
+	}
+	var (
+		traces []*types.BlockTrace
+		err    error
+	)
+	runPinned(r.traceFetchCPUs(), func() {
+		traces, err = r.getChunkTraces(task.Task.ChunkTaskDetail)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = r.checkEpochBoundary(traces[0].Header.Number.Uint64(), traces[len(traces)-1].Header.Number.Uint64()); err != nil {
+		return nil, err
+	}
+	return runProve(r, func() (*message.ChunkProof, error) {
+		return r.proverCore.ProveChunk(task.Task.ID, traces)
+	})
+}
+
+// splitChunk reports whether the chunk task has more blocks than MaxChunkBlocks, so that it's split into sub-chunks.
+func (r *Prover) splitChunk(task *store.ProvingTask) bool {
+	detail := task.Task.ChunkTaskDetail
+	if r.cfg.MaxChunkBlocks == 0 || task.Task.Type != message.ProofTypeChunk || detail == nil {
+		return false
+	}
+	numBlocks := len(detail.BlockHashes)
+	if len(detail.BlockTraces) > numBlocks {
+		numBlocks = len(detail.BlockTraces)
+	}
+	return uint64(numBlocks) > r.cfg.MaxChunkBlocks
+}
+
+// proveAndSubmitSubChunks splits the chunk task into sub-chunks of at most MaxChunkBlocks blocks, then proves and
+// submits the sub-chunks one by one under the id of the task. The whole task is reported as failed if any sub-chunk fails.
+func (r *Prover) proveAndSubmitSubChunks(task *store.ProvingTask, lease *taskLease) error {
+	var (
+		traces []*types.BlockTrace
+		err    error
+	)
+	runPinned(r.traceFetchCPUs(), func() {
+		traces, err = r.getChunkTraces(task.Task.ChunkTaskDetail)
+	})
+	if err != nil {
+		log.Error("failed to get traces of chunk task", "task-id", task.Task.ID, "err", err)
+		return r.submitErr(task, message.ProofFailureNoPanic, err)
+	}
+	subChunks, err := splitChunkTraces(traces, r.cfg.MaxChunkBlocks)
+	if err != nil {
+		log.Error("failed to split chunk task", "task-id", task.Task.ID, "err", err)
+		return r.submitErr(task, message.ProofFailureNoPanic, err)
+	}
+	log.Info("split chunk task into sub-chunks", "task-id", task.Task.ID, "blocks", len(traces), "sub-chunks", len(subChunks))
+
+	for i, subTraces := range subChunks {
+		if lease.isLost() {
+			return r.abandonTask(task)
+		}
+		subTask := &message.SubTask{
+			Index:            i,
+			Count:            len(subChunks),
+			StartBlockNumber: subTraces[0].Header.Number.Uint64(),
+			EndBlockNumber:   subTraces[len(subTraces)-1].Header.Number.Uint64(),
+		}
+		subTaskID := fmt.Sprintf("%s-%d", task.Task.ID, i)
+		if err = r.checkEpochBoundary(subTask.StartBlockNumber, subTask.EndBlockNumber); err != nil {
+			return r.submitErr(task, message.ProofFailureNoPanic, err)
+		}
+
+		start := time.Now()
+		proof, err := runProve(r, func() (*message.ChunkProof, error) {
+			return r.proverCore.ProveChunk(subTaskID, subTraces)
+		})
+		r.observeProve(message.ProofTypeChunk, start, err)
+		if errors.Is(err, errProveAborted) {
+			return r.restoreAbortedTask(task, err)
+		}
+		if err != nil {
+			log.Error("failed to prove sub-chunk", "task-id", task.Task.ID, "sub-chunk", i, "err", err)
+			return r.submitErr(task, message.ProofFailureNoPanic, err)
+		}
+		if err = r.proofLimiter.record(time.Now()); err != nil {
+			log.Error("failed to record proof count", "task-id", task.Task.ID, "err", err)
+		}
+
+		proofMsg := &message.ProofDetail{
+			ID:         subTaskID,
+			Type:       message.ProofTypeChunk,
+			Status:     message.StatusOk,
+			ChunkProof: proof,
+		}
+		if err = r.submitSubProof(proofMsg, task, subTask); err != nil {
+			if deleteErr := r.stack.Delete(task.Task.ID); deleteErr != nil {
+				log.Error("prover stack pop failed", "task_type", task.Task.Type, "task_id", task.Task.ID, "err", deleteErr)
+			}
+			return err
+		}
+	}
+
+	if deleteErr := r.stack.Delete(task.Task.ID); deleteErr != nil {
+		log.Error("prover stack pop failed", "task_type", task.Task.Type, "task_id", task.Task.ID, "err", deleteErr)
+	}
+	return nil
+}
+
+// runProve runs the prover core call on the prove cpus. The prover core can't be interrupted, so once the prover is
+// stopped or its context is cancelled, errProveAborted is returned without waiting for the call, whose result is discarded.
+// Likewise ErrProveTimeout is returned once the call takes longer than the prove timeout, the call then keeps its prove
+// slot until it returns, so that the timed out calls don't pile up on the prover core.
+func runProve[T any](r *Prover, prove func() (T, error)) (T, error) {
+	type result struct {
+		proof T
+		err   error
+	}
+	var empty T
+	if !r.acquireProveSlot() {
+		return empty, errProveAborted
+	}
+	resultCh := make(chan result, 1)
+	go runPinned(r.proveCPUs(), func() {
+		defer r.releaseProveSlot()
+		proof, err := prove()
+		resultCh <- result{proof, err}
+	})
+
+	var timeout <-chan time.Time
+	if r.cfg.ProveTimeoutSec > 0 {
+		timer := time.NewTimer(time.Duration(r.cfg.ProveTimeoutSec) * time.Second)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case res := <-resultCh:
+		// the traces are fetched with the context of the prover, so the call fails rather than hangs once it's cancelled.
+		if res.err == nil || !r.stopping() {
+			return res.proof, res.err
+		}
+	case <-timeout:
+		return empty, fmt.Errorf("%w after %v sec", ErrProveTimeout, r.cfg.ProveTimeoutSec)
+	case <-r.stopChan:
+	case <-r.ctx.Done():
+	}
+	return empty, errProveAborted
+}
+
+// acquireProveSlot waits for a prove slot, e.g. for a timed out call to return, it returns false once the prover is stopping.
+func (r *Prover) acquireProveSlot() bool {
+	r.proveSlotsOnce.Do(func() {
+		concurrency := r.cfg.Concurrency
+		if concurrency <= 0 {
+			concurrency = 1
+		}
+		r.proveSlots = make(chan struct{}, concurrency)
+	})
+	select {
+	case r.proveSlots <- struct{}{}:
+		return true
+	default:
+	}
+	log.Warn("waiting for the timed out prove to return before proving")
+	select {
+	case r.proveSlots <- struct{}{}:
+		return true
+	case <-r.stopChan:
+	case <-r.ctx.Done():
+	}
+	return false
+}
+
+func (r *Prover) releaseProveSlot() {
+	<-r.proveSlots
+}
+
+// stopping reports whether the prover is stopped or its context is cancelled.
+func (r *Prover) stopping() bool {
+	return atomic.LoadInt64(&r.isClosed) == 1 || r.ctx.Err() != nil
+}
+
+// restoreAbortedTask keeps the task aborted on shutdown in the stack without counting the attempt, so that it's proved
+// again after the restart rather than taken as a proving panic.
+func (r *Prover) restoreAbortedTask(task *store.ProvingTask, err error) error {
+	log.Warn("proving task aborted", "task-type", task.Task.Type, "task-id", task.Task.ID)
+	if updateErr := r.stack.UpdateTimes(task, task.Times-1); updateErr != nil {
+		log.Warn("failed to restore times of the aborted task", "task-id", task.Task.ID, "err", updateErr)
+	}
+	return err
+}
+
+// abandonTask drops the task without submitting anything, since its lease is lost and it's probably owned by another prover now.
+// The prover core can't be interrupted, so the proving of the task is only abandoned after it's done.
+func (r *Prover) abandonTask(task *store.ProvingTask) error {
+	if err := r.stack.Delete(task.Task.ID); err != nil {
+		log.Error("prover stack pop failed", "task_type", task.Task.Type, "task_id", task.Task.ID, "err", err)
+	}
+	return fmt.Errorf("%w, abandon task %v", ErrTaskLeaseLost, task.Task.ID)
+}
+
+// splitChunkTraces splits the sorted traces of a chunk into contiguous sub-chunks of at most maxBlocks blocks,
+// each block must follow the one before it by both number and parent hash.
+func splitChunkTraces(traces []*types.BlockTrace, maxBlocks uint64) ([][]*types.BlockTrace, error) {
+	if len(traces) == 0 {
+		return nil, fmt.Errorf("traces are empty")
+	}
+	if maxBlocks == 0 {
+		return nil, fmt.Errorf("max blocks of sub-chunks is zero")
+	}
+	for i := 1; i < len(traces); i++ {
+		prev, cur := traces[i-1].Header, traces[i].Header
+		if prev.Number.Uint64()+1 != cur.Number.Uint64() || cur.ParentHash != prev.Hash() {
+			return nil, fmt.Errorf("block %v doesn't follow block %v", cur.Number, prev.Number)
+		}
+	}
+
+	var subChunks [][]*types.BlockTrace
+	for start := 0; start < len(traces); start += int(maxBlocks) {
+		end := start + int(maxBlocks)
+		if end > len(traces) {
+			end = len(traces)
+		}
+		subChunks = append(subChunks, traces[start:end])
+	}
+	return subChunks, nil
+}
+
+// submitSubProof submits the proof of a sub-chunk of the task, the proof is kept to resubmit it later
+// if the coordinator can't be connected.
+func (r *Prover) submitSubProof(msg *message.ProofDetail, task *store.ProvingTask, subTask *message.SubTask) error {
+	r.waitSubmitTurn(task.Task.ID)
+
+	req, err := newSubmitProofRequest(msg, task.Task.UUID, r.proverCore.ParamsHash())
+	if err != nil {
+		return err
+	}
+	req.TaskID = task.Task.ID
+	req.SubTask = subTask
+
+	if err = r.coordinatorClient.SubmitProof(r.ctx, req); err != nil {
+		r.holdOffSubmit(err)
+		if errors.Is(errors.Unwrap(err), client.ErrCoordinatorConnect) {
+			pendingProof := &store.PendingProof{UUID: task.Task.UUID, Proof: msg, SubTask: subTask, TaskID: task.Task.ID}
+			pushErr := r.stack.PushPendingProof(pendingProof)
+			if pushErr == nil {
+				log.Warn("failed to submit sub-chunk proof, resubmit it later", "task-id", task.Task.ID, "sub-chunk", subTask.Index, "err", err)
+				return nil
+			}
+			log.Error("failed to push proof into submit queue", "task_type", msg.Type, "task_id", msg.ID, "err", pushErr)
+		}
+		return fmt.Errorf("error submitting sub-chunk proof: %v", err)
+	}
+	log.Info("sub-chunk proof submitted successfully", "task-id", task.Task.ID, "sub-chunk", subTask.Index, "sub-chunks", subTask.Count,
+		"start block", subTask.StartBlockNumber, "end block", subTask.EndBlockNumber)
+	return nil
+}
+
+// streamTraces reports whether the traces of the chunk are streamed from l2geth rather than buffered,
+// the traces provided by the coordinator are always buffered.
+func (r *Prover) streamTraces(detail *message.ChunkTaskDetail) bool {
+	return r.cfg.L2Geth != nil && r.cfg.L2Geth.StreamTraces && len(detail.BlockTraces) == 0 && detail.TracesURL == ""
+}
+
+func (r *Prover) proveChunkStream(task *store.ProvingTask) (*message.ChunkProof, error) {
+	var (
+		next core.TraceIterator
+		err  error
+	)
+	runPinned(r.traceFetchCPUs(), func() {
+		next, err = r.streamSortedTracesByHashes(task.Task.ChunkTaskDetail.BlockHashes)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("get traces from eth node failed, block hashes: %v, err: %v", task.Task.ChunkTaskDetail.BlockHashes, err)
 	}
-	return r.proverCore.ProveChunk(task.Task.ID, traces)
+	return runProve(r, func() (*message.ChunkProof, error) {
+		return r.proverCore.ProveChunkStream(task.Task.ID, next)
+	})
 }
 
 func (r *Prover) proveBatch(task *store.ProvingTask) (*message.BatchProof, error) {
 	if task.Task.BatchTaskDetail == nil {
 		return nil, fmt.Errorf("BatchTaskDetail is empty")
 	}
-	return r.proverCore.ProveBatch(task.Task.ID, task.Task.BatchTaskDetail.ChunkInfos, task.Task.BatchTaskDetail.ChunkProofs)
+	chunkProofs := task.Task.BatchTaskDetail.ChunkProofs
+	if r.cfg.CheckChunkProofOrder {
+		var err error
+		chunkProofs, err = orderChunkProofs(task.Task.BatchTaskDetail.ChunkInfos, chunkProofs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return runProve(r, func() (*message.BatchProof, error) {
+		return r.proverCore.ProveBatch(task.Task.ID, task.Task.BatchTaskDetail.ChunkInfos, chunkProofs)
+	})
+}
+
+// orderChunkProofs returns the chunk proofs in the order of the chunk infos, matching each proof by the chunk info
+// it carries. The chunk proofs are left as received if they're already in order.
+func orderChunkProofs(chunkInfos []*message.ChunkInfo, chunkProofs []*message.ChunkProof) ([]*message.ChunkProof, error) {
+	if len(chunkInfos) != len(chunkProofs) {
+		return nil, fmt.Errorf("%w, chunk infos: %v, chunk proofs: %v", ErrChunkProofsMismatch, len(chunkInfos), len(chunkProofs))
+	}
+
+	proofsByInfo := make(map[message.ChunkInfo][]*message.ChunkProof, len(chunkProofs))
+	for i, proof := range chunkProofs {
+		if proof == nil || proof.ChunkInfo == nil {
+			return nil, fmt.Errorf("%w, chunk proof %v carries no chunk info", ErrChunkProofsMismatch, i)
+		}
+		proofsByInfo[*proof.ChunkInfo] = append(proofsByInfo[*proof.ChunkInfo], proof)
+	}
+
+	ordered := make([]*message.ChunkProof, len(chunkInfos))
+	reordered := false
+	for i, info := range chunkInfos {
+		if info == nil {
+			return nil, fmt.Errorf("%w, chunk info %v is empty", ErrChunkProofsMismatch, i)
+		}
+		proofs := proofsByInfo[*info]
+		if len(proofs) == 0 {
+			return nil, fmt.Errorf("%w, no chunk proof for chunk %v, post state root: %v", ErrChunkProofsMismatch, i, info.PostStateRoot.Hex())
+		}
+		ordered[i] = proofs[0]
+		proofsByInfo[*info] = proofs[1:]
+		reordered = reordered || ordered[i] != chunkProofs[i]
+	}
+	if reordered {
+		log.Warn("chunk proofs are out of order, reordered them by the chunk infos")
+	}
+	return ordered, nil
 }
 
 func (r *Prover) submitProof(msg *message.ProofDetail, uuid string) error {
+	r.waitSubmitTurn(msg.ID)
+
 	// prepare the submit request
+	req, err := newSubmitProofRequest(msg, uuid, r.proverCore.ParamsHash())
+	if err != nil {
+		return err
+	}
+
+	// persist the proof and pop the task before submitting the proof, so that the proof is resubmitted
+	// rather than proved again if the prover crashes before it's submitted.
+	persisted := true
+	if pushErr := r.stack.PushPendingProof(&store.PendingProof{UUID: uuid, Proof: msg}); pushErr != nil {
+		log.Error("failed to push proof into submit queue", "task_type", msg.Type, "task_id", msg.ID, "err", pushErr)
+		persisted = false
+	} else if deleteErr := r.stack.Delete(msg.ID); deleteErr != nil {
+		log.Error("prover stack pop failed", "task_type", msg.Type, "task_id", msg.ID, "err", deleteErr)
+	}
+
+	// send the submit request
+	err = r.coordinatorClient.SubmitProof(r.ctx, req)
+	if err != nil {
+		r.holdOffSubmit(err)
+	}
+	retryable := err != nil && errors.Is(errors.Unwrap(err), client.ErrCoordinatorConnect)
+	switch {
+	case retryable && !persisted:
+		// keep the task to prove it again, since the proof can't be resubmitted.
+		return fmt.Errorf("error submitting proof: %v", err)
+	case retryable:
+		// keep the proof to resubmit it later rather than proving the task again.
+	case persisted:
+		if deleteErr := r.stack.DeletePendingProof(msg.ID); deleteErr != nil {
+			log.Error("failed to delete proof from submit queue", "task_type", msg.Type, "task_id", msg.ID, "err", deleteErr)
+		}
+	default:
+		if deleteErr := r.stack.Delete(msg.ID); deleteErr != nil {
+			log.Error("prover stack pop failed", "task_type", msg.Type, "task_id", msg.ID, "err", deleteErr)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error submitting proof: %v", err)
+	}
+
+	log.Info("proof submitted successfully", "task-id", msg.ID, "task-type", msg.Type, "task-status", msg.Status, "err", msg.Error)
+
+	return nil
+}
+
+// queueProof queues the proof into the submit queue to be submitted by the submit loop, the proof is submitted right
+// away if it fails to be queued.
+func (r *Prover) queueProof(msg *message.ProofDetail, uuid string) error {
+	if err := r.stack.PushPendingProof(&store.PendingProof{UUID: uuid, Proof: msg}); err != nil {
+		log.Error("failed to push proof into submit queue, submit it right away", "task_type", msg.Type, "task_id", msg.ID, "err", err)
+		return r.submitProof(msg, uuid)
+	}
+	if deleteErr := r.stack.Delete(msg.ID); deleteErr != nil {
+		log.Error("prover stack pop failed", "task_type", msg.Type, "task_id", msg.ID, "err", deleteErr)
+	}
+	select {
+	case r.submitCh <- struct{}{}:
+	default:
+	}
+	log.Info("proof queued to be submitted", "task-id", msg.ID, "task-type", msg.Type)
+	return nil
+}
+
+// submitLoop submits the queued proofs in the background, the failed submissions are retried every retryWait.
+func (r *Prover) submitLoop() {
+	ticker := time.NewTicker(retryWait)
+	defer ticker.Stop()
+	for {
+		r.submitPendingProofs()
+		select {
+		case <-r.stopChan:
+			return
+		case <-r.ctx.Done():
+			return
+		case <-r.submitCh:
+		case <-ticker.C:
+		}
+	}
+}
+
+// submitPendingProofs submits the proofs of the submit queue in order until it's empty, it stops at the first failed
+// submission so that the proof is retried later.
+func (r *Prover) submitPendingProofs() {
+	for r.submitWait() <= 0 {
+		proof, err := r.stack.PeekPendingProof()
+		if err != nil {
+			if !errors.Is(err, store.ErrEmpty) {
+				log.Error("failed to peek from submit queue", "err", err)
+			}
+			return
+		}
+		if err = r.resubmitPendingProof(proof); err != nil {
+			log.Error("failed to submit queued proof", "task-id", proof.Proof.ID, "err", err)
+			return
+		}
+	}
+}
+
+// holdOffSubmit delays the next proof submission if the coordinator hinted when to retry.
+func (r *Prover) holdOffSubmit(err error) {
+	wait, ok := client.RetryAfter(err)
+	if !ok {
+		return
+	}
+	r.submitMu.Lock()
+	r.submitNotBefore = time.Now().Add(wait)
+	r.submitMu.Unlock()
+	r.metrics.proverSubmitBackpressureTotal.Inc()
+	log.Warn("coordinator asked to retry submitting proofs later", "retry after", wait)
+}
+
+// submitWait returns how long the coordinator asked to hold off submitting proofs.
+func (r *Prover) submitWait() time.Duration {
+	r.submitMu.Lock()
+	defer r.submitMu.Unlock()
+	return time.Until(r.submitNotBefore)
+}
+
+// resubmitPendingProof resubmits a proof of the submit queue. The proof is moved to the failed proofs
+// once the coordinator rejects it or it has been retried MaxSubmitRetries times.
+func (r *Prover) resubmitPendingProof(proof *store.PendingProof) error {
+	req, err := newSubmitProofRequest(proof.Proof, proof.UUID, r.proverCore.ParamsHash())
+	if err == nil {
+		if proof.SubTask != nil {
+			req.TaskID = proof.TaskID
+			req.SubTask = proof.SubTask
+		}
+		err = r.coordinatorClient.SubmitProof(r.ctx, req)
+	}
+	if err == nil {
+		if deleteErr := r.stack.DeletePendingProof(proof.Proof.ID); deleteErr != nil {
+			log.Error("failed to delete proof from submit queue", "task_type", proof.Proof.Type, "task_id", proof.Proof.ID, "err", deleteErr)
+		}
+		log.Info("proof resubmitted successfully", "task-id", proof.Proof.ID, "task-type", proof.Proof.Type, "retries", proof.Retries)
+		return nil
+	}
+
+	r.holdOffSubmit(err)
+	proof.Retries++
+	if !errors.Is(errors.Unwrap(err), client.ErrCoordinatorConnect) ||
+		(r.cfg.MaxSubmitRetries > 0 && proof.Retries >= r.cfg.MaxSubmitRetries) {
+		if moveErr := r.stack.MoveToFailedProofs(proof); moveErr != nil {
+			return fmt.Errorf("failed to move proof to failed proofs: %v, submit err: %v", moveErr, err)
+		}
+		r.metrics.proverProofSubmitGiveUpTotal.Inc()
+		log.Error("give up submitting proof", "task-id", proof.Proof.ID, "task-type", proof.Proof.Type, "retries", proof.Retries, "err", err)
+		return fmt.Errorf("error resubmitting proof, moved to failed proofs: %v", err)
+	}
+
+	if pushErr := r.stack.PushPendingProof(proof); pushErr != nil {
+		log.Error("failed to update proof in submit queue", "task_type", proof.Proof.Type, "task_id", proof.Proof.ID, "err", pushErr)
+	}
+	return fmt.Errorf("error resubmitting proof, retries: %d, err: %v", proof.Retries, err)
+}
+
+func newSubmitProofRequest(msg *message.ProofDetail, uuid string, paramsHash string) (*client.SubmitProofRequest, error) {
 	req := &client.SubmitProofRequest{
-		UUID:     uuid,
-		TaskID:   msg.ID,
-		TaskType: int(msg.Type),
-		Status:   int(msg.Status),
+		UUID:       uuid,
+		TaskID:     msg.ID,
+		TaskType:   int(msg.Type),
+		Status:     int(msg.Status),
+		ParamsHash: paramsHash,
 	}
 
 	// marshal proof by tasktype
@@ -313,7 +1287,7 @@ func (r *Prover) submitProof(msg *message.ProofDetail, uuid string) error {
 		if msg.ChunkProof != nil {
 			proofData, err := json.Marshal(msg.ChunkProof)
 			if err != nil {
-				return fmt.Errorf("error marshaling chunk proof: %v", err)
+				return nil, fmt.Errorf("error marshaling chunk proof: %v", err)
 			}
 			req.Proof = string(proofData)
 		}
@@ -321,31 +1295,17 @@ func (r *Prover) submitProof(msg *message.ProofDetail, uuid string) error {
 		if msg.BatchProof != nil {
 			proofData, err := json.Marshal(msg.BatchProof)
 			if err != nil {
-				return fmt.Errorf("error marshaling batch proof: %v", err)
+				return nil, fmt.Errorf("error marshaling batch proof: %v", err)
 			}
 			req.Proof = string(proofData)
 		}
 	}
-
-	// send the submit request
-	if err := r.coordinatorClient.SubmitProof(r.ctx, req); err != nil {
-		if !errors.Is(errors.Unwrap(err), client.ErrCoordinatorConnect) {
-			if deleteErr := r.stack.Delete(msg.ID); deleteErr != nil {
-				log.Error("prover stack pop failed", "task_type", msg.Type, "task_id", msg.ID, "err", deleteErr)
-			}
-		}
-		return fmt.Errorf("error submitting proof: %v", err)
-	}
-
-	if deleteErr := r.stack.Delete(msg.ID); deleteErr != nil {
-		log.Error("prover stack pop failed", "task_type", msg.Type, "task_id", msg.ID, "err", deleteErr)
-	}
-	log.Info("proof submitted successfully", "task-id", msg.ID, "task-type", msg.Type, "task-status", msg.Status, "err", msg.Error)
-
-	return nil
+	return req, nil
 }
 
 func (r *Prover) submitErr(task *store.ProvingTask, proofFailureType message.ProofFailureType, err error) error {
+	r.waitSubmitTurn(task.Task.ID)
+
 	// prepare the submit request
 	req := &client.SubmitProofRequest{
 		UUID:        task.Task.UUID,
@@ -376,18 +1336,221 @@ func (r *Prover) submitErr(task *store.ProvingTask, proofFailureType message.Pro
 	return nil
 }
 
+// getChunkTraces returns the traces of the chunk, the traces pre-fetched by the coordinator
+// are used if provided, otherwise the traces are fetched from l2geth.
+func (r *Prover) getChunkTraces(detail *message.ChunkTaskDetail) ([]*types.BlockTrace, error) {
+	traces := detail.BlockTraces
+	if len(traces) == 0 && detail.TracesURL != "" {
+		resp, err := resty.New().R().SetContext(r.ctx).SetResult(&traces).Get(detail.TracesURL)
+		if err != nil {
+			return nil, fmt.Errorf("get traces from url failed, url: %v, err: %v", detail.TracesURL, err)
+		}
+		if resp.IsError() {
+			return nil, fmt.Errorf("get traces from url failed, url: %v, status: %v", detail.TracesURL, resp.Status())
+		}
+	}
+	if len(traces) == 0 && r.fetchTracesByRange(detail) {
+		rangeTraces, err := r.getTracesByRange(detail.StartBlockNumber, detail.EndBlockNumber, detail.BlockHashes)
+		if err == nil {
+			return rangeTraces, nil
+		}
+		log.Warn("get traces by range failed, fall back to block hashes", "start", detail.StartBlockNumber, "end", detail.EndBlockNumber, "err", err)
+	}
+	if len(traces) == 0 {
+		var err error
+		traces, err = r.getSortedTracesByHashes(detail.BlockHashes)
+		if err != nil {
+			return nil, fmt.Errorf("get traces from eth node failed, block hashes: %v, err: %v", detail.BlockHashes, err)
+		}
+		return traces, nil
+	}
+
+	if len(detail.BlockHashes) != 0 {
+		if len(traces) != len(detail.BlockHashes) {
+			return nil, fmt.Errorf("provided traces mismatch block hashes, traces: %v, block hashes: %v", len(traces), len(detail.BlockHashes))
+		}
+		blockHashes := make(map[common.Hash]struct{}, len(detail.BlockHashes))
+		for _, blockHash := range detail.BlockHashes {
+			blockHashes[blockHash] = struct{}{}
+		}
+		for _, trace := range traces {
+			if trace == nil || trace.Header == nil {
+				return nil, fmt.Errorf("provided trace is empty")
+			}
+			if _, ok := blockHashes[trace.Header.Hash()]; !ok {
+				return nil, fmt.Errorf("provided trace of block %v is not in the block hashes", trace.Header.Hash())
+			}
+		}
+	}
+	if err := sortAndCheckTraces(traces); err != nil {
+		return nil, fmt.Errorf("invalid provided traces: %v", err)
+	}
+	return traces, nil
+}
+
 func (r *Prover) getSortedTracesByHashes(blockHashes []common.Hash) ([]*types.BlockTrace, error) {
 	if len(blockHashes) == 0 {
 		return nil, fmt.Errorf("blockHashes is empty")
 	}
 
-	var traces []*types.BlockTrace
+	traces := make([]*types.BlockTrace, len(blockHashes))
+	eg, ctx := errgroup.WithContext(r.ctx)
+	eg.SetLimit(r.traceFetchParallelism())
+	for i := range blockHashes {
+		i := i
+		eg.Go(func() error {
+			trace, err := r.getBlockTraceByHash(ctx, blockHashes[i])
+			if err != nil {
+				return err
+			}
+			traces[i] = trace
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	if err := sortAndCheckTraces(traces); err != nil {
+		return nil, err
+	}
+	return traces, nil
+}
+
+// traceFetchParallelism is the max number of traces fetched from l2geth concurrently.
+func (r *Prover) traceFetchParallelism() int {
+	if r.cfg.L2Geth == nil || r.cfg.L2Geth.TraceFetchParallelism <= 0 {
+		return 1
+	}
+	return r.cfg.L2Geth.TraceFetchParallelism
+}
+
+// getBlockTraceByHash fetches the trace of a block from l2geth, the traces are cached so that
+// a retried task doesn't fetch them again.
+func (r *Prover) getBlockTraceByHash(ctx context.Context, blockHash common.Hash) (*types.BlockTrace, error) {
+	if r.traceCache != nil {
+		if trace, ok := r.traceCache.Get(blockHash); ok {
+			return trace.(*types.BlockTrace), nil
+		}
+	}
+	trace, err := r.l2GethClient.GetBlockTraceByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if r.traceCache != nil && trace != nil {
+		r.traceCache.Add(blockHash, trace)
+	}
+	return trace, nil
+}
+
+// fetchTracesByRange reports whether the traces of the chunk are fetched by its block range.
+func (r *Prover) fetchTracesByRange(detail *message.ChunkTaskDetail) bool {
+	return r.cfg.L2Geth != nil && r.cfg.L2Geth.FetchTracesByRange && r.l2GethRPCClient != nil &&
+		detail.EndBlockNumber != 0 && detail.StartBlockNumber <= detail.EndBlockNumber
+}
+
+// getTracesByRange fetches the traces of the blocks in [start, end] from l2geth in one batch request,
+// and checks the traces match the block hashes of the task.
+func (r *Prover) getTracesByRange(start, end uint64, blockHashes []common.Hash) ([]*types.BlockTrace, error) {
+	if uint64(len(blockHashes)) != end-start+1 {
+		return nil, fmt.Errorf("block range mismatches block hashes, range: [%v, %v], block hashes: %v", start, end, len(blockHashes))
+	}
+	expected := make(map[common.Hash]struct{}, len(blockHashes))
+	for _, blockHash := range blockHashes {
+		expected[blockHash] = struct{}{}
+	}
+
+	traces := make([]*types.BlockTrace, len(blockHashes))
+	reqs := make([]rpc.BatchElem, len(blockHashes))
+	for i := range reqs {
+		reqs[i] = rpc.BatchElem{
+			Method: "scroll_getBlockTraceByNumberOrHash",
+			Args:   []interface{}{hexutil.EncodeUint64(start + uint64(i))},
+			Result: &traces[i],
+		}
+	}
+	if err := r.l2GethRPCClient.BatchCallContext(r.ctx, reqs); err != nil {
+		return nil, err
+	}
+
+	for i, req := range reqs {
+		number := start + uint64(i)
+		if req.Error != nil {
+			return nil, fmt.Errorf("get trace of block %v failed: %w", number, req.Error)
+		}
+		trace := traces[i]
+		if trace == nil || trace.Header == nil || trace.Header.Number == nil || trace.Header.Number.Uint64() != number {
+			return nil, fmt.Errorf("trace of block %v is empty or mismatches its number", number)
+		}
+		if _, ok := expected[trace.Header.Hash()]; !ok {
+			return nil, fmt.Errorf("trace of block %v is not in the block hashes, hash: %v", number, trace.Header.Hash())
+		}
+	}
+	return traces, nil
+}
+
+// streamSortedTracesByHashes sorts the block hashes by the numbers of their headers and returns an
+// iterator fetching the traces from l2geth one at a time in block order.
+func (r *Prover) streamSortedTracesByHashes(blockHashes []common.Hash) (core.TraceIterator, error) {
+	if len(blockHashes) == 0 {
+		return nil, fmt.Errorf("blockHashes is empty")
+	}
+
+	headers := make([]*types.Header, 0, len(blockHashes))
 	for _, blockHash := range blockHashes {
-		trace, err := r.l2GethClient.GetBlockTraceByHash(r.ctx, blockHash)
+		header, err := r.l2GethClient.HeaderByHash(r.ctx, blockHash)
 		if err != nil {
 			return nil, err
 		}
-		traces = append(traces, trace)
+		headers = append(headers, header)
+	}
+	sort.Slice(headers, func(i, j int) bool {
+		return headers[i].Number.Int64() < headers[j].Number.Int64()
+	})
+	for i := 0; i < len(headers)-1; i++ {
+		if headers[i].Number.Int64()+1 != headers[i+1].Number.Int64() {
+			return nil, fmt.Errorf("block numbers are not continuous, got %v and %v",
+				headers[i].Number.Int64(), headers[i+1].Number.Int64())
+		}
+	}
+	if err := r.checkEpochBoundary(headers[0].Number.Uint64(), headers[len(headers)-1].Number.Uint64()); err != nil {
+		return nil, err
+	}
+
+	i := 0
+	return func() (*types.BlockTrace, error) {
+		if i >= len(headers) {
+			return nil, io.EOF
+		}
+		trace, err := r.getBlockTraceByHash(r.ctx, headers[i].Hash())
+		if err != nil {
+			return nil, err
+		}
+		if trace == nil || trace.Header == nil || trace.Header.Number.Cmp(headers[i].Number) != 0 {
+			return nil, fmt.Errorf("trace of block %v is empty or mismatches its header", headers[i].Number)
+		}
+		i++
+		return trace, nil
+	}, nil
+}
+
+// checkEpochBoundary checks the blocks in [firstBlock, lastBlock] are within a single epoch,
+// i.e. no configured epoch boundary is in (firstBlock, lastBlock].
+func (r *Prover) checkEpochBoundary(firstBlock, lastBlock uint64) error {
+	for _, boundary := range r.cfg.EpochBoundaries {
+		if firstBlock < boundary && boundary <= lastBlock {
+			return fmt.Errorf("%w, blocks: [%v, %v], boundary: %v", ErrChunkSpansEpochs, firstBlock, lastBlock, boundary)
+		}
+	}
+	return nil
+}
+
+// sortAndCheckTraces sorts the traces by block number and checks they are continuous.
+func sortAndCheckTraces(traces []*types.BlockTrace) error {
+	for _, trace := range traces {
+		if trace == nil || trace.Header == nil {
+			return fmt.Errorf("trace is empty")
+		}
 	}
 
 	// Sort BlockTraces by header number.
@@ -397,24 +1560,76 @@ func (r *Prover) getSortedTracesByHashes(blockHashes []common.Hash) ([]*types.Bl
 
 	// Check that the block numbers are continuous
 	for i := 0; i < len(traces)-1; i++ {
+		if traces[i].Header.Number.Cmp(traces[i+1].Header.Number) == 0 {
+			return fmt.Errorf("block numbers are not continuous, got duplicate block %v", traces[i].Header.Number.Int64())
+		}
 		if traces[i].Header.Number.Int64()+1 != traces[i+1].Header.Number.Int64() {
-			return nil, fmt.Errorf("block numbers are not continuous, got %v and %v",
+			return fmt.Errorf("block numbers are not continuous, got %v and %v",
 				traces[i].Header.Number.Int64(), traces[i+1].Header.Number.Int64())
 		}
 	}
-	return traces, nil
+	return nil
+}
+
+// Done returns a channel closed once the prover is stopped.
+func (r *Prover) Done() <-chan struct{} {
+	return r.stopChan
 }
 
-// Stop closes the websocket connection.
+// Err returns the fatal error stopping the prover, nil if it's not stopped by a fatal error.
+func (r *Prover) Err() error {
+	if fatalErr, ok := r.fatalErr.Load().(*FatalError); ok {
+		return fatalErr
+	}
+	return nil
+}
+
+// StopGracefully stops taking new tasks and waits for the tasks being proved to be proved and submitted before
+// stopping the prover, the prover is stopped anyway once the timeout is reached.
+func (r *Prover) StopGracefully(timeout time.Duration) error {
+	r.gracefulMu.Lock()
+	atomic.StoreInt32(&r.gracefulStop, 1)
+	r.gracefulMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.inFlight.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+		log.Info("tasks in progress are done, stop prover")
+	case <-time.After(timeout):
+		err = fmt.Errorf("timed out after %v waiting for the tasks in progress", timeout)
+	}
+	r.Stop()
+	return err
+}
+
+// Stop stops the prover, it waits for the workers to exit before closing the stack db. It must not be called
+// by the workers, which call signalStop instead.
 func (r *Prover) Stop() {
-	if atomic.LoadInt64(&r.isClosed) == 1 {
+	r.signalStop()
+	// the workers still use the stack until they exit, e.g. to restore the aborted tasks.
+	r.workers.Wait()
+	r.closeOnce.Do(func() {
+		if err := r.stack.Close(); err != nil {
+			log.Error("failed to close bbolt db", "error", err)
+		}
+	})
+}
+
+// signalStop tells the workers to stop without waiting for them, so that it can be called by the workers.
+func (r *Prover) signalStop() {
+	r.stopMu.Lock()
+	defer r.stopMu.Unlock()
+	// the workers of the prove loop may stop the prover concurrently, e.g. on fatal errors.
+	if !atomic.CompareAndSwapInt64(&r.isClosed, 0, 1) {
 		return
 	}
-	atomic.StoreInt64(&r.isClosed, 1)
-
 	close(r.stopChan)
-	// Close db
-	if err := r.stack.Close(); err != nil {
-		log.Error("failed to close bbolt db", "error", err)
-	}
+	// submit the proofs held for their turn right away.
+	r.order.flush()
 }
@@ -0,0 +1,109 @@
+package prover
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"scroll-tech/common/types/message"
+)
+
+type proverMetrics struct {
+	proverProofCountInWindow      prometheus.Gauge
+	proverProofLimitReachedTotal  prometheus.Counter
+	proverStackDBSizeBytes        prometheus.Gauge
+	proverStackDBReclaimedBytes   prometheus.Counter
+	proverProveLoopStalledTotal   prometheus.Counter
+	proverProofSubmitGiveUpTotal  prometheus.Counter
+	proverResourceLowTotal        prometheus.Counter
+	proverSubmitBackpressureTotal prometheus.Counter
+	proverTaskLeaseLostTotal      prometheus.Counter
+	proverTasksFetchedTotal       prometheus.Counter
+	proverProofsSucceededTotal    prometheus.Counter
+	proverProofsFailedTotal       prometheus.Counter
+	proverProvePanicTotal         prometheus.Counter
+	proverProveDurationSeconds    *prometheus.HistogramVec
+}
+
+var (
+	initProverMetricOnce sync.Once
+	proverMetric         *proverMetrics
+)
+
+func initProverMetrics(reg prometheus.Registerer) *proverMetrics {
+	initProverMetricOnce.Do(func() {
+		proverMetric = &proverMetrics{
+			proverProofCountInWindow: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+				Name: "prover_proof_count_in_window",
+				Help: "The number of proofs produced in the current proof limit window",
+			}),
+			proverProofLimitReachedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "prover_proof_limit_reached_total",
+				Help: "The total number of times task fetching is paused by the proof limit",
+			}),
+			proverStackDBSizeBytes: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+				Name: "prover_stack_db_size_bytes",
+				Help: "The file size of the stack db after the latest compaction",
+			}),
+			proverStackDBReclaimedBytes: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "prover_stack_db_reclaimed_bytes_total",
+				Help: "The total number of bytes reclaimed by compacting the stack db",
+			}),
+			proverProveLoopStalledTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "prover_prove_loop_stalled_total",
+				Help: "The total number of times the watchdog found the prove loop stalled",
+			}),
+			proverProofSubmitGiveUpTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "prover_proof_submit_give_up_total",
+				Help: "The total number of proofs moved to the failed proofs after failed submissions",
+			}),
+			proverSubmitBackpressureTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "prover_submit_backpressure_total",
+				Help: "The total number of proof submissions the coordinator asked to retry later",
+			}),
+			proverResourceLowTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "prover_resource_low_total",
+				Help: "The total number of times task fetching is skipped by low free disk space or memory",
+			}),
+			proverTaskLeaseLostTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "prover_task_lease_lost_total",
+				Help: "The total number of tasks abandoned since their lease failed to be renewed",
+			}),
+			proverTasksFetchedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "prover_tasks_fetched_total",
+				Help: "The total number of tasks fetched from the coordinator",
+			}),
+			proverProofsSucceededTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "prover_proofs_succeeded_total",
+				Help: "The total number of proofs proved successfully",
+			}),
+			proverProofsFailedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "prover_proofs_failed_total",
+				Help: "The total number of proofs failed to be proved",
+			}),
+			proverProvePanicTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "prover_prove_panic_total",
+				Help: "The total number of tasks given up after the prover crashed proving them too many times",
+			}),
+			proverProveDurationSeconds: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+				Name:    "prover_prove_duration_seconds",
+				Help:    "The time spent proving a task, successful or not",
+				Buckets: prometheus.ExponentialBuckets(1, 2, 14), // 1s to ~2.3h
+			}, []string{"proof_type"}),
+		}
+	})
+	return proverMetric
+}
+
+// proofTypeLabel returns the label value of the proof type.
+func proofTypeLabel(proofType message.ProofType) string {
+	switch proofType {
+	case message.ProofTypeChunk:
+		return "chunk"
+	case message.ProofTypeBatch:
+		return "batch"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,2027 @@
+package prover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/ethclient"
+	"github.com/scroll-tech/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+
+	"scroll-tech/prover/client"
+	"scroll-tech/prover/config"
+	"scroll-tech/prover/core"
+	"scroll-tech/prover/store"
+
+	ctypes "scroll-tech/common/types"
+	"scroll-tech/common/types/message"
+)
+
+func loadBlockTrace(t *testing.T, file string) *types.BlockTrace {
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	trace := &types.BlockTrace{}
+	assert.NoError(t, json.Unmarshal(data, trace))
+	return trace
+}
+
+// mockScrollAPI serves block traces as the scroll namespace of l2geth.
+type mockScrollAPI struct {
+	traces map[common.Hash]*types.BlockTrace
+	// the number of traces requested by number and by hash.
+	byNumber int
+	byHash   int
+}
+
+func (api *mockScrollAPI) GetBlockTraceByNumberOrHash(blockNrOrHash rpc.BlockNumberOrHash) (*types.BlockTrace, error) {
+	if blockHash, ok := blockNrOrHash.Hash(); ok {
+		api.byHash++
+		return api.traces[blockHash], nil
+	}
+	api.byNumber++
+	number, _ := blockNrOrHash.Number()
+	for _, trace := range api.traces {
+		if trace.Header.Number.Int64() == number.Int64() {
+			return trace, nil
+		}
+	}
+	return nil, nil
+}
+
+// mockEthAPI serves block headers as the eth namespace of l2geth.
+type mockEthAPI struct {
+	traces map[common.Hash]*types.BlockTrace
+}
+
+func (api *mockEthAPI) GetBlockByHash(blockHash common.Hash, fullTx bool) (*types.Header, error) {
+	if trace, ok := api.traces[blockHash]; ok {
+		return trace.Header, nil
+	}
+	return nil, nil
+}
+
+func TestGetChunkTraces(t *testing.T) {
+	trace2 := loadBlockTrace(t, "../common/testdata/blockTrace_02.json")
+	trace3 := loadBlockTrace(t, "../common/testdata/blockTrace_03.json")
+	trace4 := loadBlockTrace(t, "../common/testdata/blockTrace_04.json")
+	blockHashes := []common.Hash{trace3.Header.Hash(), trace2.Header.Hash()}
+
+	// l2geth only serves block 2 and 3
+	server := rpc.NewServer()
+	defer server.Stop()
+	api := &mockScrollAPI{traces: map[common.Hash]*types.BlockTrace{
+		trace2.Header.Hash(): trace2,
+		trace3.Header.Hash(): trace3,
+	}}
+	assert.NoError(t, server.RegisterName("scroll", api))
+	assert.NoError(t, server.RegisterName("eth", &mockEthAPI{traces: api.traces}))
+	rpcClient := rpc.DialInProc(server)
+	r := &Prover{
+		ctx:             context.Background(),
+		cfg:             &config.Config{},
+		l2GethClient:    ethclient.NewClient(rpcClient),
+		l2GethRPCClient: rpcClient,
+	}
+
+	t.Run("provided traces", func(t *testing.T) {
+		// l2geth is not requested for the provided traces
+		r := &Prover{ctx: context.Background()}
+		traces, err := r.getChunkTraces(&message.ChunkTaskDetail{
+			BlockHashes: blockHashes,
+			BlockTraces: []*types.BlockTrace{trace3, trace2},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(traces))
+		assert.Equal(t, uint64(2), traces[0].Header.Number.Uint64())
+		assert.Equal(t, uint64(3), traces[1].Header.Number.Uint64())
+	})
+
+	t.Run("provided traces are not continuous", func(t *testing.T) {
+		_, err := r.getChunkTraces(&message.ChunkTaskDetail{
+			BlockTraces: []*types.BlockTrace{trace2, trace4},
+		})
+		assert.ErrorContains(t, err, "block numbers are not continuous")
+	})
+
+	t.Run("provided traces mismatch block hashes", func(t *testing.T) {
+		_, err := r.getChunkTraces(&message.ChunkTaskDetail{
+			BlockHashes: blockHashes,
+			BlockTraces: []*types.BlockTrace{trace2, trace4},
+		})
+		assert.ErrorContains(t, err, "is not in the block hashes")
+	})
+
+	t.Run("provided traces url", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			assert.NoError(t, json.NewEncoder(w).Encode([]*types.BlockTrace{trace2, trace3}))
+		}))
+		defer srv.Close()
+
+		traces, err := r.getChunkTraces(&message.ChunkTaskDetail{
+			BlockHashes: blockHashes,
+			TracesURL:   srv.URL,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(traces))
+		assert.Equal(t, uint64(2), traces[0].Header.Number.Uint64())
+	})
+
+	t.Run("fallback to l2geth", func(t *testing.T) {
+		traces, err := r.getChunkTraces(&message.ChunkTaskDetail{
+			BlockHashes: blockHashes,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(traces))
+		assert.Equal(t, uint64(2), traces[0].Header.Number.Uint64())
+		assert.Equal(t, uint64(3), traces[1].Header.Number.Uint64())
+	})
+
+	t.Run("traces by range", func(t *testing.T) {
+		r := &Prover{
+			ctx:             context.Background(),
+			cfg:             &config.Config{L2Geth: &config.L2GethConfig{FetchTracesByRange: true}},
+			l2GethClient:    r.l2GethClient,
+			l2GethRPCClient: rpcClient,
+		}
+		api.byNumber = 0
+		traces, err := r.getChunkTraces(&message.ChunkTaskDetail{
+			BlockHashes:      blockHashes,
+			StartBlockNumber: 2,
+			EndBlockNumber:   3,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, api.byNumber)
+		assert.Equal(t, 2, len(traces))
+		assert.Equal(t, trace2.Header.Hash(), traces[0].Header.Hash())
+		assert.Equal(t, trace3.Header.Hash(), traces[1].Header.Hash())
+
+		// the traces of the range must match the block hashes.
+		_, err = r.getTracesByRange(2, 3, []common.Hash{trace2.Header.Hash(), trace4.Header.Hash()})
+		assert.ErrorContains(t, err, "is not in the block hashes")
+		_, err = r.getTracesByRange(2, 4, blockHashes)
+		assert.ErrorContains(t, err, "block range mismatches block hashes")
+		_, err = r.getTracesByRange(3, 4, []common.Hash{trace3.Header.Hash(), trace4.Header.Hash()})
+		assert.ErrorContains(t, err, "trace of block 4 is empty")
+
+		// fall back to the block hashes when the range doesn't match them.
+		api.byNumber = 0
+		traces, err = r.getChunkTraces(&message.ChunkTaskDetail{
+			BlockHashes:      blockHashes,
+			StartBlockNumber: 3,
+			EndBlockNumber:   4,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, api.byNumber)
+		assert.Equal(t, 2, len(traces))
+		assert.Equal(t, trace2.Header.Hash(), traces[0].Header.Hash())
+
+		// the range is not used without the config.
+		api.byNumber = 0
+		r.cfg.L2Geth.FetchTracesByRange = false
+		_, err = r.getChunkTraces(&message.ChunkTaskDetail{
+			BlockHashes:      blockHashes,
+			StartBlockNumber: 2,
+			EndBlockNumber:   3,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, api.byNumber)
+	})
+
+	t.Run("traces from l2geth are checked for continuity", func(t *testing.T) {
+		api.traces[trace4.Header.Hash()] = trace4
+		defer delete(api.traces, trace4.Header.Hash())
+
+		// contiguous blocks are sorted by number.
+		traces, err := r.getSortedTracesByHashes([]common.Hash{trace3.Header.Hash(), trace2.Header.Hash()})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(traces))
+		assert.Equal(t, uint64(2), traces[0].Header.Number.Uint64())
+		assert.Equal(t, uint64(3), traces[1].Header.Number.Uint64())
+
+		// there is a gap between the blocks.
+		_, err = r.getSortedTracesByHashes([]common.Hash{trace4.Header.Hash(), trace2.Header.Hash()})
+		assert.ErrorContains(t, err, fmt.Sprintf("block numbers are not continuous, got 2 and %v", trace4.Header.Number))
+
+		// block 2 is listed twice.
+		_, err = r.getSortedTracesByHashes([]common.Hash{trace2.Header.Hash(), trace3.Header.Hash(), trace2.Header.Hash()})
+		assert.ErrorContains(t, err, "block numbers are not continuous, got duplicate block 2")
+	})
+
+	t.Run("streamed traces match buffered traces", func(t *testing.T) {
+		buffered, err := r.getSortedTracesByHashes([]common.Hash{trace3.Header.Hash(), trace2.Header.Hash()})
+		assert.NoError(t, err)
+
+		next, err := r.streamSortedTracesByHashes(blockHashes)
+		assert.NoError(t, err)
+		var streamed []*types.BlockTrace
+		for {
+			trace, err := next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			streamed = append(streamed, trace)
+		}
+		assert.Equal(t, len(buffered), len(streamed))
+		for i := range buffered {
+			assert.Equal(t, buffered[i].Header.Hash(), streamed[i].Header.Hash())
+		}
+	})
+
+	t.Run("cached traces are not fetched again", func(t *testing.T) {
+		cache, err := lru.New(2)
+		assert.NoError(t, err)
+		r := &Prover{
+			ctx:          context.Background(),
+			cfg:          &config.Config{},
+			l2GethClient: r.l2GethClient,
+			traceCache:   cache,
+		}
+		api.byHash = 0
+		for i := 0; i < 2; i++ {
+			traces, err := r.getChunkTraces(&message.ChunkTaskDetail{BlockHashes: blockHashes})
+			assert.NoError(t, err)
+			assert.Equal(t, 2, len(traces))
+			assert.Equal(t, uint64(2), traces[0].Header.Number.Uint64())
+			assert.Equal(t, uint64(3), traces[1].Header.Number.Uint64())
+		}
+		assert.Equal(t, 2, api.byHash)
+
+		// the streamed traces are served from the cache too.
+		next, err := r.streamSortedTracesByHashes(blockHashes)
+		assert.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			_, err = next()
+			assert.NoError(t, err)
+		}
+		_, err = next()
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, 2, api.byHash)
+
+		// the missing traces aren't cached.
+		_, err = r.getSortedTracesByHashes([]common.Hash{trace4.Header.Hash()})
+		assert.Error(t, err)
+		_, err = r.getSortedTracesByHashes([]common.Hash{trace4.Header.Hash()})
+		assert.Error(t, err)
+		assert.Equal(t, 4, api.byHash)
+	})
+
+	t.Run("streamed traces are not continuous", func(t *testing.T) {
+		api.traces[trace4.Header.Hash()] = trace4
+		defer delete(api.traces, trace4.Header.Hash())
+		_, err := r.streamSortedTracesByHashes([]common.Hash{trace2.Header.Hash(), trace4.Header.Hash()})
+		assert.ErrorContains(t, err, "block numbers are not continuous")
+	})
+}
+
+// concurrentScrollAPI serves block traces, holding each request until the given number of requests
+// have arrived, and records the max number of requests in flight.
+type concurrentScrollAPI struct {
+	traces      map[common.Hash]*types.BlockTrace
+	wait        int32
+	arrived     int32
+	inFlight    int32
+	maxInFlight int32
+}
+
+func (api *concurrentScrollAPI) GetBlockTraceByNumberOrHash(blockNrOrHash rpc.BlockNumberOrHash) (*types.BlockTrace, error) {
+	atomic.AddInt32(&api.arrived, 1)
+	inFlight := atomic.AddInt32(&api.inFlight, 1)
+	defer atomic.AddInt32(&api.inFlight, -1)
+	for {
+		maxInFlight := atomic.LoadInt32(&api.maxInFlight)
+		if inFlight <= maxInFlight || atomic.CompareAndSwapInt32(&api.maxInFlight, maxInFlight, inFlight) {
+			break
+		}
+	}
+	for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&api.arrived) < atomic.LoadInt32(&api.wait) && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	blockHash, _ := blockNrOrHash.Hash()
+	trace, ok := api.traces[blockHash]
+	if !ok {
+		return nil, fmt.Errorf("block %v not found", blockHash)
+	}
+	return trace, nil
+}
+
+func TestGetSortedTracesConcurrently(t *testing.T) {
+	trace2 := loadBlockTrace(t, "../common/testdata/blockTrace_02.json")
+	trace3 := loadBlockTrace(t, "../common/testdata/blockTrace_03.json")
+	trace4 := loadBlockTrace(t, "../common/testdata/blockTrace_04.json")
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	api := &concurrentScrollAPI{
+		traces: map[common.Hash]*types.BlockTrace{
+			trace2.Header.Hash(): trace2,
+			trace3.Header.Hash(): trace3,
+		},
+		wait: 2,
+	}
+	assert.NoError(t, server.RegisterName("scroll", api))
+	r := &Prover{
+		ctx:          context.Background(),
+		cfg:          &config.Config{L2Geth: &config.L2GethConfig{TraceFetchParallelism: 2}},
+		l2GethClient: ethclient.NewClient(rpc.DialInProc(server)),
+	}
+
+	// the traces are fetched concurrently and sorted by number.
+	traces, err := r.getSortedTracesByHashes([]common.Hash{trace3.Header.Hash(), trace2.Header.Hash()})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&api.maxInFlight))
+	assert.Equal(t, 2, len(traces))
+	assert.Equal(t, uint64(2), traces[0].Header.Number.Uint64())
+	assert.Equal(t, uint64(3), traces[1].Header.Number.Uint64())
+
+	// the failure of fetching any trace fails the fetch.
+	atomic.StoreInt32(&api.arrived, 0)
+	_, err = r.getSortedTracesByHashes([]common.Hash{trace2.Header.Hash(), trace4.Header.Hash()})
+	assert.ErrorContains(t, err, "not found")
+
+	// the traces are fetched one at a time by default.
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&api.inFlight) == 0 }, 5*time.Second, 10*time.Millisecond)
+	atomic.StoreInt32(&api.maxInFlight, 0)
+	atomic.StoreInt32(&api.wait, 0)
+	r.cfg.L2Geth.TraceFetchParallelism = 0
+	_, err = r.getSortedTracesByHashes([]common.Hash{trace3.Header.Hash(), trace2.Header.Hash()})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&api.maxInFlight))
+}
+
+func TestResubmitPendingProof(t *testing.T) {
+	// the coordinator is unavailable until it's marked available.
+	var submitCalls, available int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&submitCalls, 1)
+		if atomic.LoadInt64(&available) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer server.Close()
+
+	path, err := os.MkdirTemp("/tmp/", "prover_submit_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	defer stack.Close()
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	r := &Prover{
+		ctx:               context.Background(),
+		cfg:               &config.Config{MaxSubmitRetries: 2},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		proverCore:        &core.ProverCore{},
+		metrics:           initProverMetrics(prometheus.NewRegistry()),
+	}
+
+	task := &store.ProvingTask{Task: &message.TaskMsg{UUID: "uuid-1", ID: "task-1", Type: message.ProofTypeBatch}}
+	assert.NoError(t, stack.Push(task))
+	proofMsg := &message.ProofDetail{ID: "task-1", Type: message.ProofTypeBatch, Status: message.StatusOk, BatchProof: &message.BatchProof{Proof: []byte{1}}}
+
+	// the failed proof is queued rather than proved again.
+	assert.Error(t, r.submitProof(proofMsg, "uuid-1"))
+	_, err = stack.PeekByType(message.ProofTypeBatch)
+	assert.ErrorIs(t, err, store.ErrEmpty)
+	pending, err := stack.PeekPendingProof()
+	assert.NoError(t, err)
+	assert.Equal(t, "task-1", pending.Proof.ID)
+
+	// retried up to MaxSubmitRetries times, then dead-lettered.
+	assert.Error(t, r.resubmitPendingProof(pending))
+	pending, err = stack.PeekPendingProof()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pending.Retries)
+
+	assert.Error(t, r.resubmitPendingProof(pending))
+	_, err = stack.PeekPendingProof()
+	assert.ErrorIs(t, err, store.ErrEmpty)
+	failed, err := stack.GetFailedProofs()
+	assert.NoError(t, err)
+	assert.Len(t, failed, 1)
+	assert.Equal(t, "task-1", failed[0].Proof.ID)
+	assert.Equal(t, 2, failed[0].Retries)
+	assert.EqualValues(t, 3, atomic.LoadInt64(&submitCalls))
+
+	// a queued proof is dropped from the queue once it's submitted.
+	atomic.StoreInt64(&available, 1)
+	assert.NoError(t, stack.PushPendingProof(&store.PendingProof{UUID: "uuid-2", Proof: &message.ProofDetail{ID: "task-2", Type: message.ProofTypeBatch}}))
+	pending, err = stack.PeekPendingProof()
+	assert.NoError(t, err)
+	assert.NoError(t, r.resubmitPendingProof(pending))
+	_, err = stack.PeekPendingProof()
+	assert.ErrorIs(t, err, store.ErrEmpty)
+}
+
+func TestProveLoopExitOnFatalError(t *testing.T) {
+	path, err := os.MkdirTemp("/tmp/", "prover_fatal_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	// the stack db can't be read once closed.
+	assert.NoError(t, stack.Close())
+
+	r := &Prover{
+		ctx:      context.Background(),
+		cfg:      &config.Config{Core: &config.ProverCoreConfig{ProofType: message.ProofTypeBatch}, ExitOnFatalError: true},
+		stack:    stack,
+		stopChan: make(chan struct{}),
+		metrics:  initProverMetrics(prometheus.NewRegistry()),
+	}
+
+	var fatalErr *FatalError
+	assert.ErrorAs(t, r.proveAndSubmit(), &fatalErr)
+
+	done := make(chan struct{})
+	go func() {
+		r.ProveLoop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("prove loop didn't exit on the fatal error")
+	}
+
+	// the prover is stopped and the fatal error is surfaced.
+	select {
+	case <-r.Done():
+	default:
+		t.Fatal("prover isn't stopped on the fatal error")
+	}
+	assert.ErrorAs(t, r.Err(), &fatalErr)
+}
+
+func TestProveAndSubmitCoordinatorDrain(t *testing.T) {
+	// the coordinator asks to drain until it's marked available.
+	var getTaskCalls, available int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/coordinator/v1/get_task" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+			return
+		}
+		atomic.AddInt64(&getTaskCalls, 1)
+		if atomic.LoadInt64(&available) == 0 {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.ErrCoordinatorDraining, "errmsg": "maintenance"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"errcode": ctypes.Success,
+			"data":    map[string]interface{}{"uuid": "uuid-1", "task_id": "task-1", "task_type": int(message.ProofTypeBatch), "task_data": "{}"},
+		})
+	}))
+	defer server.Close()
+
+	defer func(wait, drainWait time.Duration) { retryWait, drainRetryWait = wait, drainWait }(retryWait, drainRetryWait)
+	retryWait = time.Millisecond
+	drainRetryWait = time.Hour
+
+	newProver := func(exitOnDrain bool) *Prover {
+		path, err := os.MkdirTemp("/tmp/", "prover_drain_test-")
+		assert.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(path) })
+		stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+		assert.NoError(t, err)
+
+		priv, err := crypto.GenerateKey()
+		assert.NoError(t, err)
+		coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+		assert.NoError(t, err)
+
+		metrics := initProverMetrics(prometheus.NewRegistry())
+		return &Prover{
+			ctx:               context.Background(),
+			cfg:               &config.Config{Core: &config.ProverCoreConfig{ProofType: message.ProofTypeBatch}, ExitOnDrain: exitOnDrain},
+			stack:             stack,
+			coordinatorClient: coordinatorClient,
+			proverCore:        &core.ProverCore{},
+			proofLimiter:      newProofLimiter(nil, stack, metrics),
+			stopChan:          make(chan struct{}),
+			metrics:           metrics,
+		}
+	}
+
+	t.Run("stop fetching", func(t *testing.T) {
+		atomic.StoreInt64(&getTaskCalls, 0)
+		r := newProver(false)
+		defer r.Stop()
+
+		assert.NoError(t, r.proveAndSubmit())
+		assert.False(t, r.drainingSince.IsZero())
+		assert.EqualValues(t, 1, atomic.LoadInt64(&getTaskCalls))
+
+		// no task is fetched while draining.
+		assert.NoError(t, r.proveAndSubmit())
+		assert.EqualValues(t, 1, atomic.LoadInt64(&getTaskCalls))
+
+		// the coordinator is asked again after drainRetryWait, fetching resumes once it ended draining.
+		atomic.StoreInt64(&available, 1)
+		defer atomic.StoreInt64(&available, 0)
+		r.drainingSince = time.Now().Add(-2 * drainRetryWait)
+		_ = r.proveAndSubmit()
+		assert.EqualValues(t, 2, atomic.LoadInt64(&getTaskCalls))
+		assert.True(t, r.drainingSince.IsZero())
+	})
+
+	t.Run("exit on drain", func(t *testing.T) {
+		atomic.StoreInt64(&getTaskCalls, 0)
+		r := newProver(true)
+
+		assert.NoError(t, r.proveAndSubmit())
+		assert.EqualValues(t, 1, atomic.LoadInt64(&getTaskCalls))
+
+		// the prover is stopped once drained.
+		assert.NoError(t, r.proveAndSubmit())
+		assert.EqualValues(t, 1, atomic.LoadInt64(&getTaskCalls))
+		select {
+		case <-r.Done():
+		default:
+			t.Fatal("prover isn't stopped once drained")
+		}
+		assert.NoError(t, r.Err())
+	})
+}
+
+func TestProveChunkEpochBoundary(t *testing.T) {
+	trace2 := loadBlockTrace(t, "../common/testdata/blockTrace_02.json")
+	trace3 := loadBlockTrace(t, "../common/testdata/blockTrace_03.json")
+	newTask := func() *store.ProvingTask {
+		return &store.ProvingTask{Task: &message.TaskMsg{
+			ID:              "task-1",
+			Type:            message.ProofTypeChunk,
+			ChunkTaskDetail: &message.ChunkTaskDetail{BlockTraces: []*types.BlockTrace{trace2, trace3}},
+		}}
+	}
+
+	// the chunk of blocks 2 and 3 spans the epoch starting at block 3.
+	r := &Prover{
+		ctx:        context.Background(),
+		cfg:        &config.Config{EpochBoundaries: []uint64{100, 3}},
+		proverCore: &core.ProverCore{},
+	}
+	_, err := r.proveChunk(newTask())
+	assert.ErrorIs(t, err, ErrChunkSpansEpochs)
+
+	// the chunk is within the epoch starting at block 2 and the one before block 4.
+	r.cfg.EpochBoundaries = []uint64{2, 4}
+	proof, err := r.proveChunk(newTask())
+	assert.NoError(t, err)
+	assert.NotNil(t, proof)
+
+	assert.NoError(t, r.checkEpochBoundary(5, 5))
+	assert.ErrorIs(t, r.checkEpochBoundary(3, 4), ErrChunkSpansEpochs)
+}
+
+func TestProveBatchChunkProofOrder(t *testing.T) {
+	infos := make([]*message.ChunkInfo, 3)
+	proofs := make([]*message.ChunkProof, 3)
+	for i := range infos {
+		infos[i] = &message.ChunkInfo{
+			ChainID:       534352,
+			PrevStateRoot: common.BigToHash(big.NewInt(int64(i))),
+			PostStateRoot: common.BigToHash(big.NewInt(int64(i + 1))),
+		}
+		info := *infos[i]
+		proofs[i] = &message.ChunkProof{Proof: []byte{byte(i)}, ChunkInfo: &info}
+	}
+
+	// the chunk proofs in order are left as received.
+	ordered, err := orderChunkProofs(infos, proofs)
+	assert.NoError(t, err)
+	assert.Equal(t, proofs, ordered)
+
+	// the chunk proofs out of order are reordered by the chunk infos.
+	ordered, err = orderChunkProofs(infos, []*message.ChunkProof{proofs[2], proofs[0], proofs[1]})
+	assert.NoError(t, err)
+	assert.Equal(t, proofs, ordered)
+
+	// the chunk proofs which don't match the chunk infos are rejected.
+	_, err = orderChunkProofs(infos, proofs[:2])
+	assert.ErrorIs(t, err, ErrChunkProofsMismatch)
+	_, err = orderChunkProofs(infos, []*message.ChunkProof{proofs[0], proofs[1], proofs[1]})
+	assert.ErrorIs(t, err, ErrChunkProofsMismatch)
+	_, err = orderChunkProofs(infos, []*message.ChunkProof{proofs[0], proofs[1], {Proof: []byte{2}}})
+	assert.ErrorIs(t, err, ErrChunkProofsMismatch)
+
+	newTask := func(chunkProofs []*message.ChunkProof) *store.ProvingTask {
+		return &store.ProvingTask{Task: &message.TaskMsg{
+			ID:              "task-1",
+			Type:            message.ProofTypeBatch,
+			BatchTaskDetail: &message.BatchTaskDetail{ChunkInfos: infos, ChunkProofs: chunkProofs},
+		}}
+	}
+	r := &Prover{
+		ctx:        context.Background(),
+		cfg:        &config.Config{CheckChunkProofOrder: true},
+		proverCore: &core.ProverCore{},
+	}
+	proof, err := r.proveBatch(newTask([]*message.ChunkProof{proofs[1], proofs[2], proofs[0]}))
+	assert.NoError(t, err)
+	assert.NotNil(t, proof)
+	_, err = r.proveBatch(newTask([]*message.ChunkProof{proofs[0], proofs[0], proofs[1]}))
+	assert.ErrorIs(t, err, ErrChunkProofsMismatch)
+
+	// the chunk proofs are passed as received without the check.
+	r.cfg.CheckChunkProofOrder = false
+	proof, err = r.proveBatch(newTask([]*message.ChunkProof{proofs[0], proofs[0], proofs[1]}))
+	assert.NoError(t, err)
+	assert.NotNil(t, proof)
+}
+
+func TestSubmitProofParamsHash(t *testing.T) {
+	var paramsHashes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/submit_proof") {
+			var req client.SubmitProofRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			paramsHashes = append(paramsHashes, req.ParamsHash)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer server.Close()
+
+	path, err := os.MkdirTemp("/tmp/", "prover_params_hash_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	defer stack.Close()
+	paramsPath := filepath.Join(path, "params")
+	assert.NoError(t, os.MkdirAll(paramsPath, os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(paramsPath, "params20"), []byte("params"), 0600))
+
+	proverCore, err := core.NewProverCore(&config.ProverCoreConfig{ParamsPath: paramsPath, ProofType: message.ProofTypeBatch})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, proverCore.ParamsHash())
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	r := &Prover{
+		ctx:               context.Background(),
+		cfg:               &config.Config{},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		proverCore:        proverCore,
+		metrics:           initProverMetrics(prometheus.NewRegistry()),
+	}
+
+	// both the submitted and the resubmitted proofs carry the params hash of the prover core.
+	proofMsg := &message.ProofDetail{ID: "task-1", Type: message.ProofTypeBatch, Status: message.StatusOk, BatchProof: &message.BatchProof{Proof: []byte{1}}}
+	assert.NoError(t, r.submitProof(proofMsg, "uuid-1"))
+	assert.NoError(t, r.resubmitPendingProof(&store.PendingProof{UUID: "uuid-2", Proof: &message.ProofDetail{ID: "task-2", Type: message.ProofTypeBatch}}))
+	assert.Equal(t, []string{proverCore.ParamsHash(), proverCore.ParamsHash()}, paramsHashes)
+}
+
+func TestResubmitPendingProofRetryAfter(t *testing.T) {
+	// the coordinator rate limits the first submission.
+	var submitCalls int64
+	var submitTimes []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&submitCalls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			submitTimes = append(submitTimes, time.Now())
+			return
+		}
+		submitTimes = append(submitTimes, time.Now())
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer server.Close()
+
+	defer func(wait time.Duration) { retryWait = wait }(retryWait)
+	retryWait = time.Hour
+
+	path, err := os.MkdirTemp("/tmp/", "prover_retry_after_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	defer stack.Close()
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5, RetryCount: 3}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	r := &Prover{
+		ctx:               context.Background(),
+		cfg:               &config.Config{},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		proverCore:        &core.ProverCore{},
+		metrics:           initProverMetrics(prometheus.NewRegistry()),
+	}
+
+	// the rate limited proof is queued and the next submission is held off as hinted.
+	proofMsg := &message.ProofDetail{ID: "task-1", Type: message.ProofTypeBatch, Status: message.StatusOk, BatchProof: &message.BatchProof{Proof: []byte{1}}}
+	assert.Error(t, r.submitProof(proofMsg, "uuid-1"))
+	assert.EqualValues(t, 1, atomic.LoadInt64(&submitCalls))
+	assert.True(t, r.submitNotBefore.After(time.Now()))
+
+	// the queued proof is resubmitted once the hinted wait passed, without the default backoff.
+	assert.NoError(t, r.proveAndSubmit())
+	assert.EqualValues(t, 2, atomic.LoadInt64(&submitCalls))
+	assert.GreaterOrEqual(t, submitTimes[1].Sub(submitTimes[0]), 900*time.Millisecond)
+	_, err = stack.PeekPendingProof()
+	assert.ErrorIs(t, err, store.ErrEmpty)
+}
+
+func TestProveAndSubmitSplitChunk(t *testing.T) {
+	// the traces of the contiguous blocks [10, 14].
+	var traces []*types.BlockTrace
+	parentHash := common.HexToHash("0x1234")
+	for number := int64(10); number < 15; number++ {
+		header := &types.Header{Number: big.NewInt(number), ParentHash: parentHash, Difficulty: big.NewInt(0)}
+		traces = append(traces, &types.BlockTrace{Header: header})
+		parentHash = header.Hash()
+	}
+
+	t.Run("split traces", func(t *testing.T) {
+		subChunks, err := splitChunkTraces(traces, 2)
+		assert.NoError(t, err)
+		assert.Len(t, subChunks, 3)
+		var numBlocks int
+		for _, subTraces := range subChunks {
+			assert.NotEmpty(t, subTraces)
+			assert.LessOrEqual(t, len(subTraces), 2)
+			for i := 1; i < len(subTraces); i++ {
+				assert.Equal(t, subTraces[i-1].Header.Number.Uint64()+1, subTraces[i].Header.Number.Uint64())
+				assert.Equal(t, subTraces[i-1].Header.Hash(), subTraces[i].Header.ParentHash)
+			}
+			numBlocks += len(subTraces)
+		}
+		assert.Equal(t, len(traces), numBlocks)
+
+		// the traces of a gap or a fork are rejected.
+		_, err = splitChunkTraces([]*types.BlockTrace{traces[0], traces[2]}, 2)
+		assert.Error(t, err)
+		forked := &types.BlockTrace{Header: &types.Header{Number: big.NewInt(11), ParentHash: common.HexToHash("0x5678"), Difficulty: big.NewInt(0)}}
+		_, err = splitChunkTraces([]*types.BlockTrace{traces[0], forked}, 2)
+		assert.Error(t, err)
+	})
+
+	t.Run("prove and submit sub-chunks", func(t *testing.T) {
+		var requests []client.SubmitProofRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/submit_proof") {
+				var req client.SubmitProofRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				requests = append(requests, req)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+		}))
+		defer server.Close()
+
+		path, err := os.MkdirTemp("/tmp/", "prover_split_chunk_test-")
+		assert.NoError(t, err)
+		defer os.RemoveAll(path)
+		stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+		assert.NoError(t, err)
+		defer stack.Close()
+
+		priv, err := crypto.GenerateKey()
+		assert.NoError(t, err)
+		coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+		assert.NoError(t, err)
+
+		metrics := initProverMetrics(prometheus.NewRegistry())
+		r := &Prover{
+			ctx:               context.Background(),
+			cfg:               &config.Config{Core: &config.ProverCoreConfig{ProofType: message.ProofTypeChunk}, MaxChunkBlocks: 2},
+			stack:             stack,
+			coordinatorClient: coordinatorClient,
+			proverCore:        &core.ProverCore{},
+			proofLimiter:      newProofLimiter(nil, stack, metrics),
+			metrics:           metrics,
+		}
+
+		assert.NoError(t, stack.Push(&store.ProvingTask{Task: &message.TaskMsg{
+			UUID:            "uuid-1",
+			ID:              "task-1",
+			Type:            message.ProofTypeChunk,
+			ChunkTaskDetail: &message.ChunkTaskDetail{BlockTraces: traces},
+		}}))
+		assert.NoError(t, r.proveAndSubmit())
+
+		// each sub-chunk is proved and submitted under the id of the task.
+		assert.Len(t, requests, 3)
+		expected := [][2]uint64{{10, 11}, {12, 13}, {14, 14}}
+		for i, req := range requests {
+			assert.Equal(t, "task-1", req.TaskID)
+			assert.Equal(t, "uuid-1", req.UUID)
+			assert.Equal(t, int(message.StatusOk), req.Status)
+			assert.NotEmpty(t, req.Proof)
+			assert.Equal(t, &message.SubTask{Index: i, Count: 3, StartBlockNumber: expected[i][0], EndBlockNumber: expected[i][1]}, req.SubTask)
+		}
+		_, err = stack.Peek()
+		assert.ErrorIs(t, err, store.ErrEmpty)
+	})
+}
+
+func TestProveAndSubmitFetchBackoff(t *testing.T) {
+	// the coordinator fails to serve tasks until it's marked available.
+	var available int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/coordinator/v1/get_task" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+			return
+		}
+		if atomic.LoadInt64(&available) == 0 {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.ErrCoordinatorGetTaskFailure, "errmsg": "unavailable"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"errcode": ctypes.Success,
+			"data":    map[string]interface{}{"uuid": "uuid-1", "task_id": "task-1", "task_type": int(message.ProofTypeBatch), "task_data": "{}"},
+		})
+	}))
+	defer server.Close()
+
+	defer func(wait, maxBackoff time.Duration) { retryWait, maxFetchBackoff = wait, maxBackoff }(retryWait, maxFetchBackoff)
+	retryWait = time.Millisecond
+	maxFetchBackoff = 8 * time.Millisecond
+
+	path, err := os.MkdirTemp("/tmp/", "prover_backoff_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	metrics := initProverMetrics(prometheus.NewRegistry())
+	r := &Prover{
+		ctx:               context.Background(),
+		cfg:               &config.Config{Core: &config.ProverCoreConfig{ProofType: message.ProofTypeBatch}},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		proverCore:        &core.ProverCore{},
+		proofLimiter:      newProofLimiter(nil, stack, metrics),
+		stopChan:          make(chan struct{}),
+		metrics:           metrics,
+	}
+	defer r.Stop()
+
+	// the backoff doubles on each consecutive failure up to the cap.
+	for _, backoff := range []time.Duration{1, 2, 4, 8, 8} {
+		assert.Error(t, r.proveAndSubmit())
+		assert.Equal(t, backoff*time.Millisecond, r.fetchBackoff)
+	}
+
+	// the backoff is reset once a task is fetched.
+	atomic.StoreInt64(&available, 1)
+	_ = r.proveAndSubmit()
+	assert.Zero(t, r.fetchBackoff)
+
+	// the jittered wait is within a half of the backoff.
+	r.fetchBackoff = 0
+	for i := 0; i < 10; i++ {
+		wait := r.nextFetchBackoff()
+		assert.LessOrEqual(t, wait, r.fetchBackoff)
+		assert.GreaterOrEqual(t, wait, r.fetchBackoff/2)
+	}
+}
+
+func TestProveAndSubmitMaxProveRetry(t *testing.T) {
+	var requests []client.SubmitProofRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/submit_proof") {
+			var req client.SubmitProofRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			requests = append(requests, req)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer server.Close()
+
+	newProver := func(maxProveRetry *int) *Prover {
+		path, err := os.MkdirTemp("/tmp/", "prover_max_prove_retry_test-")
+		assert.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(path) })
+		stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+		assert.NoError(t, err)
+		t.Cleanup(func() { stack.Close() })
+
+		priv, err := crypto.GenerateKey()
+		assert.NoError(t, err)
+		coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+		assert.NoError(t, err)
+
+		metrics := initProverMetrics(prometheus.NewRegistry())
+		return &Prover{
+			ctx:               context.Background(),
+			cfg:               &config.Config{Core: &config.ProverCoreConfig{ProofType: message.ProofTypeBatch}, MaxProveRetry: maxProveRetry},
+			stack:             stack,
+			coordinatorClient: coordinatorClient,
+			proverCore:        &core.ProverCore{},
+			proofLimiter:      newProofLimiter(nil, stack, metrics),
+			metrics:           metrics,
+		}
+	}
+
+	// proves the task already tried the given times, returning the submitted request.
+	proveTask := func(r *Prover, times int) client.SubmitProofRequest {
+		requests = nil
+		assert.NoError(t, r.stack.Push(&store.ProvingTask{
+			Task:  &message.TaskMsg{UUID: "uuid-1", ID: "task-1", Type: message.ProofTypeBatch, BatchTaskDetail: &message.BatchTaskDetail{}},
+			Times: times,
+		}))
+		assert.NoError(t, r.proveAndSubmit())
+		assert.Len(t, requests, 1)
+		return requests[0]
+	}
+
+	zero, five := 0, 5
+	for _, tt := range []struct {
+		name          string
+		maxProveRetry *int
+		expected      int
+	}{
+		{"default", nil, defaultMaxProveRetry},
+		{"fail fast", &zero, 0},
+		{"more retries", &five, 5},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newProver(tt.maxProveRetry)
+
+			// the task is retried exactly MaxProveRetry times.
+			req := proveTask(r, tt.expected)
+			assert.Equal(t, int(message.StatusOk), req.Status)
+
+			// the task is given up as a proving panic afterwards.
+			req = proveTask(r, tt.expected+1)
+			assert.Equal(t, int(message.StatusProofError), req.Status)
+			assert.Equal(t, int(message.ProofFailurePanic), req.FailureType)
+		})
+	}
+}
+
+// blockingScrollAPI serves no trace until it's released, keeping the prover core busy proving the chunk.
+type blockingScrollAPI struct {
+	requested chan struct{}
+	release   chan struct{}
+	// the traces served once released.
+	traces map[common.Hash]*types.BlockTrace
+}
+
+func (api *blockingScrollAPI) GetBlockTraceByNumberOrHash(blockNrOrHash rpc.BlockNumberOrHash) (*types.BlockTrace, error) {
+	select {
+	case api.requested <- struct{}{}:
+	default:
+	}
+	<-api.release
+	blockHash, _ := blockNrOrHash.Hash()
+	return api.traces[blockHash], nil
+}
+
+func TestProveLoopContextCancelled(t *testing.T) {
+	trace2 := loadBlockTrace(t, "../common/testdata/blockTrace_02.json")
+	trace3 := loadBlockTrace(t, "../common/testdata/blockTrace_03.json")
+
+	var submitted int32
+	coordinator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/submit_proof") {
+			atomic.AddInt32(&submitted, 1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer coordinator.Close()
+
+	// l2geth serves the headers, but hangs on serving the traces streamed into the prover core.
+	server := rpc.NewServer()
+	defer server.Stop()
+	api := &blockingScrollAPI{requested: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(api.release)
+	assert.NoError(t, server.RegisterName("scroll", api))
+	assert.NoError(t, server.RegisterName("eth", &mockEthAPI{traces: map[common.Hash]*types.BlockTrace{
+		trace2.Header.Hash(): trace2,
+		trace3.Header.Hash(): trace3,
+	}}))
+	rpcClient := rpc.DialInProc(server)
+
+	path, err := os.MkdirTemp("/tmp/", "prover_ctx_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	defer stack.Close()
+	assert.NoError(t, stack.Push(&store.ProvingTask{Task: &message.TaskMsg{
+		UUID:            "uuid-1",
+		ID:              "task-1",
+		Type:            message.ProofTypeChunk,
+		ChunkTaskDetail: &message.ChunkTaskDetail{BlockHashes: []common.Hash{trace2.Header.Hash(), trace3.Header.Hash()}},
+	}}))
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: coordinator.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	metrics := initProverMetrics(prometheus.NewRegistry())
+	r := &Prover{
+		ctx: ctx,
+		cfg: &config.Config{
+			Core:   &config.ProverCoreConfig{ProofType: message.ProofTypeChunk},
+			L2Geth: &config.L2GethConfig{StreamTraces: true},
+		},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		l2GethClient:      ethclient.NewClient(rpcClient),
+		l2GethRPCClient:   rpcClient,
+		proverCore:        &core.ProverCore{},
+		proofLimiter:      newProofLimiter(nil, stack, metrics),
+		stopChan:          make(chan struct{}),
+		metrics:           metrics,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.ProveLoop()
+		close(done)
+	}()
+	select {
+	case <-api.requested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("prover core didn't start proving the chunk")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("prove loop didn't exit once the context is cancelled")
+	}
+
+	// the aborted task is kept to be proved again, rather than reported as failed.
+	assert.Equal(t, int32(0), atomic.LoadInt32(&submitted))
+	task, err := stack.PeekByType(message.ProofTypeChunk)
+	assert.NoError(t, err)
+	assert.Equal(t, "task-1", task.Task.ID)
+	assert.Equal(t, 0, task.Times)
+}
+
+func TestStopWaitsForWorkers(t *testing.T) {
+	trace2 := loadBlockTrace(t, "../common/testdata/blockTrace_02.json")
+	trace3 := loadBlockTrace(t, "../common/testdata/blockTrace_03.json")
+
+	var submitted int32
+	coordinator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/submit_proof") {
+			atomic.AddInt32(&submitted, 1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer coordinator.Close()
+
+	// l2geth serves the headers, but hangs on serving the traces streamed into the prover core.
+	server := rpc.NewServer()
+	defer server.Stop()
+	api := &blockingScrollAPI{requested: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(api.release)
+	assert.NoError(t, server.RegisterName("scroll", api))
+	assert.NoError(t, server.RegisterName("eth", &mockEthAPI{traces: map[common.Hash]*types.BlockTrace{
+		trace2.Header.Hash(): trace2,
+		trace3.Header.Hash(): trace3,
+	}}))
+	rpcClient := rpc.DialInProc(server)
+
+	path, err := os.MkdirTemp("/tmp/", "prover_stop_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	dbPath := filepath.Join(path, "test-stack")
+	stack, err := store.NewStack(dbPath)
+	assert.NoError(t, err)
+	assert.NoError(t, stack.Push(&store.ProvingTask{Task: &message.TaskMsg{
+		UUID:            "uuid-1",
+		ID:              "task-1",
+		Type:            message.ProofTypeChunk,
+		ChunkTaskDetail: &message.ChunkTaskDetail{BlockHashes: []common.Hash{trace2.Header.Hash(), trace3.Header.Hash()}},
+	}}))
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: coordinator.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	metrics := initProverMetrics(prometheus.NewRegistry())
+	r := &Prover{
+		ctx: context.Background(),
+		cfg: &config.Config{
+			Core:   &config.ProverCoreConfig{ProofType: message.ProofTypeChunk},
+			L2Geth: &config.L2GethConfig{StreamTraces: true},
+		},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		l2GethClient:      ethclient.NewClient(rpcClient),
+		l2GethRPCClient:   rpcClient,
+		proverCore:        &core.ProverCore{},
+		proofLimiter:      newProofLimiter(nil, stack, metrics),
+		stopChan:          make(chan struct{}),
+		metrics:           metrics,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.ProveLoop()
+		close(done)
+	}()
+	select {
+	case <-api.requested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("prover core didn't start proving the chunk")
+	}
+
+	// Stop returns once the worker restored the aborted task, before the stack is closed.
+	stopped := make(chan struct{})
+	go func() {
+		r.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop didn't return")
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("prove loop didn't exit once the prover is stopped")
+	}
+
+	// the aborted task is kept to be proved again, with its attempt uncounted.
+	assert.Equal(t, int32(0), atomic.LoadInt32(&submitted))
+	stack, err = store.NewStack(dbPath)
+	assert.NoError(t, err)
+	defer stack.Close()
+	task, err := stack.PeekByType(message.ProofTypeChunk)
+	assert.NoError(t, err)
+	assert.Equal(t, "task-1", task.Task.ID)
+	assert.Equal(t, 0, task.Times)
+}
+
+func TestFetchBatchTask(t *testing.T) {
+	chunkInfos := []*message.ChunkInfo{
+		{ChainID: 534352, PrevStateRoot: common.HexToHash("0x01"), PostStateRoot: common.HexToHash("0x02"), DataHash: common.HexToHash("0xaa")},
+		{ChainID: 534352, PrevStateRoot: common.HexToHash("0x02"), PostStateRoot: common.HexToHash("0x03"), DataHash: common.HexToHash("0xbb")},
+	}
+	chunkProofs := []*message.ChunkProof{
+		{Proof: []byte{1}, ChunkInfo: chunkInfos[0]},
+		{Proof: []byte{2}, ChunkInfo: chunkInfos[1]},
+	}
+
+	var taskData string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/coordinator/v1/get_task" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"errcode": ctypes.Success,
+			"data":    map[string]interface{}{"uuid": "uuid-1", "task_id": "task-1", "task_type": int(message.ProofTypeBatch), "task_data": taskData},
+		})
+	}))
+	defer server.Close()
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+	r := &Prover{
+		ctx:               context.Background(),
+		cfg:               &config.Config{Core: &config.ProverCoreConfig{ProofType: message.ProofTypeBatch}},
+		coordinatorClient: coordinatorClient,
+		proverCore:        &core.ProverCore{},
+	}
+
+	t.Run("chunk infos and chunk proofs", func(t *testing.T) {
+		data, err := json.Marshal(&message.BatchTaskDetail{ChunkInfos: chunkInfos, ChunkProofs: chunkProofs})
+		assert.NoError(t, err)
+		taskData = string(data)
+
+		task, err := r.fetchTaskFromCoordinator()
+		assert.NoError(t, err)
+		assert.Equal(t, "task-1", task.Task.ID)
+		assert.Equal(t, chunkInfos, task.Task.BatchTaskDetail.ChunkInfos)
+		assert.Len(t, task.Task.BatchTaskDetail.ChunkProofs, 2)
+		for i, proof := range task.Task.BatchTaskDetail.ChunkProofs {
+			assert.Equal(t, chunkProofs[i].Proof, proof.Proof)
+			assert.Equal(t, chunkInfos[i], proof.ChunkInfo)
+		}
+	})
+
+	t.Run("chunk infos missing", func(t *testing.T) {
+		data, err := json.Marshal(&message.BatchTaskDetail{ChunkProofs: chunkProofs})
+		assert.NoError(t, err)
+		taskData = string(data)
+
+		_, err = r.fetchTaskFromCoordinator()
+		assert.ErrorContains(t, err, "batch task has 0 chunk infos but 2 chunk proofs")
+	})
+}
+
+func TestProveLoopConcurrency(t *testing.T) {
+	const numTasks = 12
+
+	var (
+		mu                    sync.Mutex
+		submitted             = make(map[string]int)
+		inFlight, maxInFlight int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/coordinator/v1/get_task":
+			// no more tasks once the seeded ones are proved.
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.ErrCoordinatorDraining, "errmsg": "maintenance"})
+			return
+		case "/coordinator/v1/submit_proof":
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			mu.Lock()
+			if n > maxInFlight {
+				maxInFlight = n
+			}
+			mu.Unlock()
+
+			var req client.SubmitProofRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			submitted[req.TaskID]++
+			mu.Unlock()
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer server.Close()
+
+	defer func(wait time.Duration) { retryWait = wait }(retryWait)
+	retryWait = time.Millisecond
+
+	path, err := os.MkdirTemp("/tmp/", "prover_concurrency_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	for i := 0; i < numTasks; i++ {
+		id := fmt.Sprintf("task-%d", i)
+		assert.NoError(t, stack.Push(&store.ProvingTask{Task: &message.TaskMsg{
+			UUID: "uuid-" + id, ID: id, Type: message.ProofTypeBatch, BatchTaskDetail: &message.BatchTaskDetail{},
+		}}))
+	}
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	metrics := initProverMetrics(prometheus.NewRegistry())
+	r := &Prover{
+		ctx: context.Background(),
+		cfg: &config.Config{
+			Core:        &config.ProverCoreConfig{ProofType: message.ProofTypeBatch},
+			ExitOnDrain: true,
+			Concurrency: 4,
+		},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		proverCore:        &core.ProverCore{},
+		proofLimiter:      newProofLimiter(nil, stack, metrics),
+		stopChan:          make(chan struct{}),
+		metrics:           metrics,
+	}
+
+	// the prover stops once the seeded tasks are all proved.
+	done := make(chan struct{})
+	go func() {
+		r.ProveLoop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("prove loop didn't drain the stack")
+	}
+
+	// each task is proved and submitted exactly once, by the workers proving concurrently.
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, submitted, numTasks)
+	for id, times := range submitted {
+		assert.Equal(t, 1, times, id)
+	}
+	assert.Greater(t, maxInFlight, int32(1))
+}
+
+func TestProveAndSubmitAsync(t *testing.T) {
+	// the coordinator hangs on submissions until released, then fails them until it's marked available.
+	var (
+		submitCalls, available int64
+		release                = make(chan struct{})
+		mu                     sync.Mutex
+		submitted              []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/submit_proof") {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+			return
+		}
+		<-release
+		atomic.AddInt64(&submitCalls, 1)
+		if atomic.LoadInt64(&available) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var req client.SubmitProofRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		submitted = append(submitted, req.TaskID)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer server.Close()
+
+	defer func(wait time.Duration) { retryWait = wait }(retryWait)
+	retryWait = 10 * time.Millisecond
+
+	path, err := os.MkdirTemp("/tmp/", "prover_async_submit_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	metrics := initProverMetrics(prometheus.NewRegistry())
+	r := &Prover{
+		ctx:               context.Background(),
+		cfg:               &config.Config{Core: &config.ProverCoreConfig{ProofType: message.ProofTypeBatch}, AsyncSubmit: true},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		proverCore:        &core.ProverCore{},
+		proofLimiter:      newProofLimiter(nil, stack, metrics),
+		stopChan:          make(chan struct{}),
+		submitCh:          make(chan struct{}, 1),
+		metrics:           metrics,
+	}
+	defer r.Stop()
+	go r.submitLoop()
+
+	// the tasks are proved one after another while the submission of the first proof hangs.
+	for _, id := range []string{"task-1", "task-2"} {
+		assert.NoError(t, stack.Push(&store.ProvingTask{Task: &message.TaskMsg{
+			UUID: "uuid-" + id, ID: id, Type: message.ProofTypeBatch, BatchTaskDetail: &message.BatchTaskDetail{},
+		}}))
+		proved := make(chan error)
+		go func() { proved <- r.proveAndSubmit() }()
+		select {
+		case err = <-proved:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("proving is blocked on submitting the proof")
+		}
+	}
+	_, err = stack.PeekByType(message.ProofTypeBatch)
+	assert.ErrorIs(t, err, store.ErrEmpty)
+
+	// the failed submissions are retried rather than dropping the proofs.
+	close(release)
+	assert.Eventually(t, func() bool { return atomic.LoadInt64(&submitCalls) >= 3 }, 5*time.Second, 10*time.Millisecond)
+	pending, err := stack.PeekPendingProof()
+	assert.NoError(t, err)
+	assert.Positive(t, pending.Retries)
+
+	// the proofs are all submitted once the coordinator is back.
+	atomic.StoreInt64(&available, 1)
+	assert.Eventually(t, func() bool {
+		_, peekErr := stack.PeekPendingProof()
+		return errors.Is(peekErr, store.ErrEmpty)
+	}, 5*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.ElementsMatch(t, []string{"task-1", "task-2"}, submitted)
+	failed, err := stack.GetFailedProofs()
+	assert.NoError(t, err)
+	assert.Empty(t, failed)
+}
+
+func TestSubmitProofCrashRecovery(t *testing.T) {
+	path, err := os.MkdirTemp("/tmp/", "prover_crash_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	dbPath := filepath.Join(path, "test-stack")
+
+	var (
+		stack     *store.Stack
+		crashed   int64
+		submitted []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/coordinator/v1/get_task":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.ErrCoordinatorDraining, "errmsg": "maintenance"})
+			return
+		case "/coordinator/v1/submit_proof":
+			if atomic.LoadInt64(&crashed) == 0 {
+				// the proof is persisted and the task popped by the time it's submitted.
+				pending, peekErr := stack.PeekPendingProof()
+				assert.NoError(t, peekErr)
+				assert.Equal(t, "task-1", pending.Proof.ID)
+				_, peekErr = stack.PeekByType(message.ProofTypeBatch)
+				assert.ErrorIs(t, peekErr, store.ErrEmpty)
+				// the prover crashes before the submission is acknowledged.
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			var req client.SubmitProofRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			submitted = append(submitted, req.TaskID)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer server.Close()
+
+	newProver := func() *Prover {
+		stack, err = store.NewStack(dbPath)
+		assert.NoError(t, err)
+		priv, err := crypto.GenerateKey()
+		assert.NoError(t, err)
+		coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+		assert.NoError(t, err)
+		metrics := initProverMetrics(prometheus.NewRegistry())
+		return &Prover{
+			ctx:               context.Background(),
+			cfg:               &config.Config{Core: &config.ProverCoreConfig{ProofType: message.ProofTypeBatch}},
+			stack:             stack,
+			coordinatorClient: coordinatorClient,
+			proverCore:        &core.ProverCore{},
+			proofLimiter:      newProofLimiter(nil, stack, metrics),
+			stopChan:          make(chan struct{}),
+			metrics:           metrics,
+		}
+	}
+
+	r := newProver()
+	assert.NoError(t, stack.Push(&store.ProvingTask{Task: &message.TaskMsg{
+		UUID: "uuid-1", ID: "task-1", Type: message.ProofTypeBatch, BatchTaskDetail: &message.BatchTaskDetail{},
+	}}))
+	assert.Error(t, r.proveAndSubmit())
+	r.Stop()
+
+	// the restarted prover resubmits the persisted proof rather than proving the task again.
+	atomic.StoreInt64(&crashed, 1)
+	r = newProver()
+	defer r.Stop()
+	_, err = stack.PeekByType(message.ProofTypeBatch)
+	assert.ErrorIs(t, err, store.ErrEmpty)
+	assert.NoError(t, r.proveAndSubmit())
+	assert.Equal(t, []string{"task-1"}, submitted)
+	_, err = stack.PeekPendingProof()
+	assert.ErrorIs(t, err, store.ErrEmpty)
+}
+
+func TestProveMetrics(t *testing.T) {
+	r := &Prover{
+		ctx:        context.Background(),
+		cfg:        &config.Config{Core: &config.ProverCoreConfig{ProofType: message.ProofTypeBatch}},
+		proverCore: &core.ProverCore{},
+		stopChan:   make(chan struct{}),
+		metrics:    initProverMetrics(prometheus.NewRegistry()),
+	}
+	succeeded := testutil.ToFloat64(r.metrics.proverProofsSucceededTotal)
+	failed := testutil.ToFloat64(r.metrics.proverProofsFailedTotal)
+	observations := func() uint64 {
+		m := &dto.Metric{}
+		assert.NoError(t, r.metrics.proverProveDurationSeconds.WithLabelValues("batch").(prometheus.Histogram).Write(m))
+		return m.GetHistogram().GetSampleCount()
+	}
+	observed := observations()
+
+	_, err := r.prove(&store.ProvingTask{Task: &message.TaskMsg{ID: "task-1", Type: message.ProofTypeBatch, BatchTaskDetail: &message.BatchTaskDetail{}}})
+	assert.NoError(t, err)
+	assert.Equal(t, succeeded+1, testutil.ToFloat64(r.metrics.proverProofsSucceededTotal))
+	assert.Equal(t, failed, testutil.ToFloat64(r.metrics.proverProofsFailedTotal))
+
+	// the batch task without its detail fails to be proved.
+	_, err = r.prove(&store.ProvingTask{Task: &message.TaskMsg{ID: "task-2", Type: message.ProofTypeBatch}})
+	assert.Error(t, err)
+	assert.Equal(t, succeeded+1, testutil.ToFloat64(r.metrics.proverProofsSucceededTotal))
+	assert.Equal(t, failed+1, testutil.ToFloat64(r.metrics.proverProofsFailedTotal))
+
+	// both are observed by the proving duration of the proof type.
+	assert.Equal(t, observed+2, observations())
+}
+
+func TestFetchTaskSignature(t *testing.T) {
+	coordinatorKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	otherKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+
+	task := &message.TaskAssignment{UUID: "uuid-1", TaskID: "task-1", TaskType: uint64(message.ProofTypeBatch), TaskData: "{}"}
+	var (
+		taskData  string
+		signature string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/coordinator/v1/get_task" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"errcode": ctypes.Success,
+			"data": map[string]interface{}{
+				"uuid": task.UUID, "task_id": task.TaskID, "task_type": task.TaskType, "task_data": taskData, "signature": signature,
+			},
+		})
+	}))
+	defer server.Close()
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorCfg := &config.CoordinatorConfig{
+		BaseURL:              server.URL,
+		ConnectionTimeoutSec: 5,
+		PublicKey:            common.Bytes2Hex(crypto.CompressPubkey(&coordinatorKey.PublicKey)),
+	}
+	coordinatorClient, err := client.NewCoordinatorClient(coordinatorCfg, "test-prover", priv, nil)
+	assert.NoError(t, err)
+	r := &Prover{
+		ctx:               context.Background(),
+		cfg:               &config.Config{Core: &config.ProverCoreConfig{ProofType: message.ProofTypeBatch}, Coordinator: coordinatorCfg},
+		coordinatorClient: coordinatorClient,
+		proverCore:        &core.ProverCore{},
+	}
+
+	validSignature, err := task.Sign(coordinatorKey)
+	assert.NoError(t, err)
+	otherSignature, err := task.Sign(otherKey)
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		name      string
+		taskData  string
+		signature string
+		expected  string
+	}{
+		{"valid signature", "{}", validSignature, ""},
+		{"tampered task", `{"chunk_proofs":[]}`, validSignature, "invalid signature of task task-1"},
+		{"signed by another key", "{}", otherSignature, "invalid signature of task task-1"},
+		{"malformed signature", "{}", "0x1234", "failed to verify the signature of task task-1"},
+		{"unsigned", "{}", "", "task task-1 is not signed by the coordinator"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			taskData, signature = tt.taskData, tt.signature
+			fetched, err := r.fetchTaskFromCoordinator()
+			if tt.expected == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "task-1", fetched.Task.ID)
+				return
+			}
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+
+	// the tasks aren't verified without the public key of the coordinator.
+	coordinatorCfg.PublicKey = ""
+	taskData, signature = "{}", ""
+	_, err = r.fetchTaskFromCoordinator()
+	assert.NoError(t, err)
+}
+
+func TestStopGracefully(t *testing.T) {
+	trace2 := loadBlockTrace(t, "../common/testdata/blockTrace_02.json")
+	trace3 := loadBlockTrace(t, "../common/testdata/blockTrace_03.json")
+	traces := map[common.Hash]*types.BlockTrace{
+		trace2.Header.Hash(): trace2,
+		trace3.Header.Hash(): trace3,
+	}
+
+	var (
+		mu        sync.Mutex
+		submitted []client.SubmitProofRequest
+	)
+	coordinator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/submit_proof") {
+			var req client.SubmitProofRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mu.Lock()
+			submitted = append(submitted, req)
+			mu.Unlock()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer coordinator.Close()
+
+	// l2geth serves the traces streamed into the prover core once released, so that the proving takes long.
+	server := rpc.NewServer()
+	defer server.Stop()
+	api := &blockingScrollAPI{requested: make(chan struct{}, 1), release: make(chan struct{}), traces: traces}
+	assert.NoError(t, server.RegisterName("scroll", api))
+	assert.NoError(t, server.RegisterName("eth", &mockEthAPI{traces: traces}))
+	rpcClient := rpc.DialInProc(server)
+
+	path, err := os.MkdirTemp("/tmp/", "prover_graceful_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	for _, id := range []string{"task-1", "task-2"} {
+		assert.NoError(t, stack.Push(&store.ProvingTask{Task: &message.TaskMsg{
+			UUID:            "uuid-" + id,
+			ID:              id,
+			Type:            message.ProofTypeChunk,
+			ChunkTaskDetail: &message.ChunkTaskDetail{BlockHashes: []common.Hash{trace2.Header.Hash(), trace3.Header.Hash()}},
+		}}))
+	}
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: coordinator.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	metrics := initProverMetrics(prometheus.NewRegistry())
+	r := &Prover{
+		ctx: context.Background(),
+		cfg: &config.Config{
+			Core:   &config.ProverCoreConfig{ProofType: message.ProofTypeChunk},
+			L2Geth: &config.L2GethConfig{StreamTraces: true},
+		},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		l2GethClient:      ethclient.NewClient(rpcClient),
+		l2GethRPCClient:   rpcClient,
+		proverCore:        &core.ProverCore{},
+		proofLimiter:      newProofLimiter(nil, stack, metrics),
+		stopChan:          make(chan struct{}),
+		metrics:           metrics,
+	}
+
+	loopDone := make(chan struct{})
+	go func() {
+		r.ProveLoop()
+		close(loopDone)
+	}()
+	select {
+	case <-api.requested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("prover core didn't start proving the chunk")
+	}
+
+	// the prover waits for the task being proved.
+	stopped := make(chan error)
+	go func() { stopped <- r.StopGracefully(5 * time.Second) }()
+	select {
+	case <-stopped:
+		t.Fatal("prover stopped while the task is being proved")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(api.release)
+	select {
+	case err = <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("prover didn't stop once the task is proved")
+	}
+	select {
+	case <-loopDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("prove loop didn't exit")
+	}
+
+	// the task in progress is submitted before the prover stops, and no new task is taken.
+	select {
+	case <-r.Done():
+	default:
+		t.Fatal("prover isn't stopped")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, submitted, 1)
+	assert.Equal(t, "task-2", submitted[0].TaskID)
+	assert.Equal(t, int(message.StatusOk), submitted[0].Status)
+
+	// the graceful stop times out on a task that takes too long.
+	r = &Prover{stopChan: make(chan struct{}), stack: stack}
+	r.inFlight.Add(1)
+	assert.ErrorContains(t, r.StopGracefully(10*time.Millisecond), "timed out")
+	r.inFlight.Done()
+}
+
+func TestProveUnknownTaskType(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		submitted []client.SubmitProofRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/submit_proof") {
+			var req client.SubmitProofRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mu.Lock()
+			submitted = append(submitted, req)
+			mu.Unlock()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer server.Close()
+
+	path, err := os.MkdirTemp("/tmp/", "prover_unknown_type_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	metrics := initProverMetrics(prometheus.NewRegistry())
+	r := &Prover{
+		ctx:               context.Background(),
+		cfg:               &config.Config{Core: &config.ProverCoreConfig{ProofType: message.ProofTypeBatch}},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		proverCore:        &core.ProverCore{},
+		proofLimiter:      newProofLimiter(nil, stack, metrics),
+		stopChan:          make(chan struct{}),
+		metrics:           metrics,
+	}
+
+	// the task of an unknown proof type is reported as a proof error.
+	detail, err := r.prove(&store.ProvingTask{Task: &message.TaskMsg{ID: "task-1", Type: message.ProofType(99)}})
+	assert.ErrorContains(t, err, "mismatches the prover type")
+	assert.Equal(t, message.StatusProofError, detail.Status)
+	assert.Equal(t, err.Error(), detail.Error)
+	assert.Nil(t, detail.BatchProof)
+
+	// the task of another proof type is submitted as failed, not as proved.
+	task := &store.ProvingTask{Task: &message.TaskMsg{
+		UUID:            "uuid-2",
+		ID:              "task-2",
+		Type:            message.ProofTypeChunk,
+		ChunkTaskDetail: &message.ChunkTaskDetail{},
+	}}
+	assert.NoError(t, stack.Push(task))
+	assert.NoError(t, r.proveAndSubmitTask(task))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, submitted, 1)
+	assert.Equal(t, "task-2", submitted[0].TaskID)
+	assert.Equal(t, int(message.StatusProofError), submitted[0].Status)
+	assert.Equal(t, "", submitted[0].Proof)
+	assert.Contains(t, submitted[0].FailureMsg, "mismatches the prover type")
+	_, err = stack.Peek()
+	assert.ErrorIs(t, err, store.ErrEmpty)
+}
+
+func TestProveTimeout(t *testing.T) {
+	trace2 := loadBlockTrace(t, "../common/testdata/blockTrace_02.json")
+	trace3 := loadBlockTrace(t, "../common/testdata/blockTrace_03.json")
+	traces := map[common.Hash]*types.BlockTrace{
+		trace2.Header.Hash(): trace2,
+		trace3.Header.Hash(): trace3,
+	}
+
+	var (
+		mu        sync.Mutex
+		submitted []client.SubmitProofRequest
+	)
+	coordinator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/submit_proof") {
+			var req client.SubmitProofRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mu.Lock()
+			submitted = append(submitted, req)
+			mu.Unlock()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer coordinator.Close()
+
+	// l2geth hangs on serving the traces streamed into the prover core until it's released.
+	server := rpc.NewServer()
+	defer server.Stop()
+	api := &blockingScrollAPI{requested: make(chan struct{}, 1), release: make(chan struct{}), traces: traces}
+	assert.NoError(t, server.RegisterName("scroll", api))
+	assert.NoError(t, server.RegisterName("eth", &mockEthAPI{traces: traces}))
+	rpcClient := rpc.DialInProc(server)
+
+	path, err := os.MkdirTemp("/tmp/", "prover_timeout_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	for _, id := range []string{"task-1", "task-2"} {
+		assert.NoError(t, stack.Push(&store.ProvingTask{Task: &message.TaskMsg{
+			UUID:            "uuid-" + id,
+			ID:              id,
+			Type:            message.ProofTypeChunk,
+			ChunkTaskDetail: &message.ChunkTaskDetail{BlockHashes: []common.Hash{trace2.Header.Hash(), trace3.Header.Hash()}},
+		}}))
+	}
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: coordinator.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	metrics := initProverMetrics(prometheus.NewRegistry())
+	r := &Prover{
+		ctx: context.Background(),
+		cfg: &config.Config{
+			Core:            &config.ProverCoreConfig{ProofType: message.ProofTypeChunk},
+			L2Geth:          &config.L2GethConfig{StreamTraces: true},
+			ProveTimeoutSec: 1,
+		},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		l2GethClient:      ethclient.NewClient(rpcClient),
+		l2GethRPCClient:   rpcClient,
+		proverCore:        &core.ProverCore{},
+		proofLimiter:      newProofLimiter(nil, stack, metrics),
+		stopChan:          make(chan struct{}),
+		metrics:           metrics,
+	}
+
+	// the hanging task is failed once it times out.
+	start := time.Now()
+	assert.NoError(t, r.proveAndSubmit())
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	mu.Lock()
+	assert.Len(t, submitted, 1)
+	assert.Equal(t, "task-2", submitted[0].TaskID)
+	assert.Equal(t, int(message.StatusProofError), submitted[0].Status)
+	assert.Contains(t, submitted[0].FailureMsg, "prove timed out")
+	mu.Unlock()
+
+	// the next iteration of the loop moves on to the next task once the timed out call returns.
+	<-api.requested
+	done := make(chan error, 1)
+	go func() { done <- r.proveAndSubmit() }()
+	select {
+	case <-api.requested:
+		t.Fatal("task-1 proved while the timed out call is running")
+	case <-done:
+		t.Fatal("task-1 proved while the timed out call is running")
+	case <-time.After(200 * time.Millisecond):
+	}
+	close(api.release)
+	select {
+	case err = <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("task-1 not proved once the timed out call returned")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, submitted, 2)
+	assert.Equal(t, "task-1", submitted[1].TaskID)
+	assert.Equal(t, int(message.StatusOk), submitted[1].Status)
+	_, err = stack.Peek()
+	assert.ErrorIs(t, err, store.ErrEmpty)
+}
+
+func TestCurrentTask(t *testing.T) {
+	trace2 := loadBlockTrace(t, "../common/testdata/blockTrace_02.json")
+	trace3 := loadBlockTrace(t, "../common/testdata/blockTrace_03.json")
+	traces := map[common.Hash]*types.BlockTrace{
+		trace2.Header.Hash(): trace2,
+		trace3.Header.Hash(): trace3,
+	}
+
+	coordinator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	defer coordinator.Close()
+
+	// l2geth serves the traces streamed into the prover core once released, keeping the task being proved.
+	server := rpc.NewServer()
+	defer server.Stop()
+	api := &blockingScrollAPI{requested: make(chan struct{}, 1), release: make(chan struct{}), traces: traces}
+	assert.NoError(t, server.RegisterName("scroll", api))
+	assert.NoError(t, server.RegisterName("eth", &mockEthAPI{traces: traces}))
+	rpcClient := rpc.DialInProc(server)
+
+	path, err := os.MkdirTemp("/tmp/", "prover_current_task_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	assert.NoError(t, stack.Push(&store.ProvingTask{Task: &message.TaskMsg{
+		UUID:            "uuid-1",
+		ID:              "task-1",
+		Type:            message.ProofTypeChunk,
+		ChunkTaskDetail: &message.ChunkTaskDetail{BlockHashes: []common.Hash{trace2.Header.Hash(), trace3.Header.Hash()}},
+	}}))
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: coordinator.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	metrics := initProverMetrics(prometheus.NewRegistry())
+	r := &Prover{
+		ctx: context.Background(),
+		cfg: &config.Config{
+			Core:   &config.ProverCoreConfig{ProofType: message.ProofTypeChunk},
+			L2Geth: &config.L2GethConfig{StreamTraces: true},
+		},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		l2GethClient:      ethclient.NewClient(rpcClient),
+		l2GethRPCClient:   rpcClient,
+		proverCore:        &core.ProverCore{},
+		proofLimiter:      newProofLimiter(nil, stack, metrics),
+		stopChan:          make(chan struct{}),
+		metrics:           metrics,
+	}
+
+	// the prover is idle before taking the task.
+	_, _, _, ok := r.CurrentTask()
+	assert.False(t, ok)
+
+	start := time.Now()
+	done := make(chan error)
+	go func() { done <- r.proveAndSubmit() }()
+	select {
+	case <-api.requested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("prover core didn't start proving the chunk")
+	}
+
+	// the task being proved is reported.
+	taskID, proofType, startedAt, ok := r.CurrentTask()
+	assert.True(t, ok)
+	assert.Equal(t, "task-1", taskID)
+	assert.Equal(t, message.ProofTypeChunk, proofType)
+	assert.False(t, startedAt.Before(start))
+	assert.False(t, startedAt.After(time.Now()))
+
+	// the prover is idle again once the task is submitted.
+	close(api.release)
+	assert.NoError(t, <-done)
+	_, _, _, ok = r.CurrentTask()
+	assert.False(t, ok)
+}
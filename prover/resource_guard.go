@@ -0,0 +1,68 @@
+package prover
+
+import (
+	"path/filepath"
+
+	"github.com/scroll-tech/go-ethereum/log"
+
+	"scroll-tech/prover/config"
+)
+
+// resourceGuard pauses task fetching while the free disk space of the stack db
+// directory or the available memory is below the configured low-watermarks.
+type resourceGuard struct {
+	cfg     *config.ResourceGuardConfig
+	dir     string
+	metrics *proverMetrics
+
+	// probes of the free resources, replaced by fakes in tests.
+	freeDisk   func(path string) (uint64, error)
+	freeMemory func() (uint64, error)
+}
+
+func newResourceGuard(cfg *config.ResourceGuardConfig, dbPath string, metrics *proverMetrics) *resourceGuard {
+	return &resourceGuard{
+		cfg:        cfg,
+		dir:        filepath.Dir(dbPath),
+		metrics:    metrics,
+		freeDisk:   freeDiskBytes,
+		freeMemory: freeMemoryBytes,
+	}
+}
+
+func (g *resourceGuard) enabled() bool {
+	return g != nil && g.cfg != nil && (g.cfg.MinFreeDiskBytes > 0 || g.cfg.MinFreeMemoryBytes > 0)
+}
+
+// allow reports whether there are enough free resources to fetch a new task.
+// A failed probe doesn't block fetching, it's only logged.
+func (g *resourceGuard) allow() bool {
+	if !g.enabled() {
+		return true
+	}
+	allow := true
+	if g.cfg.MinFreeDiskBytes > 0 {
+		free, err := g.freeDisk(g.dir)
+		if err != nil {
+			log.Warn("failed to probe free disk space", "dir", g.dir, "error", err)
+		} else if free < g.cfg.MinFreeDiskBytes {
+			log.Warn("free disk space is critically low, skip fetching tasks",
+				"dir", g.dir, "free bytes", free, "min free bytes", g.cfg.MinFreeDiskBytes)
+			allow = false
+		}
+	}
+	if g.cfg.MinFreeMemoryBytes > 0 {
+		free, err := g.freeMemory()
+		if err != nil {
+			log.Warn("failed to probe available memory", "error", err)
+		} else if free < g.cfg.MinFreeMemoryBytes {
+			log.Warn("available memory is critically low, skip fetching tasks",
+				"free bytes", free, "min free bytes", g.cfg.MinFreeMemoryBytes)
+			allow = false
+		}
+	}
+	if !allow {
+		g.metrics.proverResourceLowTotal.Inc()
+	}
+	return allow
+}
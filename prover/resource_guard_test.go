@@ -0,0 +1,101 @@
+package prover
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"scroll-tech/prover/client"
+	"scroll-tech/prover/config"
+	"scroll-tech/prover/core"
+	"scroll-tech/prover/store"
+
+	"scroll-tech/common/types/message"
+)
+
+func newFakeResourceGuard(cfg *config.ResourceGuardConfig, disk, memory *uint64, probeErr *error) *resourceGuard {
+	g := newResourceGuard(cfg, "/tmp/stack", initProverMetrics(prometheus.NewRegistry()))
+	g.freeDisk = func(string) (uint64, error) { return atomic.LoadUint64(disk), *probeErr }
+	g.freeMemory = func() (uint64, error) { return atomic.LoadUint64(memory), *probeErr }
+	return g
+}
+
+func TestResourceGuard(t *testing.T) {
+	var probeErr error
+	disk, memory := uint64(100), uint64(100)
+	g := newFakeResourceGuard(&config.ResourceGuardConfig{MinFreeDiskBytes: 50, MinFreeMemoryBytes: 50}, &disk, &memory, &probeErr)
+	assert.True(t, g.allow())
+
+	atomic.StoreUint64(&disk, 10)
+	assert.False(t, g.allow())
+
+	atomic.StoreUint64(&disk, 100)
+	atomic.StoreUint64(&memory, 10)
+	assert.False(t, g.allow())
+
+	// a failed probe doesn't block fetching.
+	probeErr = errors.New("probe failed")
+	assert.True(t, g.allow())
+
+	// the watermarks are unset.
+	probeErr = nil
+	assert.True(t, newFakeResourceGuard(&config.ResourceGuardConfig{}, &disk, &memory, &probeErr).allow())
+	assert.True(t, newFakeResourceGuard(nil, &disk, &memory, &probeErr).allow())
+}
+
+func TestProveAndSubmitSkipFetchOnLowResource(t *testing.T) {
+	var coordinatorCalls int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&coordinatorCalls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	path, err := os.MkdirTemp("/tmp/", "prover_resource_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	defer stack.Close()
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	var probeErr error
+	disk, memory := uint64(10), uint64(100)
+	metrics := initProverMetrics(prometheus.NewRegistry())
+	r := &Prover{
+		ctx:               context.Background(),
+		cfg:               &config.Config{Core: &config.ProverCoreConfig{ProofType: message.ProofTypeBatch}},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		proverCore:        &core.ProverCore{},
+		proofLimiter:      newProofLimiter(nil, stack, metrics),
+		resourceGuard:     newFakeResourceGuard(&config.ResourceGuardConfig{MinFreeDiskBytes: 50}, &disk, &memory, &probeErr),
+		metrics:           metrics,
+	}
+
+	defer func(wait time.Duration) { retryWait = wait }(retryWait)
+	retryWait = time.Millisecond
+
+	// the coordinator isn't asked for a task while the disk space is low.
+	assert.NoError(t, r.proveAndSubmit())
+	assert.EqualValues(t, 0, atomic.LoadInt64(&coordinatorCalls))
+
+	// fetching resumes once the disk space is freed.
+	atomic.StoreUint64(&disk, 100)
+	assert.Error(t, r.proveAndSubmit())
+	assert.NotZero(t, atomic.LoadInt64(&coordinatorCalls))
+}
@@ -0,0 +1,47 @@
+package prover
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// freeDiskBytes returns the disk space available to unprivileged users on the filesystem of the path.
+func freeDiskBytes(path string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
+
+// freeMemoryBytes returns the MemAvailable of /proc/meminfo.
+func freeMemoryBytes() (uint64, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid MemAvailable %q: %w", fields[1], err)
+		}
+		return kb * 1024, nil
+	}
+	if err = scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.New("MemAvailable not found in /proc/meminfo")
+}
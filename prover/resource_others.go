@@ -0,0 +1,17 @@
+//go:build !linux
+
+package prover
+
+import (
+	"errors"
+)
+
+// freeDiskBytes is not supported on this platform.
+func freeDiskBytes(path string) (uint64, error) {
+	return 0, errors.New("probing free disk space is only supported on linux")
+}
+
+// freeMemoryBytes is not supported on this platform.
+func freeMemoryBytes() (uint64, error) {
+	return 0, errors.New("probing available memory is only supported on linux")
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// proofCountBucket holds the number of proofs produced in the current window.
+var proofCountBucket = []byte("proof-count")
+
+var proofCountKey = []byte("window")
+
+// ProofCount is the number of proofs produced in the window starting at WindowStart.
+type ProofCount struct {
+	WindowStart int64  `json:"window_start"`
+	Count       uint64 `json:"count"`
+}
+
+// GetProofCount returns the number of proofs produced in the window starting at windowStart.
+func (s *Stack) GetProofCount(windowStart int64) (uint64, error) {
+	var count uint64
+	err := s.view(func(tx *bbolt.Tx) error {
+		pc, err := getProofCount(tx)
+		if err != nil {
+			return err
+		}
+		if pc.WindowStart == windowStart {
+			count = pc.Count
+		}
+		return nil
+	})
+	return count, err
+}
+
+// IncreaseProofCount increases the number of proofs produced in the window starting at windowStart,
+// the count of the previous window is dropped once a new window starts.
+func (s *Stack) IncreaseProofCount(windowStart int64) (uint64, error) {
+	var count uint64
+	err := s.update(func(tx *bbolt.Tx) error {
+		pc, err := getProofCount(tx)
+		if err != nil {
+			return err
+		}
+		if pc.WindowStart != windowStart {
+			pc = &ProofCount{WindowStart: windowStart}
+		}
+		pc.Count++
+		count = pc.Count
+
+		byt, err := json.Marshal(pc)
+		if err != nil {
+			return fmt.Errorf("error marshaling proof count: %v", err)
+		}
+		return tx.Bucket(proofCountBucket).Put(proofCountKey, byt)
+	})
+	return count, err
+}
+
+func getProofCount(tx *bbolt.Tx) (*ProofCount, error) {
+	pc := &ProofCount{}
+	value := tx.Bucket(proofCountBucket).Get(proofCountKey)
+	if len(value) == 0 {
+		return pc, nil
+	}
+	if err := json.Unmarshal(value, pc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal proof count: %v", err)
+	}
+	return pc, nil
+}
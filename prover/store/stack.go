@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"sync"
 
+	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/log"
 	"go.etcd.io/bbolt"
 
@@ -17,8 +20,13 @@ var (
 )
 
 // Stack is a first-input last-output db.
+// Tasks are partitioned by proof type, each partition is stored in its own bucket.
 type Stack struct {
 	*bbolt.DB
+
+	// mu guards the db against being swapped by Compact.
+	mu   sync.RWMutex
+	path string
 }
 
 // ProvingTask is the value in stack.
@@ -29,8 +37,21 @@ type ProvingTask struct {
 	Times int `json:"times"`
 }
 
+// bucket is the default partition, it holds the tasks without a proof type
+// and the tasks pushed before the stack was partitioned.
 var bucket = []byte("stack")
 
+// partitions lists all the proof types that own a partition.
+var partitions = []message.ProofType{message.ProofTypeUndefined, message.ProofTypeChunk, message.ProofTypeBatch}
+
+// partitionBucket returns the bucket name of the partition of the given proof type.
+func partitionBucket(proofType message.ProofType) []byte {
+	if proofType == message.ProofTypeUndefined {
+		return bucket
+	}
+	return []byte(fmt.Sprintf("stack-%d", proofType))
+}
+
 // NewStack new a Stack object.
 func NewStack(path string) (*Stack, error) {
 	kvdb, err := bbolt.Open(path, 0666, nil)
@@ -38,34 +59,151 @@ func NewStack(path string) (*Stack, error) {
 		return nil, err
 	}
 	err = kvdb.Update(func(tx *bbolt.Tx) error {
-		_, err = tx.CreateBucketIfNotExists(bucket)
-		return err
+		for _, proofType := range partitions {
+			if _, err = tx.CreateBucketIfNotExists(partitionBucket(proofType)); err != nil {
+				return err
+			}
+		}
+		for _, name := range [][]byte{proofCountBucket, submitQueueBucket, failedProofsBucket} {
+			if _, err = tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return migrateToPartitions(tx)
 	})
 	if err != nil {
 		log.Crit("init stack failed", "error", err)
 	}
-	return &Stack{DB: kvdb}, nil
+	return &Stack{DB: kvdb, path: path}, nil
+}
+
+// migrateToPartitions moves the typed tasks left in the default bucket into their partitions.
+func migrateToPartitions(tx *bbolt.Tx) error {
+	bu := tx.Bucket(bucket)
+
+	moved := make(map[string]message.ProofType)
+	if err := bu.ForEach(func(k, v []byte) error {
+		task := &ProvingTask{}
+		if err := json.Unmarshal(v, task); err != nil {
+			return fmt.Errorf("failed to unmarshal task %s: %v", string(k), err)
+		}
+		if task.Task != nil && task.Task.Type != message.ProofTypeUndefined {
+			moved[string(k)] = task.Task.Type
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for k, proofType := range moved {
+		partition := tx.Bucket(partitionBucket(proofType))
+		if partition == nil {
+			return fmt.Errorf("unknown partition of proof type: %v", proofType)
+		}
+		if err := partition.Put([]byte(k), common.CopyBytes(bu.Get([]byte(k)))); err != nil {
+			return err
+		}
+		if err := bu.Delete([]byte(k)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// Push appends the proving-task on the top of Stack.
+// MigrateUntypedTasks moves the tasks without a proof type left in the default bucket, i.e. pushed by a prover
+// before the tasks were typed, into the partition of the given proof type, which is the one of the prover. The tasks
+// without a task message can't be proved and are dropped.
+func (s *Stack) MigrateUntypedTasks(proofType message.ProofType) error {
+	return s.update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket(bucket)
+		partition := tx.Bucket(partitionBucket(proofType))
+		if partition == nil {
+			return fmt.Errorf("unknown partition of proof type: %v", proofType)
+		}
+
+		tasks := make(map[string]*ProvingTask)
+		if err := bu.ForEach(func(k, v []byte) error {
+			task := &ProvingTask{}
+			if err := json.Unmarshal(v, task); err != nil {
+				return fmt.Errorf("failed to unmarshal task %s: %v", string(k), err)
+			}
+			tasks[string(k)] = task
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for k, task := range tasks {
+			if err := bu.Delete([]byte(k)); err != nil {
+				return err
+			}
+			if task.Task == nil {
+				log.Warn("drop the legacy task without a task message", "key", k)
+				continue
+			}
+			task.Task.Type = proofType
+			byt, err := json.Marshal(task)
+			if err != nil {
+				return err
+			}
+			if err = partition.Put([]byte(k), byt); err != nil {
+				return err
+			}
+			log.Info("migrate the legacy task without a proof type", "task-id", task.Task.ID, "proof-type", proofType)
+		}
+		return nil
+	})
+}
+
+// Push appends the proving-task on the top of its proof type partition.
 func (s *Stack) Push(task *ProvingTask) error {
 	byt, err := json.Marshal(task)
 	if err != nil {
 		return err
 	}
 	key := []byte(task.Task.ID)
-	return s.Update(func(tx *bbolt.Tx) error {
-		return tx.Bucket(bucket).Put(key, byt)
+	return s.update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket(partitionBucket(task.Task.Type))
+		if bu == nil {
+			return fmt.Errorf("unknown partition of proof type: %v", task.Task.Type)
+		}
+		return bu.Put(key, byt)
 	})
 }
 
-// Peek return the top element of the Stack.
+// Peek return the top element of the first non-empty partition of the Stack.
 func (s *Stack) Peek() (*ProvingTask, error) {
+	for _, proofType := range partitions {
+		task, err := s.PeekByType(proofType)
+		if errors.Is(err, ErrEmpty) {
+			continue
+		}
+		return task, err
+	}
+	return nil, ErrEmpty
+}
+
+// PeekByType return the top element of the partition of the given proof type.
+func (s *Stack) PeekByType(proofType message.ProofType) (*ProvingTask, error) {
+	return s.PeekByTypeExcept(proofType, nil)
+}
+
+// PeekByTypeExcept returns the topmost element of the partition of the given proof type whose task id isn't excluded,
+// so that the concurrent provers sharing the stack each take a different task.
+func (s *Stack) PeekByTypeExcept(proofType message.ProofType, excluded map[string]struct{}) (*ProvingTask, error) {
 	var value []byte
-	if err := s.View(func(tx *bbolt.Tx) error {
-		bu := tx.Bucket(bucket)
+	if err := s.view(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket(partitionBucket(proofType))
+		if bu == nil {
+			return fmt.Errorf("unknown partition of proof type: %v", proofType)
+		}
 		c := bu.Cursor()
-		_, value = c.Last()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			if _, ok := excluded[string(k)]; !ok {
+				value = common.CopyBytes(v)
+				break
+			}
+		}
 		return nil
 	}); err != nil {
 		return nil, err
@@ -82,11 +220,15 @@ func (s *Stack) Peek() (*ProvingTask, error) {
 	return traces, nil
 }
 
-// Delete pops the proving-task on the top of Stack.
+// Delete pops the proving-task from the Stack, whichever partition it's in.
 func (s *Stack) Delete(taskID string) error {
-	return s.Update(func(tx *bbolt.Tx) error {
-		bu := tx.Bucket(bucket)
-		return bu.Delete([]byte(taskID))
+	return s.update(func(tx *bbolt.Tx) error {
+		for _, proofType := range partitions {
+			if err := tx.Bucket(partitionBucket(proofType)).Delete([]byte(taskID)); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
@@ -98,10 +240,95 @@ func (s *Stack) UpdateTimes(task *ProvingTask, updateTimes int) error {
 		return fmt.Errorf("error marshaling task: %v", err)
 	}
 	key := []byte(task.Task.ID)
-	return s.Update(func(tx *bbolt.Tx) error {
-		bu := tx.Bucket(bucket)
-		c := bu.Cursor()
-		key, _ = c.Last()
+	return s.update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket(partitionBucket(task.Task.Type))
+		if bu == nil {
+			return fmt.Errorf("unknown partition of proof type: %v", task.Task.Type)
+		}
+		// the task isn't necessarily the top one once the stack is shared by concurrent provers.
+		if bu.Get(key) == nil {
+			return fmt.Errorf("task %s not found", task.Task.ID)
+		}
 		return bu.Put(key, byt)
 	})
 }
+
+func (s *Stack) view(fn func(*bbolt.Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.DB.View(fn)
+}
+
+func (s *Stack) update(fn func(*bbolt.Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.DB.Update(fn)
+}
+
+// Close closes the stack db.
+func (s *Stack) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.DB.Close()
+}
+
+// Compact copies the stack db into a new file to reclaim the free pages and replaces
+// the old file with it, it returns the file sizes before and after the compaction.
+func (s *Stack) Compact() (int64, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, err := fileSize(s.path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	compactPath := s.path + ".compact"
+	if err = os.RemoveAll(compactPath); err != nil {
+		return 0, 0, err
+	}
+	dst, err := bbolt.Open(compactPath, 0666, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open compact db: %v", err)
+	}
+	if err = bbolt.Compact(dst, s.DB, 0); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(compactPath)
+		return 0, 0, fmt.Errorf("failed to compact db: %v", err)
+	}
+	if err = dst.Close(); err != nil {
+		_ = os.Remove(compactPath)
+		return 0, 0, fmt.Errorf("failed to close compact db: %v", err)
+	}
+
+	// swap the db file with the compacted one.
+	if err = s.DB.Close(); err != nil {
+		_ = os.Remove(compactPath)
+		return 0, 0, fmt.Errorf("failed to close stack db: %v", err)
+	}
+	renameErr := os.Rename(compactPath, s.path)
+	// reopen the stack db, it's still the old one if the rename failed.
+	kvdb, err := bbolt.Open(s.path, 0666, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to reopen stack db: %v", err)
+	}
+	s.DB = kvdb
+	if renameErr != nil {
+		_ = os.Remove(compactPath)
+		return 0, 0, fmt.Errorf("failed to replace stack db with the compacted one: %v", renameErr)
+	}
+
+	after, err := fileSize(s.path)
+	if err != nil {
+		return 0, 0, err
+	}
+	return before, after, nil
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
@@ -1,13 +1,16 @@
 package store
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strconv"
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
+	"go.etcd.io/bbolt"
 
 	"scroll-tech/common/types/message"
 )
@@ -69,3 +72,284 @@ func TestStack(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 3, peek2.Times)
 }
+
+func TestStackPartition(t *testing.T) {
+	// Create temp path
+	path, err := os.MkdirTemp("/tmp/", "stack_db_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	// Create stack db instance
+	s, err := NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	defer s.Close()
+
+	for i := 0; i < 4; i++ {
+		proofType := message.ProofTypeChunk
+		if i%2 == 1 {
+			proofType = message.ProofTypeBatch
+		}
+		task := &ProvingTask{
+			Task: &message.TaskMsg{
+				ID:   strconv.Itoa(i),
+				Type: proofType,
+			},
+		}
+		assert.NoError(t, s.Push(task))
+	}
+
+	// the undefined partition is empty
+	_, err = s.PeekByType(message.ProofTypeUndefined)
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	// each partition only returns the tasks of its own proof type
+	for _, expected := range []string{"2", "0"} {
+		peek, peekErr := s.PeekByType(message.ProofTypeChunk)
+		assert.NoError(t, peekErr)
+		assert.Equal(t, expected, peek.Task.ID)
+		assert.Equal(t, message.ProofTypeChunk, peek.Task.Type)
+		assert.NoError(t, s.UpdateTimes(peek, 1))
+		assert.NoError(t, s.Delete(expected))
+	}
+	_, err = s.PeekByType(message.ProofTypeChunk)
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	peek, err := s.PeekByType(message.ProofTypeBatch)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", peek.Task.ID)
+	assert.Equal(t, 0, peek.Times)
+
+	// Peek falls through to the first non-empty partition
+	peek, err = s.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, message.ProofTypeBatch, peek.Task.Type)
+}
+
+func TestStackPeekByTypeExcept(t *testing.T) {
+	path, err := os.MkdirTemp("/tmp/", "stack_db_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	s, err := NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	defer s.Close()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, s.Push(&ProvingTask{Task: &message.TaskMsg{ID: strconv.Itoa(i), Type: message.ProofTypeChunk}}))
+	}
+
+	// the excluded tasks are skipped from the top.
+	excluded := map[string]struct{}{}
+	for _, expected := range []string{"2", "1", "0"} {
+		peek, peekErr := s.PeekByTypeExcept(message.ProofTypeChunk, excluded)
+		assert.NoError(t, peekErr)
+		assert.Equal(t, expected, peek.Task.ID)
+		excluded[expected] = struct{}{}
+	}
+	_, err = s.PeekByTypeExcept(message.ProofTypeChunk, excluded)
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	// the times of a task below the top are updated in place.
+	peek, err := s.PeekByTypeExcept(message.ProofTypeChunk, map[string]struct{}{"2": {}})
+	assert.NoError(t, err)
+	assert.NoError(t, s.UpdateTimes(peek, 2))
+	top, err := s.PeekByType(message.ProofTypeChunk)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", top.Task.ID)
+	assert.Equal(t, 0, top.Times)
+	peek, err = s.PeekByTypeExcept(message.ProofTypeChunk, map[string]struct{}{"2": {}})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", peek.Task.ID)
+	assert.Equal(t, 2, peek.Times)
+
+	// a deleted task isn't brought back by updating its times.
+	assert.NoError(t, s.Delete("1"))
+	assert.Error(t, s.UpdateTimes(peek, 3))
+}
+
+func TestStackMigrateToPartitions(t *testing.T) {
+	// Create temp path
+	path, err := os.MkdirTemp("/tmp/", "stack_db_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	dbPath := filepath.Join(path, "test-stack")
+
+	// write a typed task into the legacy bucket
+	kvdb, err := bbolt.Open(dbPath, 0666, nil)
+	assert.NoError(t, err)
+	byt, err := json.Marshal(&ProvingTask{Task: &message.TaskMsg{ID: "1", Type: message.ProofTypeBatch}})
+	assert.NoError(t, err)
+	assert.NoError(t, kvdb.Update(func(tx *bbolt.Tx) error {
+		bu, bucketErr := tx.CreateBucketIfNotExists(bucket)
+		if bucketErr != nil {
+			return bucketErr
+		}
+		return bu.Put([]byte("1"), byt)
+	}))
+	assert.NoError(t, kvdb.Close())
+
+	s, err := NewStack(dbPath)
+	assert.NoError(t, err)
+	defer s.Close()
+
+	_, err = s.PeekByType(message.ProofTypeUndefined)
+	assert.ErrorIs(t, err, ErrEmpty)
+	peek, err := s.PeekByType(message.ProofTypeBatch)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", peek.Task.ID)
+}
+
+func TestStackMigrateUntypedTasks(t *testing.T) {
+	// Create temp path
+	path, err := os.MkdirTemp("/tmp/", "stack_db_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	s, err := NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	defer s.Close()
+
+	// an untyped legacy task and a task without a task message are left in the default bucket.
+	assert.NoError(t, s.Push(&ProvingTask{Task: &message.TaskMsg{ID: "1"}, Times: 1}))
+	assert.NoError(t, s.update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte("2"), []byte(`{"times":0}`))
+	}))
+
+	assert.NoError(t, s.MigrateUntypedTasks(message.ProofTypeChunk))
+	_, err = s.PeekByType(message.ProofTypeUndefined)
+	assert.ErrorIs(t, err, ErrEmpty)
+	peek, err := s.PeekByType(message.ProofTypeChunk)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", peek.Task.ID)
+	assert.Equal(t, message.ProofTypeChunk, peek.Task.Type)
+	assert.Equal(t, 1, peek.Times)
+
+	// the migrated task is proved and deleted like any other task of the partition.
+	assert.NoError(t, s.Delete("1"))
+	_, err = s.Peek()
+	assert.ErrorIs(t, err, ErrEmpty)
+}
+
+func TestProofCount(t *testing.T) {
+	// Create temp path
+	path, err := os.MkdirTemp("/tmp/", "stack_db_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	// Create stack db instance
+	s, err := NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	defer s.Close()
+
+	count, err := s.GetProofCount(100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), count)
+
+	for i := 1; i <= 3; i++ {
+		count, err = s.IncreaseProofCount(100)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(i), count)
+	}
+
+	// the count of another window starts from zero
+	count, err = s.GetProofCount(200)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), count)
+	count, err = s.IncreaseProofCount(200)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	// the count of the previous window is dropped
+	count, err = s.GetProofCount(100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), count)
+}
+
+func TestStackCompact(t *testing.T) {
+	// Create temp path
+	path, err := os.MkdirTemp("/tmp/", "stack_db_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	// Create stack db instance
+	s, err := NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	defer s.Close()
+
+	// fill the db with large tasks and delete them
+	hashes := make([]common.Hash, 1000)
+	for i := 0; i < 100; i++ {
+		task := &ProvingTask{
+			Task: &message.TaskMsg{
+				ID:              strconv.Itoa(i),
+				Type:            message.ProofTypeChunk,
+				ChunkTaskDetail: &message.ChunkTaskDetail{BlockHashes: hashes},
+			},
+		}
+		assert.NoError(t, s.Push(task))
+	}
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, s.Delete(strconv.Itoa(i)))
+	}
+	assert.NoError(t, s.Push(&ProvingTask{Task: &message.TaskMsg{ID: "kept", Type: message.ProofTypeBatch}}))
+
+	before, after, err := s.Compact()
+	assert.NoError(t, err)
+	assert.Less(t, after, before)
+
+	// the stack is still usable after the compaction
+	peek, err := s.PeekByType(message.ProofTypeBatch)
+	assert.NoError(t, err)
+	assert.Equal(t, "kept", peek.Task.ID)
+	assert.NoError(t, s.Push(&ProvingTask{Task: &message.TaskMsg{ID: "new", Type: message.ProofTypeChunk}}))
+	peek, err = s.PeekByType(message.ProofTypeChunk)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", peek.Task.ID)
+}
+
+func TestSubmitQueue(t *testing.T) {
+	// Create temp path
+	path, err := os.MkdirTemp("/tmp/", "stack_db_test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	// Create stack db instance
+	s, err := NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+	defer s.Close()
+
+	_, err = s.PeekPendingProof()
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	for i := 0; i < 2; i++ {
+		err = s.PushPendingProof(&PendingProof{
+			UUID:  strconv.Itoa(i),
+			Proof: &message.ProofDetail{ID: strconv.Itoa(i), Type: message.ProofTypeChunk},
+		})
+		assert.NoError(t, err)
+	}
+
+	// proofs are resubmitted in order.
+	proof, err := s.PeekPendingProof()
+	assert.NoError(t, err)
+	assert.Equal(t, "0", proof.Proof.ID)
+
+	proof.Retries = 3
+	assert.NoError(t, s.PushPendingProof(proof))
+	proof, err = s.PeekPendingProof()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, proof.Retries)
+
+	assert.NoError(t, s.MoveToFailedProofs(proof))
+	failed, err := s.GetFailedProofs()
+	assert.NoError(t, err)
+	assert.Len(t, failed, 1)
+	assert.Equal(t, "0", failed[0].Proof.ID)
+
+	proof, err = s.PeekPendingProof()
+	assert.NoError(t, err)
+	assert.Equal(t, "1", proof.Proof.ID)
+	assert.NoError(t, s.DeletePendingProof(proof.Proof.ID))
+	_, err = s.PeekPendingProof()
+	assert.ErrorIs(t, err, ErrEmpty)
+}
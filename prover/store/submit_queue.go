@@ -0,0 +1,110 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"go.etcd.io/bbolt"
+
+	"scroll-tech/common/types/message"
+)
+
+// submitQueueBucket holds the proofs failed to be submitted to the coordinator, waiting to be resubmitted.
+var submitQueueBucket = []byte("submit-queue")
+
+// failedProofsBucket holds the proofs given up after too many failed submissions.
+var failedProofsBucket = []byte("failed-proofs")
+
+// PendingProof is a proof waiting to be submitted to the coordinator.
+type PendingProof struct {
+	UUID  string               `json:"uuid"`
+	Proof *message.ProofDetail `json:"proof"`
+	// Retries is how many times the proof failed to be submitted.
+	Retries int `json:"retries"`
+	// SubTask is the sub-chunk the proof is of, the id of the proof is then the one of the sub-chunk.
+	SubTask *message.SubTask `json:"sub_task,omitempty"`
+	// TaskID is the id of the task the sub-chunk is split from, only set along with SubTask.
+	TaskID string `json:"task_id,omitempty"`
+}
+
+// PushPendingProof adds the proof into the submit queue.
+func (s *Stack) PushPendingProof(proof *PendingProof) error {
+	byt, err := json.Marshal(proof)
+	if err != nil {
+		return fmt.Errorf("error marshaling pending proof: %v", err)
+	}
+	return s.update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(submitQueueBucket).Put([]byte(proof.Proof.ID), byt)
+	})
+}
+
+// PeekPendingProof returns the first proof of the submit queue.
+func (s *Stack) PeekPendingProof() (*PendingProof, error) {
+	return s.PeekPendingProofExcept(nil)
+}
+
+// PeekPendingProofExcept returns the first proof of the submit queue whose task id isn't excluded,
+// so that a proof being submitted by a prover isn't resubmitted by another one concurrently.
+func (s *Stack) PeekPendingProofExcept(excluded map[string]struct{}) (*PendingProof, error) {
+	var value []byte
+	if err := s.view(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(submitQueueBucket).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if _, ok := excluded[string(k)]; !ok {
+				value = common.CopyBytes(v)
+				break
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(value) == 0 {
+		return nil, ErrEmpty
+	}
+
+	proof := &PendingProof{}
+	if err := json.Unmarshal(value, proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// DeletePendingProof removes the proof of the task from the submit queue.
+func (s *Stack) DeletePendingProof(taskID string) error {
+	return s.update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(submitQueueBucket).Delete([]byte(taskID))
+	})
+}
+
+// MoveToFailedProofs moves the proof from the submit queue into the failed proofs.
+func (s *Stack) MoveToFailedProofs(proof *PendingProof) error {
+	byt, err := json.Marshal(proof)
+	if err != nil {
+		return fmt.Errorf("error marshaling pending proof: %v", err)
+	}
+	key := []byte(proof.Proof.ID)
+	return s.update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(submitQueueBucket).Delete(key); err != nil {
+			return err
+		}
+		return tx.Bucket(failedProofsBucket).Put(key, byt)
+	})
+}
+
+// GetFailedProofs returns all the proofs given up after too many failed submissions.
+func (s *Stack) GetFailedProofs() ([]*PendingProof, error) {
+	var proofs []*PendingProof
+	err := s.view(func(tx *bbolt.Tx) error {
+		return tx.Bucket(failedProofsBucket).ForEach(func(k, v []byte) error {
+			proof := &PendingProof{}
+			if err := json.Unmarshal(v, proof); err != nil {
+				return fmt.Errorf("failed to unmarshal failed proof %s: %v", string(k), err)
+			}
+			proofs = append(proofs, proof)
+			return nil
+		})
+	})
+	return proofs, err
+}
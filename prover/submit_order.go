@@ -0,0 +1,75 @@
+package prover
+
+import (
+	"sync"
+)
+
+// submitOrder makes the workers submit their proofs in the order the tasks are claimed, see Config.OrderedSubmit.
+// A worker done proving holds its proof until the workers of the earlier tasks are done with theirs, so at most
+// Concurrency-1 proofs are held at a time.
+type submitOrder struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	// the sequence number of each claimed task not done yet.
+	seqs    map[string]uint64
+	nextSeq uint64
+	// set once the prover is stopping, the held proofs are then submitted without waiting for their turn.
+	flushed bool
+}
+
+func (o *submitOrder) init() {
+	if o.seqs == nil {
+		o.seqs = make(map[string]uint64)
+		o.cond = sync.NewCond(&o.mu)
+	}
+}
+
+// enqueue gives the claimed task the next turn to submit.
+func (o *submitOrder) enqueue(taskID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.init()
+	o.seqs[taskID] = o.nextSeq
+	o.nextSeq++
+}
+
+// waitTurn blocks until the earlier tasks are done, it returns right away for a task not enqueued.
+func (o *submitOrder) waitTurn(taskID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.init()
+	for !o.flushed && o.hasEarlier(taskID) {
+		o.cond.Wait()
+	}
+}
+
+func (o *submitOrder) hasEarlier(taskID string) bool {
+	seq, ok := o.seqs[taskID]
+	if !ok {
+		return false
+	}
+	for _, other := range o.seqs {
+		if other < seq {
+			return true
+		}
+	}
+	return false
+}
+
+// done ends the turn of the task whether its proof is submitted or not, e.g. the task is left in the stack.
+func (o *submitOrder) done(taskID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.init()
+	delete(o.seqs, taskID)
+	o.cond.Broadcast()
+}
+
+// flush releases the held proofs to be submitted right away.
+func (o *submitOrder) flush() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.init()
+	o.flushed = true
+	o.cond.Broadcast()
+}
@@ -0,0 +1,132 @@
+package prover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"scroll-tech/prover/client"
+	"scroll-tech/prover/config"
+	"scroll-tech/prover/core"
+	"scroll-tech/prover/store"
+
+	ctypes "scroll-tech/common/types"
+	"scroll-tech/common/types/message"
+)
+
+func newOrderedSubmitProver(t *testing.T, submitted chan<- string) *Prover {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/submit_proof") {
+			var req client.SubmitProofRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			submitted <- req.TaskID
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errcode": ctypes.Success})
+	}))
+	t.Cleanup(server.Close)
+
+	path, err := os.MkdirTemp("/tmp/", "prover_ordered_submit_test-")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(path) })
+	stack, err := store.NewStack(filepath.Join(path, "test-stack"))
+	assert.NoError(t, err)
+
+	priv, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	coordinatorClient, err := client.NewCoordinatorClient(&config.CoordinatorConfig{BaseURL: server.URL, ConnectionTimeoutSec: 5}, "test-prover", priv, nil)
+	assert.NoError(t, err)
+
+	return &Prover{
+		ctx:               context.Background(),
+		cfg:               &config.Config{Core: &config.ProverCoreConfig{ProofType: message.ProofTypeBatch}, Concurrency: 3, OrderedSubmit: true},
+		stack:             stack,
+		coordinatorClient: coordinatorClient,
+		proverCore:        &core.ProverCore{},
+		stopChan:          make(chan struct{}),
+		metrics:           initProverMetrics(prometheus.NewRegistry()),
+	}
+}
+
+func TestOrderedSubmit(t *testing.T) {
+	submitted := make(chan string, 4)
+	r := newOrderedSubmitProver(t, submitted)
+	defer r.Stop()
+
+	tasks := make([]*store.ProvingTask, 4)
+	for i := range tasks {
+		id := fmt.Sprintf("task-%d", i)
+		tasks[i] = &store.ProvingTask{Task: &message.TaskMsg{UUID: "uuid-" + id, ID: id, Type: message.ProofTypeBatch}}
+		r.claimTask(tasks[i])
+	}
+
+	// the proofs complete in the reverse order, and task-1 is left in the stack without a proof.
+	var wg sync.WaitGroup
+	for _, i := range []int{3, 2, 0} {
+		task := tasks[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer r.releaseTask(task)
+			proofMsg := &message.ProofDetail{ID: task.Task.ID, Type: message.ProofTypeBatch, Status: message.StatusOk, BatchProof: &message.BatchProof{}}
+			assert.NoError(t, r.submitProof(proofMsg, task.Task.UUID))
+		}()
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// task-0 is submitted while the later proofs are held for task-1.
+	assert.Equal(t, "task-0", <-submitted)
+	select {
+	case id := <-submitted:
+		t.Fatalf("%s submitted before task-1 is done", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	r.releaseTask(tasks[1])
+	wg.Wait()
+	assert.Equal(t, "task-2", <-submitted)
+	assert.Equal(t, "task-3", <-submitted)
+}
+
+func TestOrderedSubmitFlushOnStop(t *testing.T) {
+	submitted := make(chan string, 2)
+	r := newOrderedSubmitProver(t, submitted)
+
+	tasks := make([]*store.ProvingTask, 2)
+	for i := range tasks {
+		id := fmt.Sprintf("task-%d", i)
+		tasks[i] = &store.ProvingTask{Task: &message.TaskMsg{UUID: "uuid-" + id, ID: id, Type: message.ProofTypeBatch}}
+		r.claimTask(tasks[i])
+	}
+
+	// the proof of task-1 is held for task-0, which is never proved.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer r.releaseTask(tasks[1])
+		proofMsg := &message.ProofDetail{ID: "task-1", Type: message.ProofTypeBatch, Status: message.StatusOk, BatchProof: &message.BatchProof{}}
+		_ = r.submitProof(proofMsg, "uuid-task-1")
+	}()
+	select {
+	case id := <-submitted:
+		t.Fatalf("%s submitted before task-0 is done", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the held proof is submitted once the prover is stopping.
+	r.Stop()
+	assert.Equal(t, "task-1", <-submitted)
+	<-done
+}
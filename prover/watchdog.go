@@ -0,0 +1,57 @@
+package prover
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/log"
+
+	"scroll-tech/prover/config"
+)
+
+// beat records a heartbeat of the prove loop.
+func (r *Prover) beat() {
+	atomic.StoreInt64(&r.heartbeat, time.Now().UnixNano())
+}
+
+// LastHeartbeat returns the time of the latest prove loop iteration, it can back a liveness check.
+func (r *Prover) LastHeartbeat() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&r.heartbeat))
+}
+
+// watchdogLoop checks the heartbeat of the prove loop every checkInterval and reports
+// once per stall when the heartbeat is older than threshold.
+func (r *Prover) watchdogLoop(threshold, checkInterval time.Duration) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	var reported int64
+	for {
+		select {
+		case <-r.stopChan:
+			return
+		case <-ticker.C:
+			heartbeat := atomic.LoadInt64(&r.heartbeat)
+			stale := time.Since(time.Unix(0, heartbeat))
+			if stale <= threshold || heartbeat == reported {
+				continue
+			}
+			reported = heartbeat
+			r.metrics.proverProveLoopStalledTotal.Inc()
+			log.Error("prove loop stalled", "last heartbeat", time.Unix(0, heartbeat), "stale", stale, "threshold", threshold)
+			if r.onStalled != nil {
+				r.onStalled(stale)
+			}
+		}
+	}
+}
+
+// exitOnStalled returns the stall handler exiting the process if configured, nil otherwise.
+func exitOnStalled(cfg *config.WatchdogConfig) func(time.Duration) {
+	if cfg == nil || !cfg.ExitOnStale {
+		return nil
+	}
+	return func(stale time.Duration) {
+		log.Crit("exit on stalled prove loop", "stale", stale)
+	}
+}
@@ -0,0 +1,48 @@
+package prover
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+
+	"scroll-tech/prover/config"
+)
+
+func TestWatchdog(t *testing.T) {
+	stalled := make(chan time.Duration, 2)
+	r := &Prover{
+		cfg:       &config.Config{},
+		stopChan:  make(chan struct{}),
+		metrics:   initProverMetrics(prometheus.NewRegistry()),
+		onStalled: func(stale time.Duration) { stalled <- stale },
+	}
+	defer close(r.stopChan)
+
+	// a healthy loop keeps beating, the watchdog stays quiet.
+	r.beat()
+	go r.watchdogLoop(time.Second, 50*time.Millisecond)
+	for i := 0; i < 10; i++ {
+		r.beat()
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.Len(t, stalled, 0)
+
+	// simulate a stalled loop, the watchdog fires once for the stall.
+	select {
+	case stale := <-stalled:
+		assert.Greater(t, stale, time.Second)
+	case <-time.After(3 * time.Second):
+		t.Fatal("watchdog didn't fire on the stalled prove loop")
+	}
+	time.Sleep(200 * time.Millisecond)
+	assert.Len(t, stalled, 0)
+	assert.WithinDuration(t, time.Now(), r.LastHeartbeat(), 2*time.Second)
+}
+
+func TestExitOnStalled(t *testing.T) {
+	assert.Nil(t, exitOnStalled(nil))
+	assert.Nil(t, exitOnStalled(&config.WatchdogConfig{StaleThresholdSec: 60}))
+	assert.NotNil(t, exitOnStalled(&config.WatchdogConfig{StaleThresholdSec: 60, ExitOnStale: true}))
+}
@@ -117,6 +117,10 @@ func action(ctx *cli.Context) error {
 	// Wait until the interrupt signal is received from an OS signal.
 	<-interrupt
 
+	// Stop the processing loops before stopping the relayer, the db is closed afterwards.
+	cancel()
+	l2relayer.Stop()
+
 	return nil
 }
 
@@ -34,7 +34,7 @@ func init() {
 	app.Version = version.Version
 	app.Flags = append(app.Flags, utils.CommonFlags...)
 	app.Flags = append(app.Flags, utils.RollupRelayerFlags...)
-	app.Commands = []*cli.Command{}
+	app.Commands = stateCommands
 	app.Before = func(ctx *cli.Context) error {
 		return utils.LogSetup(ctx)
 	}
@@ -118,6 +118,10 @@ func action(ctx *cli.Context) error {
 	// Wait until the interrupt signal is received from an OS signal.
 	<-interrupt
 
+	// Stop the processing loops before stopping the relayer, the db is closed afterwards.
+	cancel()
+	l2relayer.Stop()
+
 	return nil
 }
 
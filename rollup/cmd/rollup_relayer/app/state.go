@@ -0,0 +1,111 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli/v2"
+	"gorm.io/gorm"
+
+	"scroll-tech/common/database"
+	"scroll-tech/common/utils"
+
+	"scroll-tech/rollup/internal/config"
+	"scroll-tech/rollup/internal/controller/relayer"
+)
+
+var (
+	fromIndexFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "The index of the first batch",
+		Value: 0,
+	}
+	toIndexFlag = cli.Uint64Flag{
+		Name:     "to",
+		Usage:    "The index of the last batch",
+		Required: true,
+	}
+	stateFileFlag = cli.StringFlag{
+		Name:  "file",
+		Usage: "The state file, empty means stdout for export-state and stdin for verify-state",
+	}
+
+	stateCommands = []*cli.Command{
+		{
+			Name:   "export-state",
+			Usage:  "Export the rollup state of a range of batches, one json object per line",
+			Flags:  []cli.Flag{&fromIndexFlag, &toIndexFlag, &stateFileFlag},
+			Action: exportStateAction,
+		},
+		{
+			Name:   "verify-state",
+			Usage:  "Verify an exported rollup state against the db",
+			Flags:  []cli.Flag{&stateFileFlag},
+			Action: verifyStateAction,
+		},
+	}
+)
+
+func exportStateAction(ctx *cli.Context) (err error) {
+	var w io.Writer = os.Stdout
+	if file := ctx.String(stateFileFlag.Name); file != "" {
+		f, createErr := os.Create(filepath.Clean(file))
+		if createErr != nil {
+			return fmt.Errorf("failed to create state file: %w", createErr)
+		}
+		defer func() {
+			if closeErr := f.Close(); err == nil && closeErr != nil {
+				err = fmt.Errorf("failed to close state file: %w", closeErr)
+			}
+		}()
+		w = f
+	}
+
+	return withDB(ctx, func(db *gorm.DB) error {
+		exported, exportErr := relayer.ExportBatchStates(ctx.Context, db, w, ctx.Uint64(fromIndexFlag.Name), ctx.Uint64(toIndexFlag.Name))
+		if exportErr != nil {
+			return fmt.Errorf("failed to export state after %v batches: %w", exported, exportErr)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "exported %v batches\n", exported)
+		return nil
+	})
+}
+
+func verifyStateAction(ctx *cli.Context) error {
+	var rd io.Reader = os.Stdin
+	if file := ctx.String(stateFileFlag.Name); file != "" {
+		f, err := os.Open(filepath.Clean(file))
+		if err != nil {
+			return fmt.Errorf("failed to open state file: %w", err)
+		}
+		defer f.Close()
+		rd = f
+	}
+
+	return withDB(ctx, func(db *gorm.DB) error {
+		verified, err := relayer.VerifyBatchStates(ctx.Context, db, rd)
+		if err != nil {
+			return fmt.Errorf("failed to verify state after %v batches: %w", verified, err)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "verified %v batches\n", verified)
+		return nil
+	})
+}
+
+func withDB(ctx *cli.Context, fn func(db *gorm.DB) error) error {
+	cfgFile := ctx.String(utils.ConfigFileFlag.Name)
+	cfg, err := config.NewConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config file: %w", err)
+	}
+	db, err := database.InitDB(cfg.DBConfig)
+	if err != nil {
+		return fmt.Errorf("failed to init db connection: %w", err)
+	}
+	defer func() {
+		_ = database.CloseDB(db)
+	}()
+	return fn(db)
+}
@@ -7,6 +7,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/rpc"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -35,6 +37,97 @@ func TestConfig(t *testing.T) {
 		assert.Equal(t, cfg.DBConfig, cfg2.DBConfig)
 	})
 
+	t.Run("Extra Finalize Sender Private Keys", func(t *testing.T) {
+		var relayerCfg RelayerConfig
+		err := json.Unmarshal([]byte(`{
+			"finalize_sender_private_key": "1515151515151515151515151515151515151515151515151515151515151515",
+			"extra_finalize_sender_private_keys": ["1212121212121212121212121212121212121212121212121212121212121212"]
+		}`), &relayerCfg)
+		assert.NoError(t, err)
+		assert.Len(t, relayerCfg.ExtraFinalizeSenderPrivateKeys, 1)
+
+		// the extra finalize sender account duplicates the finalize sender account.
+		err = json.Unmarshal([]byte(`{
+			"finalize_sender_private_key": "1515151515151515151515151515151515151515151515151515151515151515",
+			"extra_finalize_sender_private_keys": ["1515151515151515151515151515151515151515151515151515151515151515"]
+		}`), &relayerCfg)
+		assert.ErrorContains(t, err, "detected duplicated address")
+	})
+
+	t.Run("Shared Sender Private Keys", func(t *testing.T) {
+		var relayerCfg RelayerConfig
+		err := json.Unmarshal([]byte(`{
+			"gas_oracle_sender_private_key": "1313131313131313131313131313131313131313131313131313131313131313",
+			"commit_sender_private_key": "1414141414141414141414141414141414141414141414141414141414141414",
+			"finalize_sender_private_key": "1515151515151515151515151515151515151515151515151515151515151515"
+		}`), &relayerCfg)
+		assert.NoError(t, err)
+		assert.Empty(t, relayerCfg.SharedSenderAccounts())
+
+		// the commit sender and the extra finalize sender share the private key.
+		sharedKeys := `{
+			"allow_shared_sender_keys": %v,
+			"gas_oracle_sender_private_key": "1313131313131313131313131313131313131313131313131313131313131313",
+			"commit_sender_private_key": "1414141414141414141414141414141414141414141414141414141414141414",
+			"finalize_sender_private_key": "1515151515151515151515151515151515151515151515151515151515151515",
+			"extra_finalize_sender_private_keys": ["1414141414141414141414141414141414141414141414141414141414141414"]
+		}`
+		relayerCfg = RelayerConfig{}
+		err = json.Unmarshal([]byte(fmt.Sprintf(sharedKeys, false)), &relayerCfg)
+		assert.ErrorContains(t, err, "detected duplicated address")
+
+		relayerCfg = RelayerConfig{}
+		err = json.Unmarshal([]byte(fmt.Sprintf(sharedKeys, true)), &relayerCfg)
+		assert.NoError(t, err)
+		shared := relayerCfg.SharedSenderAccounts()
+		assert.Len(t, shared, 1)
+		assert.Equal(t, crypto.PubkeyToAddress(relayerCfg.CommitSenderPrivateKey.PublicKey), shared[0])
+	})
+
+	t.Run("Sender Config Overrides", func(t *testing.T) {
+		var relayerCfg RelayerConfig
+		err := json.Unmarshal([]byte(`{
+			"sender_config": {"endpoint": "http://shared", "confirmations": "0x0"},
+			"finalize_sender_config": {"endpoint": "http://finalize", "confirmations": "0x6"}
+		}`), &relayerCfg)
+		assert.NoError(t, err)
+		assert.Equal(t, "http://shared", relayerCfg.GetCommitSenderConfig().Endpoint)
+		assert.Equal(t, "http://shared", relayerCfg.GetGasOracleSenderConfig().Endpoint)
+		assert.Equal(t, "http://finalize", relayerCfg.GetFinalizeSenderConfig().Endpoint)
+		assert.Equal(t, rpc.BlockNumber(6), relayerCfg.GetFinalizeSenderConfig().Confirmations)
+
+		err = json.Unmarshal([]byte(`{
+			"sender_config": {"endpoint": "http://shared"},
+			"commit_sender_config": {"endpoint": "http://commit"},
+			"gas_oracle_sender_config": {"endpoint": "http://gas-oracle"}
+		}`), &relayerCfg)
+		assert.NoError(t, err)
+		assert.Equal(t, "http://commit", relayerCfg.GetCommitSenderConfig().Endpoint)
+		assert.Equal(t, "http://gas-oracle", relayerCfg.GetGasOracleSenderConfig().Endpoint)
+		assert.Equal(t, "http://shared", relayerCfg.GetFinalizeSenderConfig().Endpoint)
+	})
+
+	t.Run("Gas Price Diff Precision", func(t *testing.T) {
+		var relayerCfg RelayerConfig
+		err := json.Unmarshal([]byte(`{"gas_oracle_config": {"gas_price_diff": 500, "emergency_gas_price_diff": 1000, "gas_price_diff_precision": 1000}}`), &relayerCfg)
+		assert.NoError(t, err)
+
+		err = json.Unmarshal([]byte(`{"gas_oracle_config": {"gas_price_diff": 50000, "gas_price_diff_precision": 1000}}`), &relayerCfg)
+		assert.ErrorContains(t, err, "gas price diff 50000 exceeds gas price diff precision 1000")
+
+		err = json.Unmarshal([]byte(`{"gas_oracle_config": {"gas_price_diff": 500, "emergency_gas_price_diff": 1001, "gas_price_diff_precision": 1000}}`), &relayerCfg)
+		assert.ErrorContains(t, err, "emergency gas price diff 1001 exceeds gas price diff precision 1000")
+	})
+
+	t.Run("Finalize Batch Order", func(t *testing.T) {
+		var relayerCfg RelayerConfig
+		err := json.Unmarshal([]byte(`{"finalize_batch_order": "oldest"}`), &relayerCfg)
+		assert.NoError(t, err)
+
+		err = json.Unmarshal([]byte(`{"finalize_batch_order": "newest"}`), &relayerCfg)
+		assert.ErrorContains(t, err, "finalize batch order newest is unsupported by the rollup contract")
+	})
+
 	t.Run("File Not Found", func(t *testing.T) {
 		_, err := NewConfig("non_existent_file.json")
 		assert.ErrorIs(t, err, os.ErrNotExist)
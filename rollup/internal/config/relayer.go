@@ -18,6 +18,9 @@ type SenderConfig struct {
 	CheckPendingTime uint64 `json:"check_pending_time"`
 	// The number of blocks to wait to escalate increase gas price of the transaction.
 	EscalateBlocks uint64 `json:"escalate_blocks"`
+	// The number of seconds to wait to escalate the gas price of the transaction, whichever of EscalateBlocks and
+	// EscalateIntervalSec is reached first, 0 means only EscalateBlocks is used.
+	EscalateIntervalSec uint64 `json:"escalate_interval_sec,omitempty"`
 	// The gap number between a block be confirmed and the latest block.
 	Confirmations rpc.BlockNumber `json:"confirmations"`
 	// The numerator of gas price escalate multiple.
@@ -47,6 +50,11 @@ type RelayerConfig struct {
 	GasPriceOracleContractAddress common.Address `json:"gas_price_oracle_contract_address"`
 	// sender config
 	SenderConfig *SenderConfig `json:"sender_config"`
+	// The sender configs of the commit, finalize and gas oracle senders, replacing SenderConfig as a whole for the
+	// sender, e.g. to use different confirmations or gas strategies per role. nil means SenderConfig is used.
+	CommitSenderConfig    *SenderConfig `json:"commit_sender_config,omitempty"`
+	FinalizeSenderConfig  *SenderConfig `json:"finalize_sender_config,omitempty"`
+	GasOracleSenderConfig *SenderConfig `json:"gas_oracle_sender_config,omitempty"`
 	// gas oracle config
 	GasOracleConfig *GasOracleConfig `json:"gas_oracle_config"`
 	// ChainMonitor config of monitoring service
@@ -57,19 +65,130 @@ type RelayerConfig struct {
 	GasOracleSenderPrivateKey *ecdsa.PrivateKey `json:"-"`
 	CommitSenderPrivateKey    *ecdsa.PrivateKey `json:"-"`
 	FinalizeSenderPrivateKey  *ecdsa.PrivateKey `json:"-"`
+	// The private keys of the additional finalize sender accounts, finalize txs are distributed across all the
+	// finalize sender accounts. Since the txs of different accounts may be mined out of order while the rollup
+	// contract finalizes batches in index order, a batch is only finalized once its parent batch is finalized.
+	ExtraFinalizeSenderPrivateKeys []*ecdsa.PrivateKey `json:"-"`
 
 	// Indicates if bypass features specific to testing environments are enabled.
 	EnableTestEnvBypassFeatures bool `json:"enable_test_env_bypass_features"`
 	// The timeout in seconds for finalizing a batch without proof, only used when EnableTestEnvBypassFeatures is true.
 	FinalizeBatchWithoutProofTimeoutSec uint64 `json:"finalize_batch_without_proof_timeout_sec"`
+	// The minimum interval in seconds between the finalization of the latest finalized batch in layer1 and the
+	// finalization of the next batch, 0 means no limit.
+	FinalizeBatchIntervalSec uint64 `json:"finalize_batch_interval_sec,omitempty"`
+
+	// Indicates if the receipt of a finalize tx is checked for the FinalizeBatch event of the batch.
+	VerifyFinalizeEvent bool `json:"verify_finalize_event,omitempty"`
+	// Indicates if the receipt of a confirmed tx is re-fetched from layer1 and cross-checked before updating the db,
+	// a confirmation not verified yet is kept and re-checked.
+	VerifyConfirmationReceipt bool `json:"verify_confirmation_receipt,omitempty"`
+	// Indicates if the l2 base fee stored by the gas price oracle is read after a setL2BaseFee tx is confirmed,
+	// and the update is treated as failed if it doesn't match the pushed value.
+	VerifyGasOracleValue bool `json:"verify_gas_oracle_value,omitempty"`
+	// The timeout in seconds after which a verified batch without proof is marked as RollupProofMissing, 0 means never.
+	ProofMissingTimeoutSec uint64 `json:"proof_missing_timeout_sec,omitempty"`
+	// Indicates if the finalizeBatchWithProof tx is simulated by eth_call before being sent.
+	SimulateFinalizeTx bool `json:"simulate_finalize_tx,omitempty"`
+	// The delay in seconds before a batch whose commit tx failed is moved back to RollupPending to be re-committed,
+	// 0 means failed batches are re-committed right away.
+	CommitFailedRetryDelaySec uint64 `json:"commit_failed_retry_delay_sec,omitempty"`
+	// The max number of times a batch whose commit tx failed is re-committed before the failure is treated as permanent,
+	// 0 means no limit. Only used when CommitFailedRetryDelaySec is set.
+	MaxCommitFailedRetries uint64 `json:"max_commit_failed_retries,omitempty"`
+	// The number of times the earliest committed batch is skipped by finalization before an alert is raised, 0 means never.
+	FinalizeSkipAlertThreshold uint64 `json:"finalize_skip_alert_threshold,omitempty"`
+	// The max number of in-flight commit and finalize txs in total, 0 means no limit.
+	MaxInFlightRollupTxs uint64 `json:"max_in_flight_rollup_txs,omitempty"`
+	// The action which gets the last in-flight slot first when MaxInFlightRollupTxs is reached:
+	// "commit" or "finalize", empty means no priority.
+	RollupTxPriority string `json:"rollup_tx_priority,omitempty"`
+	// Indicates if the configured contract addresses are checked to have code on-chain at startup.
+	CheckContractCode bool `json:"check_contract_code,omitempty"`
+	// Indicates if the l1 gas used and fee paid by the confirmed commit and finalize txs are stored against the batch.
+	RecordL1Cost bool `json:"record_l1_cost,omitempty"`
+	// Indicates if the parent batch hash of a batch is checked against the committed parent batch before committing it.
+	VerifyParentBatchHash bool `json:"verify_parent_batch_hash,omitempty"`
+	// The fee estimator consulted for the gas price of commit and finalize txs, nil means the default gas policy.
+	RollupFeeEstimator *FeeEstimatorConfig `json:"rollup_fee_estimator,omitempty"`
+	// The number of commit or finalize txs failing on-chain in a row which halts sending the txs of that action,
+	// 0 means never halt.
+	CircuitBreakerThreshold uint64 `json:"circuit_breaker_threshold,omitempty"`
+	// The cooldown in seconds after which a halted action is resumed, 0 means it's only resumed by a manual reset.
+	CircuitBreakerCooldownSec uint64 `json:"circuit_breaker_cooldown_sec,omitempty"`
+	// The max age in seconds of the latest layer2 block, committing and gas oracle updates are paused while
+	// the layer2 head is older, 0 means never pause.
+	MaxL2HeadAgeSec uint64 `json:"max_l2_head_age_sec,omitempty"`
+	// Indicates if the public input hash of a batch proof is checked against the roots of the batch before finalizing it.
+	VerifyProofPublicInputs bool `json:"verify_proof_public_inputs,omitempty"`
+	// The order committed batches are selected for finalization, only "oldest" is supported, empty means "oldest".
+	// See FinalizeBatchOrderOldest and FinalizeBatchOrderNewest for the ordering constraints of each mode.
+	FinalizeBatchOrder string `json:"finalize_batch_order,omitempty"`
+	// Indicates if a private key configured for several sender roles is only warned about rather than rejected,
+	// the senders sharing an account send txs of colliding nonces.
+	AllowSharedSenderKeys bool `json:"allow_shared_sender_keys,omitempty"`
+	// The layer1 endpoints the confirmed txs are cross-checked against before their terminal status is applied.
+	ConfirmationQuorumEndpoints []string `json:"confirmation_quorum_endpoints,omitempty"`
+	// The number of ConfirmationQuorumEndpoints whose receipt must agree with a confirmation, a confirmation without
+	// the quorum is treated as not confirmed yet and re-checked. 0 means no quorum is required.
+	ConfirmationQuorum int `json:"confirmation_quorum,omitempty"`
+	// Indicates if the batch selected for finalization is refused when its index isn't greater than the one of the
+	// latest finalized batch, which means the db is inconsistent.
+	CheckFinalizeIndexRegression bool `json:"check_finalize_index_regression,omitempty"`
+	// Indicates if a confirmation of an unknown sender type is recovered from the sent transaction of its tx hash,
+	// the sender type and the context id are taken from the pending_transaction table to update the status.
+	RecoverUnknownConfirmations bool `json:"recover_unknown_confirmations,omitempty"`
+	// The max number of times the db update of a confirmation is retried with backoff, an update still failing
+	// after that is kept and retried by the confirmation loop, since the sender won't deliver the confirmation again.
+	ConfirmationDBMaxRetry uint64 `json:"confirmation_db_max_retry,omitempty"`
+	// Indicates if the relayer runs in dry-run mode for staging, the commit, finalize and gas oracle txs are packed
+	// and logged with their decoded arguments but not sent, and the db status of the batches is left unchanged.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+const (
+	// RollupTxPriorityCommit gives commit txs priority over finalize txs.
+	RollupTxPriorityCommit = "commit"
+	// RollupTxPriorityFinalize gives finalize txs priority over commit txs.
+	RollupTxPriorityFinalize = "finalize"
+
+	// FinalizeBatchOrderOldest finalizes the earliest committed batch first, batches are finalized strictly
+	// in index order, which is what a rollup contract requiring the parent batch to be finalized expects.
+	FinalizeBatchOrderOldest = "oldest"
+	// FinalizeBatchOrderNewest would finalize the latest verified batch first, it's rejected since the finalizeBatch
+	// of the rollup contract requires the state root of the parent batch to be finalized and the batch index to follow
+	// the last finalized one, so the finalize txs of any batch but the earliest committed one revert.
+	FinalizeBatchOrderNewest = "newest"
+)
+
+// FeeEstimatorConfig is the configuration of the fee estimator of the rollup txs.
+type FeeEstimatorConfig struct {
+	// The type of the fee estimator, only "fee_history" is supported.
+	Type string `json:"type"`
+	// The number of recent blocks the fee history is read from, 0 means the default.
+	BlockCount uint64 `json:"block_count,omitempty"`
+	// The reward percentile of the fee history used as the tip, 0 means the default.
+	RewardPercentile float64 `json:"reward_percentile,omitempty"`
 }
 
 // GasOracleConfig The config for updating gas price oracle.
 type GasOracleConfig struct {
 	// MinGasPrice store the minimum gas price to set.
 	MinGasPrice uint64 `json:"min_gas_price"`
+	// MaxGasPrice store the maximum gas price to set, a higher suggested gas price is clamped to it, 0 means no limit.
+	MaxGasPrice uint64 `json:"max_gas_price,omitempty"`
 	// GasPriceDiff store the percentage of gas price difference.
 	GasPriceDiff uint64 `json:"gas_price_diff"`
+	// GasPriceDiffPrecision store the precision GasPriceDiff and EmergencyGasPriceDiff are expressed in,
+	// e.g. 1000000 means a diff of 50000 is 5%, 0 means the default 1000000. The diffs can't exceed it.
+	GasPriceDiffPrecision uint64 `json:"gas_price_diff_precision,omitempty"`
+	// MinUpdateIntervalSec store the minimum interval in seconds between two gas price updates, 0 means no limit.
+	MinUpdateIntervalSec uint64 `json:"min_update_interval_sec,omitempty"`
+	// EmergencyGasPriceDiff store the percentage of gas price difference which bypasses MinUpdateIntervalSec, 0 means never bypass.
+	EmergencyGasPriceDiff uint64 `json:"emergency_gas_price_diff,omitempty"`
+	// GasOracleMaxRetry store the number of times a batch whose gas oracle update failed in layer1 is moved back
+	// to pending to be updated again before it's marked as failed, 0 means it's marked as failed right away.
+	GasOracleMaxRetry uint64 `json:"gas_oracle_max_retry,omitempty"`
 }
 
 // relayerConfigAlias RelayerConfig alias name
@@ -85,6 +204,9 @@ func convertAndCheck(key string, uniqueAddressesSet map[string]struct{}) (*ecdsa
 		return nil, err
 	}
 
+	if uniqueAddressesSet == nil {
+		return privKey, nil
+	}
 	addr := crypto.PubkeyToAddress(privKey.PublicKey).Hex()
 	if _, exists := uniqueAddressesSet[addr]; exists {
 		return nil, fmt.Errorf("detected duplicated address for private key: %s", addr)
@@ -101,6 +223,8 @@ func (r *RelayerConfig) UnmarshalJSON(input []byte) error {
 		GasOracleSenderPrivateKey string `json:"gas_oracle_sender_private_key"`
 		CommitSenderPrivateKey    string `json:"commit_sender_private_key"`
 		FinalizeSenderPrivateKey  string `json:"finalize_sender_private_key"`
+
+		ExtraFinalizeSenderPrivateKeys []string `json:"extra_finalize_sender_private_keys,omitempty"`
 	}
 	var err error
 	if err = json.Unmarshal(input, &privateKeysConfig); err != nil {
@@ -109,7 +233,34 @@ func (r *RelayerConfig) UnmarshalJSON(input []byte) error {
 
 	*r = RelayerConfig(privateKeysConfig.relayerConfigAlias)
 
-	uniqueAddressesSet := make(map[string]struct{})
+	switch r.RollupTxPriority {
+	case "", RollupTxPriorityCommit, RollupTxPriorityFinalize:
+	default:
+		return fmt.Errorf("invalid rollup tx priority: %s", r.RollupTxPriority)
+	}
+
+	switch r.FinalizeBatchOrder {
+	case "", FinalizeBatchOrderOldest:
+	case FinalizeBatchOrderNewest:
+		return fmt.Errorf("finalize batch order %s is unsupported by the rollup contract, which only finalizes batches in index order", r.FinalizeBatchOrder)
+	default:
+		return fmt.Errorf("invalid finalize batch order: %s", r.FinalizeBatchOrder)
+	}
+
+	if oracle := r.GasOracleConfig; oracle != nil && oracle.GasPriceDiffPrecision > 0 {
+		if oracle.GasPriceDiff > oracle.GasPriceDiffPrecision {
+			return fmt.Errorf("gas price diff %d exceeds gas price diff precision %d", oracle.GasPriceDiff, oracle.GasPriceDiffPrecision)
+		}
+		if oracle.EmergencyGasPriceDiff > oracle.GasPriceDiffPrecision {
+			return fmt.Errorf("emergency gas price diff %d exceeds gas price diff precision %d", oracle.EmergencyGasPriceDiff, oracle.GasPriceDiffPrecision)
+		}
+	}
+
+	// the shared private keys are warned about by the relayer instead if they're allowed.
+	var uniqueAddressesSet map[string]struct{}
+	if !r.AllowSharedSenderKeys {
+		uniqueAddressesSet = make(map[string]struct{})
+	}
 
 	r.GasOracleSenderPrivateKey, err = convertAndCheck(privateKeysConfig.GasOracleSenderPrivateKey, uniqueAddressesSet)
 	if err != nil {
@@ -126,9 +277,64 @@ func (r *RelayerConfig) UnmarshalJSON(input []byte) error {
 		return fmt.Errorf("error converting and checking finalize sender private key: %w", err)
 	}
 
+	for i, key := range privateKeysConfig.ExtraFinalizeSenderPrivateKeys {
+		privKey, err := convertAndCheck(key, uniqueAddressesSet)
+		if err != nil {
+			return fmt.Errorf("error converting and checking extra finalize sender private key %d: %w", i, err)
+		}
+		if privKey == nil {
+			return fmt.Errorf("extra finalize sender private key %d is empty", i)
+		}
+		r.ExtraFinalizeSenderPrivateKeys = append(r.ExtraFinalizeSenderPrivateKeys, privKey)
+	}
+
 	return nil
 }
 
+// GetCommitSenderConfig returns the sender config of the commit sender.
+func (r *RelayerConfig) GetCommitSenderConfig() *SenderConfig {
+	if r.CommitSenderConfig != nil {
+		return r.CommitSenderConfig
+	}
+	return r.SenderConfig
+}
+
+// GetFinalizeSenderConfig returns the sender config of the finalize senders, including the extra finalize senders.
+func (r *RelayerConfig) GetFinalizeSenderConfig() *SenderConfig {
+	if r.FinalizeSenderConfig != nil {
+		return r.FinalizeSenderConfig
+	}
+	return r.SenderConfig
+}
+
+// GetGasOracleSenderConfig returns the sender config of the gas oracle sender.
+func (r *RelayerConfig) GetGasOracleSenderConfig() *SenderConfig {
+	if r.GasOracleSenderConfig != nil {
+		return r.GasOracleSenderConfig
+	}
+	return r.SenderConfig
+}
+
+// SharedSenderAccounts returns the accounts whose private key is configured for more than one sender role.
+func (r *RelayerConfig) SharedSenderAccounts() []common.Address {
+	privKeys := []*ecdsa.PrivateKey{r.GasOracleSenderPrivateKey, r.CommitSenderPrivateKey, r.FinalizeSenderPrivateKey}
+	privKeys = append(privKeys, r.ExtraFinalizeSenderPrivateKeys...)
+
+	counts := make(map[common.Address]int)
+	var shared []common.Address
+	for _, privKey := range privKeys {
+		if privKey == nil {
+			continue
+		}
+		addr := crypto.PubkeyToAddress(privKey.PublicKey)
+		counts[addr]++
+		if counts[addr] == 2 {
+			shared = append(shared, addr)
+		}
+	}
+	return shared
+}
+
 // MarshalJSON marshal RelayerConfig config, transfer private keys.
 func (r *RelayerConfig) MarshalJSON() ([]byte, error) {
 	privateKeysConfig := struct {
@@ -137,12 +343,17 @@ func (r *RelayerConfig) MarshalJSON() ([]byte, error) {
 		GasOracleSenderPrivateKey string `json:"gas_oracle_sender_private_key"`
 		CommitSenderPrivateKey    string `json:"commit_sender_private_key"`
 		FinalizeSenderPrivateKey  string `json:"finalize_sender_private_key"`
+
+		ExtraFinalizeSenderPrivateKeys []string `json:"extra_finalize_sender_private_keys,omitempty"`
 	}{}
 
 	privateKeysConfig.relayerConfigAlias = relayerConfigAlias(*r)
 	privateKeysConfig.GasOracleSenderPrivateKey = common.Bytes2Hex(crypto.FromECDSA(r.GasOracleSenderPrivateKey))
 	privateKeysConfig.CommitSenderPrivateKey = common.Bytes2Hex(crypto.FromECDSA(r.CommitSenderPrivateKey))
 	privateKeysConfig.FinalizeSenderPrivateKey = common.Bytes2Hex(crypto.FromECDSA(r.FinalizeSenderPrivateKey))
+	for _, privKey := range r.ExtraFinalizeSenderPrivateKeys {
+		privateKeysConfig.ExtraFinalizeSenderPrivateKeys = append(privateKeysConfig.ExtraFinalizeSenderPrivateKeys, common.Bytes2Hex(crypto.FromECDSA(privKey)))
+	}
 
 	return json.Marshal(&privateKeysConfig)
 }
@@ -0,0 +1,91 @@
+package relayer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/scroll-tech/go-ethereum/log"
+)
+
+// circuitBreaker halts an action after its txs failed on-chain a number of times in a row,
+// so that the relayer stops spending gas on txs which keep reverting.
+// A tripped breaker is closed again by reset, or after the cooldown if it's set.
+type circuitBreaker struct {
+	mu sync.Mutex
+
+	action    string
+	threshold uint64
+	cooldown  time.Duration
+	trips     prometheus.Counter
+
+	failures  uint64
+	trippedAt time.Time
+}
+
+// newCircuitBreaker creates a circuit breaker of the action, a zero threshold means it never trips
+// and a zero cooldown means it's only closed again by reset.
+func newCircuitBreaker(action string, threshold uint64, cooldown time.Duration, trips prometheus.Counter) *circuitBreaker {
+	return &circuitBreaker{
+		action:    action,
+		threshold: threshold,
+		cooldown:  cooldown,
+		trips:     trips,
+	}
+}
+
+// allow reports whether the txs of the action can be sent.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.trippedAt.IsZero() {
+		return true
+	}
+	if b.cooldown > 0 && time.Since(b.trippedAt) >= b.cooldown {
+		log.Warn("Circuit breaker is closed after the cooldown", "action", b.action, "cooldown", b.cooldown)
+		b.closeLocked()
+		return true
+	}
+	return false
+}
+
+// recordFailure counts a tx of the action which failed on-chain, it trips the breaker once the
+// failures in a row reach the threshold.
+func (b *circuitBreaker) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.threshold == 0 || b.failures < b.threshold || !b.trippedAt.IsZero() {
+		return
+	}
+	b.trippedAt = time.Now()
+	b.trips.Inc()
+	log.Error("Circuit breaker tripped, halting the action until it's reset", "action", b.action,
+		"failures", b.failures, "threshold", b.threshold, "cooldown", b.cooldown)
+}
+
+// recordSuccess counts a tx of the action which succeeded on-chain, it restarts the failures in a row.
+func (b *circuitBreaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+}
+
+// reset closes the breaker.
+func (b *circuitBreaker) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.trippedAt.IsZero() {
+		log.Info("Circuit breaker is reset", "action", b.action)
+	}
+	b.closeLocked()
+}
+
+func (b *circuitBreaker) closeLocked() {
+	b.failures = 0
+	b.trippedAt = time.Time{}
+}
@@ -0,0 +1,51 @@
+package relayer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	trips := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_circuit_breaker_tripped_total"})
+	breaker := newCircuitBreaker("commit", 3, 0, trips)
+
+	// a success in between restarts the failures in a row.
+	breaker.recordFailure()
+	breaker.recordFailure()
+	breaker.recordSuccess()
+	breaker.recordFailure()
+	breaker.recordFailure()
+	assert.True(t, breaker.allow())
+
+	// the breaker trips on the threshold and stays tripped until it's reset.
+	breaker.recordFailure()
+	assert.False(t, breaker.allow())
+	breaker.recordFailure()
+	breaker.recordSuccess()
+	assert.False(t, breaker.allow())
+	assert.Equal(t, float64(1), testutil.ToFloat64(trips))
+
+	breaker.reset()
+	assert.True(t, breaker.allow())
+	breaker.recordFailure()
+	assert.True(t, breaker.allow())
+
+	// the breaker is closed after the cooldown.
+	breaker = newCircuitBreaker("finalize", 1, time.Minute, trips)
+	breaker.recordFailure()
+	assert.False(t, breaker.allow())
+	breaker.trippedAt = time.Now().Add(-time.Minute)
+	assert.True(t, breaker.allow())
+	assert.Equal(t, float64(2), testutil.ToFloat64(trips))
+
+	// the breaker never trips without a threshold.
+	breaker = newCircuitBreaker("commit", 0, 0, trips)
+	for i := 0; i < 10; i++ {
+		breaker.recordFailure()
+	}
+	assert.True(t, breaker.allow())
+}
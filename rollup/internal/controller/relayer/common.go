@@ -1,11 +1,28 @@
 package relayer
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+
+	"scroll-tech/rollup/internal/controller/sender"
+)
 
 const (
-	gasPriceDiffPrecision = 1000000
+	defaultGasPriceDiffPrecision = 1000000
 
 	defaultGasPriceDiff = 50000 // 5%
+
+	// the backoff before the first retry of a failed confirmation db update, doubled on each retry.
+	confirmationDBRetryBackoff = 100 * time.Millisecond
+	// the interval the confirmation loop retries the confirmation db updates still failing, and re-checks
+	// the confirmations not verified yet.
+	failedConfirmationRetryInterval = 10 * time.Second
+	// the confirmation loop wakes up at least every failedConfirmationRetryInterval, it's considered stalled
+	// by HealthCheck without a heartbeat for a few intervals.
+	confirmLoopHeartbeatTimeout = 3 * failedConfirmationRetryInterval
 )
 
 var (
@@ -13,6 +30,12 @@ var (
 	ErrExecutionRevertedMessageExpired = errors.New("execution reverted: Message expired")
 	// ErrExecutionRevertedAlreadySuccessExecuted error of Message was already successfully executed
 	ErrExecutionRevertedAlreadySuccessExecuted = errors.New("execution reverted: Message was already successfully executed")
+
+	// errParentBatchNotFinalized error of the parent batch of the batch to finalize is not finalized
+	errParentBatchNotFinalized = errors.New("parent batch is not finalized")
+
+	// errConfirmationMismatch error of the re-fetched receipt of a confirmed tx disagrees with the confirmation
+	errConfirmationMismatch = errors.New("receipt disagrees with confirmation")
 )
 
 // ServiceType defines the various types of services within the relayer.
@@ -28,3 +51,15 @@ const (
 	// ServiceTypeL2GasOracle indicates the service is a Layer 2 gas oracle.
 	ServiceTypeL2GasOracle
 )
+
+// checkContractCode ensures the configured contract address has code deployed on the chain the sender sends txs to.
+func checkContractCode(s *sender.Sender, name string, addr common.Address) error {
+	isContract, err := s.IsContract(addr)
+	if err != nil {
+		return fmt.Errorf("failed to get code of %s contract %s, err: %w", name, addr.Hex(), err)
+	}
+	if !isContract {
+		return fmt.Errorf("no code at %s contract address %s, check the config", name, addr.Hex())
+	}
+	return nil
+}
@@ -33,9 +33,10 @@ type Layer1Relayer struct {
 	gasOracleSender *sender.Sender
 	l1GasOracleABI  *abi.ABI
 
-	lastGasPrice uint64
-	minGasPrice  uint64
-	gasPriceDiff uint64
+	lastGasPrice          uint64
+	minGasPrice           uint64
+	gasPriceDiff          uint64
+	gasPriceDiffPrecision uint64
 
 	l1BlockOrm *orm.L1Block
 	metrics    *l1RelayerMetrics
@@ -48,7 +49,7 @@ func NewLayer1Relayer(ctx context.Context, db *gorm.DB, cfg *config.RelayerConfi
 
 	switch serviceType {
 	case ServiceTypeL1GasOracle:
-		gasOracleSender, err = sender.NewSender(ctx, cfg.SenderConfig, cfg.GasOracleSenderPrivateKey, "l1_relayer", "gas_oracle_sender", types.SenderTypeL1GasOracle, db, reg)
+		gasOracleSender, err = sender.NewSender(ctx, cfg.GetGasOracleSenderConfig(), cfg.GasOracleSenderPrivateKey, "l1_relayer", "gas_oracle_sender", types.SenderTypeL1GasOracle, db, reg)
 		if err != nil {
 			addr := crypto.PubkeyToAddress(cfg.GasOracleSenderPrivateKey.PublicKey)
 			return nil, fmt.Errorf("new gas oracle sender failed for address %s, err: %v", addr.Hex(), err)
@@ -58,15 +59,25 @@ func NewLayer1Relayer(ctx context.Context, db *gorm.DB, cfg *config.RelayerConfi
 		if gasOracleSender.GetChainID().Cmp(big.NewInt(534352)) == 0 && cfg.EnableTestEnvBypassFeatures {
 			return nil, fmt.Errorf("cannot enable test env features in mainnet")
 		}
+
+		if cfg.CheckContractCode {
+			if err = checkContractCode(gasOracleSender, "l1 gas price oracle", cfg.GasPriceOracleContractAddress); err != nil {
+				return nil, err
+			}
+		}
 	default:
 		return nil, fmt.Errorf("invalid service type for l1_relayer: %v", serviceType)
 	}
 
 	var minGasPrice uint64
 	var gasPriceDiff uint64
+	gasPriceDiffPrecision := uint64(defaultGasPriceDiffPrecision)
 	if cfg.GasOracleConfig != nil {
 		minGasPrice = cfg.GasOracleConfig.MinGasPrice
 		gasPriceDiff = cfg.GasOracleConfig.GasPriceDiff
+		if cfg.GasOracleConfig.GasPriceDiffPrecision > 0 {
+			gasPriceDiffPrecision = cfg.GasOracleConfig.GasPriceDiffPrecision
+		}
 	} else {
 		minGasPrice = 0
 		gasPriceDiff = defaultGasPriceDiff
@@ -80,8 +91,9 @@ func NewLayer1Relayer(ctx context.Context, db *gorm.DB, cfg *config.RelayerConfi
 		gasOracleSender: gasOracleSender,
 		l1GasOracleABI:  bridgeAbi.L1GasPriceOracleABI,
 
-		minGasPrice:  minGasPrice,
-		gasPriceDiff: gasPriceDiff,
+		minGasPrice:           minGasPrice,
+		gasPriceDiff:          gasPriceDiff,
+		gasPriceDiffPrecision: gasPriceDiffPrecision,
 	}
 
 	l1Relayer.metrics = initL1RelayerMetrics(reg)
@@ -119,12 +131,12 @@ func (r *Layer1Relayer) ProcessGasPriceOracle() {
 	block := blocks[0]
 
 	if types.GasOracleStatus(block.GasOracleStatus) == types.GasOraclePending {
-		expectedDelta := r.lastGasPrice * r.gasPriceDiff / gasPriceDiffPrecision
+		expectedDelta := r.lastGasPrice * r.gasPriceDiff / r.gasPriceDiffPrecision
 		if r.lastGasPrice > 0 && expectedDelta == 0 {
 			expectedDelta = 1
 		}
 		// last is undefine or (block.BaseFee >= minGasPrice && exceed diff)
-		if r.lastGasPrice == 0 || (block.BaseFee >= r.minGasPrice && (block.BaseFee >= r.lastGasPrice+expectedDelta || block.BaseFee <= r.lastGasPrice-expectedDelta)) {
+		if r.lastGasPrice == 0 || (block.BaseFee >= r.minGasPrice && (block.BaseFee >= r.lastGasPrice+expectedDelta || block.BaseFee+expectedDelta <= r.lastGasPrice)) {
 			baseFee := big.NewInt(int64(block.BaseFee))
 			data, err := r.l1GasOracleABI.Pack("setL1BaseFee", baseFee)
 			if err != nil {
@@ -1,23 +1,31 @@
 package relayer
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/accounts/abi"
 	"github.com/scroll-tech/go-ethereum/common"
 	gethTypes "github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/crypto"
 	"github.com/scroll-tech/go-ethereum/ethclient"
 	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/rlp"
 	"gorm.io/gorm"
 
 	"scroll-tech/common/types"
+	"scroll-tech/common/types/message"
 	"scroll-tech/common/utils"
 
 	bridgeAbi "scroll-tech/rollup/abi"
@@ -42,33 +50,114 @@ type Layer2Relayer struct {
 	chunkOrm   *orm.Chunk
 	l2BlockOrm *orm.L2Block
 
+	pendingTransactionOrm *orm.PendingTransaction
+
 	cfg *config.RelayerConfig
 
 	commitSender   *sender.Sender
 	finalizeSender *sender.Sender
 	l1RollupABI    *abi.ABI
 
+	// the additional finalize senders, finalize txs are sent by the finalize senders in turn.
+	extraFinalizeSenders []*sender.Sender
+	finalizeSenderIndex  int
+
 	gasOracleSender *sender.Sender
 	l2GasOracleABI  *abi.ABI
 
-	lastGasPrice uint64
-	minGasPrice  uint64
-	gasPriceDiff uint64
+	lastGasPrice          uint64
+	minGasPrice           uint64
+	maxGasPrice           uint64
+	gasPriceDiff          uint64
+	gasPriceDiffPrecision uint64
+
+	lastGasPriceUpdateTime    time.Time
+	minGasPriceUpdateInterval time.Duration
+	emergencyGasPriceDiff     uint64
+
+	// The number of times a batch whose gas oracle update failed has been moved back to GasOraclePending,
+	// only accessed by the confirmation loop.
+	gasOracleFailedRetries map[string]uint64
+	gasOracleMaxRetry      uint64
+	// set once a failed gas oracle update is retried, the next update is sent regardless of lastGasPrice,
+	// which never made it on-chain.
+	gasOracleRetrying atomic.Bool
 
 	// Used to get batch status from chain_monitor api.
 	chainMonitorClient *resty.Client
 
+	// Used to publish the proofs of finalized batches, nothing is published if it's nil.
+	proofPublisher ProofPublisher
+
+	// The number of times a batch whose commit tx failed has been moved back to RollupPending, only kept in memory.
+	commitFailedRetries map[string]uint64
+	// the number of times the earliest committed batch is skipped by finalization, keyed by the batch hash.
+	finalizeSkips map[string]uint64
+
+	// halt committing or finalizing after the txs of the action failed on-chain repeatedly.
+	commitBreaker   *circuitBreaker
+	finalizeBreaker *circuitBreaker
+
+	// whether the layer2 head was older than MaxL2HeadAgeSec on the last check.
+	l2HeadStale bool
+
+	// the layer2 chain id, fetched once it's needed to check the public inputs of proofs.
+	l2ChainID uint64
+
+	// the layer1 clients the confirmations are cross-checked against, see ConfirmationQuorum.
+	quorumL1Clients []*ethclient.Client
+
+	// the time the txs waiting for confirmation were sent, keyed by sentTxKey, used to log the confirmation latency.
+	txSentTimes sync.Map
+
+	// the confirmations whose db update failed, keyed by sentTxKey, retried by the confirmation loop.
+	// Only accessed by the confirmation loop.
+	failedConfirmations map[sentTxKey]*failedConfirmation
+
+	// the confirmations not verified yet, keyed by sentTxKey, re-checked by the confirmation loop.
+	// Only accessed by the confirmation loop.
+	uncheckedConfirmations map[sentTxKey]*sender.Confirmation
+
+	// the unix time in nanoseconds the confirmation loop was last seen running, 0 once it exits.
+	confirmLoopHeartbeat atomic.Int64
+
+	// stop the confirmation loop, which is waited for by Stop.
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+
 	metrics *l2RelayerMetrics
 }
 
 // NewLayer2Relayer will return a new instance of Layer2RelayerClient
 func NewLayer2Relayer(ctx context.Context, l2Client *ethclient.Client, db *gorm.DB, cfg *config.RelayerConfig, initGenesis bool, serviceType ServiceType, reg prometheus.Registerer) (*Layer2Relayer, error) {
 	var gasOracleSender, commitSender, finalizeSender *sender.Sender
+	var extraFinalizeSenders []*sender.Sender
 	var err error
 
+	// the sender roles are checked regardless of the service type, since the senders of the other service share the accounts too.
+	if shared := cfg.SharedSenderAccounts(); len(shared) > 0 {
+		if !cfg.AllowSharedSenderKeys {
+			return nil, fmt.Errorf("private keys are shared by several sender roles, accounts: %v", shared)
+		}
+		log.Warn("Private keys are shared by several sender roles, the nonces of their txs will collide", "accounts", shared)
+	}
+
+	if cfg.ConfirmationQuorum < 0 || cfg.ConfirmationQuorum > len(cfg.ConfirmationQuorumEndpoints) {
+		return nil, fmt.Errorf("invalid confirmation quorum %d of %d endpoints", cfg.ConfirmationQuorum, len(cfg.ConfirmationQuorumEndpoints))
+	}
+	var quorumL1Clients []*ethclient.Client
+	for _, endpoint := range cfg.ConfirmationQuorumEndpoints {
+		client, err := ethclient.Dial(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial confirmation quorum endpoint %s, err: %w", endpoint, err)
+		}
+		quorumL1Clients = append(quorumL1Clients, client)
+	}
+
 	switch serviceType {
 	case ServiceTypeL2GasOracle:
-		gasOracleSender, err = sender.NewSender(ctx, cfg.SenderConfig, cfg.GasOracleSenderPrivateKey, "l2_relayer", "gas_oracle_sender", types.SenderTypeL2GasOracle, db, reg)
+		gasOracleSender, err = sender.NewSender(ctx, cfg.GetGasOracleSenderConfig(), cfg.GasOracleSenderPrivateKey, "l2_relayer", "gas_oracle_sender", types.SenderTypeL2GasOracle, db, reg)
 		if err != nil {
 			addr := crypto.PubkeyToAddress(cfg.GasOracleSenderPrivateKey.PublicKey)
 			return nil, fmt.Errorf("new gas oracle sender failed for address %s, err: %w", addr.Hex(), err)
@@ -79,33 +168,78 @@ func NewLayer2Relayer(ctx context.Context, l2Client *ethclient.Client, db *gorm.
 			return nil, fmt.Errorf("cannot enable test env features in mainnet")
 		}
 
+		if cfg.CheckContractCode {
+			if err = checkContractCode(gasOracleSender, "l2 gas price oracle", cfg.GasPriceOracleContractAddress); err != nil {
+				return nil, err
+			}
+		}
+
 	case ServiceTypeL2RollupRelayer:
-		commitSender, err = sender.NewSender(ctx, cfg.SenderConfig, cfg.CommitSenderPrivateKey, "l2_relayer", "commit_sender", types.SenderTypeCommitBatch, db, reg)
+		commitSender, err = sender.NewSender(ctx, cfg.GetCommitSenderConfig(), cfg.CommitSenderPrivateKey, "l2_relayer", "commit_sender", types.SenderTypeCommitBatch, db, reg)
 		if err != nil {
 			addr := crypto.PubkeyToAddress(cfg.CommitSenderPrivateKey.PublicKey)
 			return nil, fmt.Errorf("new commit sender failed for address %s, err: %w", addr.Hex(), err)
 		}
 
-		finalizeSender, err = sender.NewSender(ctx, cfg.SenderConfig, cfg.FinalizeSenderPrivateKey, "l2_relayer", "finalize_sender", types.SenderTypeFinalizeBatch, db, reg)
+		finalizeSender, err = sender.NewSender(ctx, cfg.GetFinalizeSenderConfig(), cfg.FinalizeSenderPrivateKey, "l2_relayer", "finalize_sender", types.SenderTypeFinalizeBatch, db, reg)
 		if err != nil {
 			addr := crypto.PubkeyToAddress(cfg.FinalizeSenderPrivateKey.PublicKey)
 			return nil, fmt.Errorf("new finalize sender failed for address %s, err: %w", addr.Hex(), err)
 		}
 
+		for i, privKey := range cfg.ExtraFinalizeSenderPrivateKeys {
+			extraFinalizeSender, err := sender.NewSender(ctx, cfg.GetFinalizeSenderConfig(), privKey, "l2_relayer", fmt.Sprintf("finalize_sender_%d", i+1), types.SenderTypeFinalizeBatch, db, reg)
+			if err != nil {
+				addr := crypto.PubkeyToAddress(privKey.PublicKey)
+				return nil, fmt.Errorf("new extra finalize sender failed for address %s, err: %w", addr.Hex(), err)
+			}
+			extraFinalizeSenders = append(extraFinalizeSenders, extraFinalizeSender)
+		}
+
 		// Ensure test features aren't enabled on the ethereum mainnet.
 		if commitSender.GetChainID().Cmp(big.NewInt(1)) == 0 && cfg.EnableTestEnvBypassFeatures {
 			return nil, fmt.Errorf("cannot enable test env features in mainnet")
 		}
 
+		if cfg.CheckContractCode {
+			if err = checkContractCode(commitSender, "rollup", cfg.RollupContractAddress); err != nil {
+				return nil, err
+			}
+		}
+
+		if cfg.RollupFeeEstimator != nil {
+			feeEstimator, err := sender.NewFeeEstimator(cfg.RollupFeeEstimator, cfg.GetCommitSenderConfig().Endpoint)
+			if err != nil {
+				return nil, fmt.Errorf("new rollup fee estimator failed, err: %w", err)
+			}
+			commitSender.SetFeeEstimator(feeEstimator)
+			finalizeSender.SetFeeEstimator(feeEstimator)
+			for _, extraFinalizeSender := range extraFinalizeSenders {
+				extraFinalizeSender.SetFeeEstimator(feeEstimator)
+			}
+		}
+
 	default:
 		return nil, fmt.Errorf("invalid service type for l2_relayer: %v", serviceType)
 	}
 
 	var minGasPrice uint64
+	var maxGasPrice uint64
 	var gasPriceDiff uint64
+	var minGasPriceUpdateInterval time.Duration
+	var emergencyGasPriceDiff uint64
+	var gasOracleMaxRetry uint64
+	gasPriceDiffPrecision := uint64(defaultGasPriceDiffPrecision)
 	if cfg.GasOracleConfig != nil {
 		minGasPrice = cfg.GasOracleConfig.MinGasPrice
+		maxGasPrice = cfg.GasOracleConfig.MaxGasPrice
 		gasPriceDiff = cfg.GasOracleConfig.GasPriceDiff
+		minGasPriceUpdateInterval = time.Duration(cfg.GasOracleConfig.MinUpdateIntervalSec) * time.Second
+		emergencyGasPriceDiff = cfg.GasOracleConfig.EmergencyGasPriceDiff
+		gasOracleMaxRetry = cfg.GasOracleConfig.GasOracleMaxRetry
+		if cfg.GasOracleConfig.GasPriceDiffPrecision > 0 {
+			gasPriceDiffPrecision = cfg.GasOracleConfig.GasPriceDiffPrecision
+		}
 	} else {
 		minGasPrice = 0
 		gasPriceDiff = defaultGasPriceDiff
@@ -119,17 +253,36 @@ func NewLayer2Relayer(ctx context.Context, l2Client *ethclient.Client, db *gorm.
 		l2BlockOrm: orm.NewL2Block(db),
 		chunkOrm:   orm.NewChunk(db),
 
+		pendingTransactionOrm: orm.NewPendingTransaction(db),
+
 		l2Client: l2Client,
 
-		commitSender:   commitSender,
-		finalizeSender: finalizeSender,
-		l1RollupABI:    bridgeAbi.ScrollChainABI,
+		commitSender:         commitSender,
+		finalizeSender:       finalizeSender,
+		extraFinalizeSenders: extraFinalizeSenders,
+		l1RollupABI:          bridgeAbi.ScrollChainABI,
 
 		gasOracleSender: gasOracleSender,
 		l2GasOracleABI:  bridgeAbi.L2GasPriceOracleABI,
 
-		minGasPrice:  minGasPrice,
-		gasPriceDiff: gasPriceDiff,
+		minGasPrice:           minGasPrice,
+		maxGasPrice:           maxGasPrice,
+		gasPriceDiff:          gasPriceDiff,
+		gasPriceDiffPrecision: gasPriceDiffPrecision,
+
+		minGasPriceUpdateInterval: minGasPriceUpdateInterval,
+		emergencyGasPriceDiff:     emergencyGasPriceDiff,
+
+		gasOracleFailedRetries: make(map[string]uint64),
+		gasOracleMaxRetry:      gasOracleMaxRetry,
+
+		commitFailedRetries: make(map[string]uint64),
+		finalizeSkips:       make(map[string]uint64),
+
+		failedConfirmations:    make(map[sentTxKey]*failedConfirmation),
+		uncheckedConfirmations: make(map[sentTxKey]*sender.Confirmation),
+
+		quorumL1Clients: quorumL1Clients,
 
 		cfg: cfg,
 	}
@@ -142,25 +295,118 @@ func NewLayer2Relayer(ctx context.Context, l2Client *ethclient.Client, db *gorm.
 	}
 
 	// Initialize genesis before we do anything else
+	if initGenesis && cfg.DryRun {
+		return nil, errors.New("cannot import genesis batch in dry run mode")
+	}
 	if initGenesis {
 		if err := layer2Relayer.initializeGenesis(); err != nil {
 			return nil, fmt.Errorf("failed to initialize and commit genesis batch, err: %v", err)
 		}
 	}
 	layer2Relayer.metrics = initL2RelayerMetrics(reg)
+	circuitBreakerCooldown := time.Duration(cfg.CircuitBreakerCooldownSec) * time.Second
+	layer2Relayer.commitBreaker = newCircuitBreaker("commit", cfg.CircuitBreakerThreshold, circuitBreakerCooldown,
+		layer2Relayer.metrics.rollupL2CommitCircuitBreakerTrippedTotal)
+	layer2Relayer.finalizeBreaker = newCircuitBreaker("finalize", cfg.CircuitBreakerThreshold, circuitBreakerCooldown,
+		layer2Relayer.metrics.rollupL2FinalizeCircuitBreakerTrippedTotal)
 
+	var confirmLoop func(ctx context.Context)
 	switch serviceType {
 	case ServiceTypeL2GasOracle:
-		go layer2Relayer.handleL2GasOracleConfirmLoop(ctx)
+		if err := layer2Relayer.recoverImportingGasOracle(); err != nil {
+			return nil, fmt.Errorf("failed to recover gas oracle importing batches, err: %w", err)
+		}
+		confirmLoop = layer2Relayer.handleL2GasOracleConfirmLoop
 	case ServiceTypeL2RollupRelayer:
-		go layer2Relayer.handleL2RollupRelayerConfirmLoop(ctx)
+		if err := layer2Relayer.recoverRollupConfirmations(); err != nil {
+			return nil, fmt.Errorf("failed to recover rollup confirmations, err: %w", err)
+		}
+		confirmLoop = layer2Relayer.handleL2RollupRelayerConfirmLoop
 	default:
 		return nil, fmt.Errorf("invalid service type for l2_relayer: %v", serviceType)
 	}
 
+	loopCtx, cancel := context.WithCancel(ctx)
+	layer2Relayer.cancel = cancel
+	layer2Relayer.wg.Add(1)
+	layer2Relayer.confirmLoopHeartbeat.Store(time.Now().UnixNano())
+	go func() {
+		defer layer2Relayer.wg.Done()
+		defer layer2Relayer.confirmLoopHeartbeat.Store(0)
+		confirmLoop(loopCtx)
+	}()
+
 	return layer2Relayer, nil
 }
 
+// Stop stops the confirmation loop and waits for it to exit, then stops the senders and handles the
+// confirmations they already queued. The transactions confirmed later are still in the pending_transaction
+// table and are confirmed again once the relayer restarts. It's safe to call Stop more than once.
+func (r *Layer2Relayer) Stop() {
+	r.stopOnce.Do(func() {
+		r.cancel()
+		r.wg.Wait()
+
+		for _, s := range r.senders() {
+			s.Stop()
+		}
+		for _, s := range r.senders() {
+			r.flushConfirmations(s)
+		}
+		r.retryFailedConfirmations()
+		r.recheckUncheckedConfirmations()
+		for _, failed := range r.failedConfirmations {
+			log.Error("Confirmation not applied to db before stop", "confirmation", failed.cfm)
+		}
+		for _, cfm := range r.uncheckedConfirmations {
+			log.Error("Confirmation not verified before stop", "confirmation", cfm)
+		}
+		log.Info("l2 relayer stopped")
+	})
+}
+
+// HealthCheck reports whether the relayer is able to make progress, it returns an error naming the
+// dependency that failed: the layer2 node, the db or the confirmation loop.
+func (r *Layer2Relayer) HealthCheck(ctx context.Context) error {
+	if _, err := r.l2Client.BlockNumber(ctx); err != nil {
+		return fmt.Errorf("layer2 node unreachable, err: %w", err)
+	}
+
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get db, err: %w", err)
+	}
+	if err = sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("db unreachable, err: %w", err)
+	}
+
+	heartbeat := r.confirmLoopHeartbeat.Load()
+	if heartbeat == 0 {
+		return errors.New("confirmation loop stopped")
+	}
+	if age := time.Since(time.Unix(0, heartbeat)); age > confirmLoopHeartbeatTimeout {
+		return fmt.Errorf("confirmation loop stalled, last heartbeat %v ago", age)
+	}
+	return nil
+}
+
+// flushConfirmations handles the confirmations queued by the sender without waiting for more.
+func (r *Layer2Relayer) flushConfirmations(s *sender.Sender) {
+	for {
+		select {
+		case cfm := <-s.ConfirmChan():
+			r.handleConfirmation(cfm)
+		default:
+			return
+		}
+	}
+}
+
+// SetProofPublisher sets the publisher used to publish the proofs of finalized batches.
+func (r *Layer2Relayer) SetProofPublisher(proofPublisher ProofPublisher) {
+	r.proofPublisher = proofPublisher
+}
+
 func (r *Layer2Relayer) initializeGenesis() error {
 	if count, err := r.batchOrm.GetBatchCount(r.ctx); err != nil {
 		return fmt.Errorf("failed to get batch count: %v", err)
@@ -280,6 +526,17 @@ func (r *Layer2Relayer) commitGenesisBatch(batchHash string, batchHeader []byte,
 // ProcessGasPriceOracle imports gas price to layer1
 func (r *Layer2Relayer) ProcessGasPriceOracle() {
 	r.metrics.rollupL2RelayerGasPriceOraclerRunTotal.Inc()
+	// no update can be pushed within the min update interval without an emergency diff to bypass it,
+	// skip fetching the latest batch and the suggested gas price.
+	retrying := r.gasOracleRetrying.Load()
+	if r.lastGasPrice > 0 && !retrying && r.emergencyGasPriceDiff == 0 && !r.canUpdateGasPrice(r.lastGasPrice) {
+		log.Debug("Skip l2 gas price oracle within the min update interval",
+			"lastGasPrice", r.lastGasPrice, "lastUpdateTime", r.lastGasPriceUpdateTime, "minUpdateInterval", r.minGasPriceUpdateInterval)
+		return
+	}
+	if r.isL2HeadStale() {
+		return
+	}
 	batch, err := r.batchOrm.GetLatestBatch(r.ctx)
 	if batch == nil || err != nil {
 		log.Error("Failed to GetLatestBatch", "batch", batch, "err", err)
@@ -293,24 +550,49 @@ func (r *Layer2Relayer) ProcessGasPriceOracle() {
 			return
 		}
 		suggestGasPriceUint64 := uint64(suggestGasPrice.Int64())
-		expectedDelta := r.lastGasPrice * r.gasPriceDiff / gasPriceDiffPrecision
+		if r.maxGasPrice > 0 && suggestGasPriceUint64 > r.maxGasPrice {
+			log.Warn("Clamp l2 gas price to the max gas price", "suggestGasPrice", suggestGasPriceUint64, "maxGasPrice", r.maxGasPrice)
+			suggestGasPriceUint64 = r.maxGasPrice
+			suggestGasPrice = new(big.Int).SetUint64(r.maxGasPrice)
+		}
+		// a suggested price below the floor still lowers the l2 base fee down to the min gas price,
+		// instead of leaving it at an elevated last gas price.
+		if suggestGasPriceUint64 < r.minGasPrice {
+			log.Debug("Raise l2 gas price to the min gas price", "suggestGasPrice", suggestGasPriceUint64, "minGasPrice", r.minGasPrice)
+			suggestGasPriceUint64 = r.minGasPrice
+			suggestGasPrice = new(big.Int).SetUint64(r.minGasPrice)
+		}
+		expectedDelta := r.lastGasPrice * r.gasPriceDiff / r.gasPriceDiffPrecision
 		if r.lastGasPrice > 0 && expectedDelta == 0 {
 			expectedDelta = 1
 		}
 
-		// last is undefine or (suggestGasPriceUint64 >= minGasPrice && exceed diff)
-		if r.lastGasPrice == 0 || (suggestGasPriceUint64 >= r.minGasPrice && (suggestGasPriceUint64 >= r.lastGasPrice+expectedDelta || suggestGasPriceUint64 <= r.lastGasPrice-expectedDelta)) {
+		// last is undefine or exceed diff, upward or downward.
+		// a retried update is sent regardless, the last gas price isn't on-chain.
+		if r.lastGasPrice == 0 || retrying || suggestGasPriceUint64 >= r.lastGasPrice+expectedDelta || suggestGasPriceUint64+expectedDelta <= r.lastGasPrice {
+			if r.lastGasPrice > 0 && !retrying && !r.canUpdateGasPrice(suggestGasPriceUint64) {
+				log.Debug("Defer l2 gas price update within the min update interval",
+					"lastGasPrice", r.lastGasPrice, "suggestGasPrice", suggestGasPriceUint64,
+					"lastUpdateTime", r.lastGasPriceUpdateTime, "minUpdateInterval", r.minGasPriceUpdateInterval)
+				return
+			}
+
 			data, err := r.l2GasOracleABI.Pack("setL2BaseFee", suggestGasPrice)
 			if err != nil {
 				log.Error("Failed to pack setL2BaseFee", "batch.Hash", batch.Hash, "GasPrice", suggestGasPrice.Uint64(), "err", err)
 				return
 			}
+			if r.cfg.DryRun {
+				r.logDryRunTx(r.l2GasOracleABI, types.SenderTypeL2GasOracle, batch.Hash, r.cfg.GasPriceOracleContractAddress, data)
+				return
+			}
 
 			hash, err := r.gasOracleSender.SendTransaction(batch.Hash, &r.cfg.GasPriceOracleContractAddress, big.NewInt(0), data, 0)
 			if err != nil {
 				log.Error("Failed to send setL2BaseFee tx to layer2 ", "batch.Hash", batch.Hash, "err", err)
 				return
 			}
+			r.recordTxSent(types.SenderTypeL2GasOracle, batch.Hash)
 
 			err = r.batchOrm.UpdateL2GasOracleStatusAndOracleTxHash(r.ctx, batch.Hash, types.GasOracleImporting, hash.String())
 			if err != nil {
@@ -318,28 +600,191 @@ func (r *Layer2Relayer) ProcessGasPriceOracle() {
 				return
 			}
 			r.lastGasPrice = suggestGasPriceUint64
+			r.lastGasPriceUpdateTime = time.Now()
+			r.gasOracleRetrying.Store(false)
 			r.metrics.rollupL2RelayerLastGasPrice.Set(float64(r.lastGasPrice))
 			log.Info("Update l2 gas price", "txHash", hash.String(), "GasPrice", suggestGasPrice)
 		}
 	}
 }
 
+// recoverRollupConfirmations handles the confirmations lost by a restart between the sender marking the commit or
+// finalize tx of a batch as confirmed and the batch being updated, which would leave the batch in RollupCommitting
+// or RollupFinalizing forever. The confirmation is rebuilt from the confirmed tx in the pending_transaction table
+// and its receipt, the batches whose txs are still in flight are left to the sender.
+func (r *Layer2Relayer) recoverRollupConfirmations() error {
+	for _, item := range []struct {
+		rollupStatus types.RollupStatus
+		senderType   types.SenderType
+	}{
+		{types.RollupCommitting, types.SenderTypeCommitBatch},
+		{types.RollupFinalizing, types.SenderTypeFinalizeBatch},
+	} {
+		batches, err := r.batchOrm.GetBatches(r.ctx, map[string]interface{}{"rollup_status": int(item.rollupStatus)}, nil, 0)
+		if err != nil {
+			return fmt.Errorf("failed to get %v batches, err: %w", item.rollupStatus, err)
+		}
+
+		for _, batch := range batches {
+			txs, err := r.pendingTransactionOrm.GetTransactionsByContextID(r.ctx, item.senderType, batch.Hash)
+			if err != nil {
+				return err
+			}
+
+			var confirmedTx *orm.PendingTransaction
+			inFlight := false
+			for i, tx := range txs {
+				switch tx.Status {
+				case types.TxStatusPending, types.TxStatusReplaced:
+					inFlight = true
+				case types.TxStatusConfirmed:
+					confirmedTx = &txs[i]
+				}
+			}
+			if inFlight || confirmedTx == nil {
+				continue
+			}
+
+			txHash := common.HexToHash(confirmedTx.Hash)
+			receipt, err := r.commitSender.GetTransactionReceipt(txHash)
+			if errors.Is(err, ethereum.NotFound) {
+				log.Warn("Receipt of confirmed rollup tx not found, skip recovering its confirmation", "batch hash", batch.Hash, "tx hash", confirmedTx.Hash, "type", item.senderType)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			log.Info("Recovered lost confirmation of batch", "batch hash", batch.Hash, "tx hash", confirmedTx.Hash, "type", item.senderType, "status", receipt.Status)
+			r.handleConfirmation(&sender.Confirmation{
+				ContextID:    batch.Hash,
+				IsSuccessful: receipt.Status == gethTypes.ReceiptStatusSuccessful,
+				TxHash:       txHash,
+				SenderType:   item.senderType,
+				Sender:       common.HexToAddress(confirmedTx.SenderAddress),
+				Receipt:      receipt,
+			})
+		}
+	}
+	return nil
+}
+
+// recoverImportingGasOracle resolves the batches left in GasOracleImporting by a crash between sending the
+// setL2BaseFee tx and handling its confirmation, the gas price oracle only acts on GasOraclePending batches.
+// A batch whose tx is still tracked by the sender is left to its confirmation, otherwise the batch is advanced
+// by the on-chain receipt of its txs, or moved back to GasOraclePending if none of them is found.
+func (r *Layer2Relayer) recoverImportingGasOracle() error {
+	batches, err := r.batchOrm.GetBatches(r.ctx, map[string]interface{}{"oracle_status": int(types.GasOracleImporting)}, nil, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get gas oracle importing batches, err: %w", err)
+	}
+
+	for _, batch := range batches {
+		txs, err := r.pendingTransactionOrm.GetTransactionsByContextID(r.ctx, types.SenderTypeL2GasOracle, batch.Hash)
+		if err != nil {
+			return err
+		}
+
+		candidates := []string{batch.OracleTxHash}
+		inFlight := false
+		for _, tx := range txs {
+			switch tx.Status {
+			case types.TxStatusPending, types.TxStatusReplaced:
+				inFlight = true
+			case types.TxStatusConfirmed:
+				// the confirmed replacement is checked first.
+				candidates = append([]string{tx.Hash}, candidates...)
+			}
+		}
+		if inFlight {
+			log.Info("Gas oracle tx of importing batch still in flight, waiting for its confirmation", "batch hash", batch.Hash, "oracle tx hash", batch.OracleTxHash)
+			continue
+		}
+
+		var (
+			receipt         *gethTypes.Receipt
+			confirmedTxHash string
+		)
+		for _, txHash := range candidates {
+			if txHash == "" {
+				continue
+			}
+			receipt, err = r.gasOracleSender.GetTransactionReceipt(common.HexToHash(txHash))
+			if errors.Is(err, ethereum.NotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			confirmedTxHash = txHash
+			break
+		}
+
+		if receipt == nil {
+			log.Warn("Gas oracle tx of importing batch lost, re-pending the batch", "batch hash", batch.Hash, "oracle tx hash", batch.OracleTxHash)
+			if err := r.batchOrm.UpdateL2GasOracleStatusAndOracleTxHash(r.ctx, batch.Hash, types.GasOraclePending, ""); err != nil {
+				return err
+			}
+			continue
+		}
+
+		status := types.GasOracleImported
+		if receipt.Status != gethTypes.ReceiptStatusSuccessful {
+			status = types.GasOracleImportedFailed
+		}
+		log.Info("Recovered gas oracle status of importing batch", "batch hash", batch.Hash, "oracle tx hash", confirmedTxHash, "status", status)
+		if err := r.batchOrm.UpdateL2GasOracleStatusAndOracleTxHash(r.ctx, batch.Hash, status, confirmedTxHash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canUpdateGasPrice checks whether the gas price can be updated given the min update interval,
+// a gas price exceeding the emergency diff is always allowed.
+func (r *Layer2Relayer) canUpdateGasPrice(gasPrice uint64) bool {
+	if r.minGasPriceUpdateInterval == 0 || time.Since(r.lastGasPriceUpdateTime) >= r.minGasPriceUpdateInterval {
+		return true
+	}
+	if r.emergencyGasPriceDiff == 0 {
+		return false
+	}
+	emergencyDelta := r.lastGasPrice * r.emergencyGasPriceDiff / r.gasPriceDiffPrecision
+	return gasPrice >= r.lastGasPrice+emergencyDelta || gasPrice+emergencyDelta <= r.lastGasPrice
+}
+
 // ProcessPendingBatches processes the pending batches by sending commitBatch transactions to layer 1.
 func (r *Layer2Relayer) ProcessPendingBatches() {
+	// the heartbeat tells an idle relayer from a stuck one, it's updated on each cycle whether there are batches or not.
+	r.metrics.rollupL2RelayerCommitHeartbeatTimestamp.SetToCurrentTime()
+	if !r.commitBreaker.allow() {
+		log.Debug("Committing is halted by the circuit breaker")
+		return
+	}
+	if r.isL2HeadStale() {
+		return
+	}
+
 	// get pending batches from database in ascending order by their index.
 	batches, err := r.batchOrm.GetFailedAndPendingBatches(r.ctx, 5)
 	if err != nil {
 		log.Error("Failed to fetch pending L2 batches", "err", err)
 		return
 	}
+	r.metrics.rollupL2RelayerCommitHeartbeatPendingBatches.Set(float64(len(batches)))
+	log.Debug("Commit cycle heartbeat", "pending batches", len(batches))
 	for _, batch := range batches {
-		r.metrics.rollupL2RelayerProcessPendingBatchTotal.Inc()
-		// get current header and parent header.
-		currentBatchHeader, err := types.DecodeBatchHeader(batch.BatchHeader)
-		if err != nil {
-			log.Error("Failed to decode batch header", "index", batch.Index, "error", err)
+		if !r.hasRollupTxCapacity(types.SenderTypeCommitBatch) {
 			return
 		}
+		if types.RollupStatus(batch.RollupStatus) == types.RollupCommitFailed && r.cfg.CommitFailedRetryDelaySec > 0 {
+			// later batches can't be committed before this one, so stop here if it's not ready to be re-committed.
+			if !r.retryCommitFailedBatch(batch) {
+				return
+			}
+		}
+		r.metrics.rollupL2RelayerProcessPendingBatchTotal.Inc()
+		// get parent header.
 		parentBatch := &orm.Batch{}
 		if batch.Index > 0 {
 			parentBatch, err = r.batchOrm.GetBatchByIndex(r.ctx, batch.Index-1)
@@ -366,6 +811,7 @@ func (r *Layer2Relayer) ProcessPendingBatches() {
 			return
 		}
 
+		chunks := make([]*types.Chunk, len(dbChunks))
 		encodedChunks := make([][]byte, len(dbChunks))
 		for i, c := range dbChunks {
 			var wrappedBlocks []*types.WrappedBlock
@@ -385,9 +831,24 @@ func (r *Layer2Relayer) ProcessPendingBatches() {
 				log.Error("Failed to encode chunk", "error", err)
 				return
 			}
+			chunks[i] = chunk
 			encodedChunks[i] = chunkBytes
 		}
 
+		// get current header, re-derived from the blocks if the stored one is missing or inconsistent.
+		currentBatchHeader, err := r.getCommittableBatchHeader(batch, parentBatch, chunks)
+		if err != nil {
+			log.Error("Failed to get committable batch header", "index", batch.Index, "hash", batch.Hash, "error", err)
+			return
+		}
+
+		if r.cfg.VerifyParentBatchHash {
+			if err = r.checkParentBatchHash(batch, parentBatch, currentBatchHeader); err != nil {
+				log.Error("Batch doesn't chain onto the committed parent batch, halting further committing", "index", batch.Index, "hash", batch.Hash, "error", err)
+				return
+			}
+		}
+
 		calldata, err := r.l1RollupABI.Pack("commitBatch", currentBatchHeader.Version(), parentBatch.BatchHeader, encodedChunks, currentBatchHeader.SkippedL1MessageBitmap())
 		if err != nil {
 			log.Error("Failed to pack commitBatch", "index", batch.Index, "error", err)
@@ -401,6 +862,10 @@ func (r *Layer2Relayer) ProcessPendingBatches() {
 			fallbackGasLimit = 0
 			log.Warn("Batch commit previously failed, using eth_estimateGas for the re-submission", "hash", batch.Hash)
 		}
+		if r.cfg.DryRun {
+			r.logDryRunTx(r.l1RollupABI, types.SenderTypeCommitBatch, batch.Hash, r.cfg.RollupContractAddress, calldata)
+			continue
+		}
 		txHash, err := r.commitSender.SendTransaction(batch.Hash, &r.cfg.RollupContractAddress, big.NewInt(0), calldata, fallbackGasLimit)
 		if err != nil {
 			log.Error(
@@ -420,6 +885,7 @@ func (r *Layer2Relayer) ProcessPendingBatches() {
 			)
 			return
 		}
+		r.recordTxSent(types.SenderTypeCommitBatch, batch.Hash)
 
 		err = r.batchOrm.UpdateCommitTxHashAndRollupStatus(r.ctx, batch.Hash, txHash.String(), types.RollupCommitting)
 		if err != nil {
@@ -431,6 +897,135 @@ func (r *Layer2Relayer) ProcessPendingBatches() {
 	}
 }
 
+// getCommittableBatchHeader returns the stored batch header if it's consistent with the batch, otherwise the
+// header is re-derived from the chunks and the parent batch, and it must match the batch hash.
+func (r *Layer2Relayer) getCommittableBatchHeader(batch *orm.Batch, parentBatch *orm.Batch, chunks []*types.Chunk) (*types.BatchHeader, error) {
+	batchHeader, err := types.DecodeBatchHeader(batch.BatchHeader)
+	if err == nil && batchHeader.BatchIndex() == batch.Index && batchHeader.Hash().Hex() == batch.Hash {
+		return batchHeader, nil
+	}
+	log.Warn("Batch header is missing or inconsistent, re-deriving it from the blocks", "index", batch.Index, "hash", batch.Hash, "decode error", err)
+
+	var version uint8
+	var totalL1MessagePoppedBefore uint64
+	var parentBatchHash common.Hash
+	if batch.Index > 0 {
+		parentBatchHeader, err := types.DecodeBatchHeader(parentBatch.BatchHeader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode parent batch header, index: %v, err: %w", parentBatch.Index, err)
+		}
+		version = parentBatchHeader.Version()
+		totalL1MessagePoppedBefore = parentBatchHeader.TotalL1MessagePopped()
+		parentBatchHash = common.HexToHash(parentBatch.Hash)
+	}
+
+	batchHeader, err = types.NewBatchHeader(version, batch.Index, totalL1MessagePoppedBefore, parentBatchHash, chunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-derive batch header, err: %w", err)
+	}
+	if batchHeader.Hash().Hex() != batch.Hash {
+		r.metrics.rollupL2BatchesHeaderRederiveFailedTotal.Inc()
+		return nil, fmt.Errorf("re-derived batch header mismatch, expected hash: %v, derived hash: %v", batch.Hash, batchHeader.Hash().Hex())
+	}
+
+	if err = r.batchOrm.UpdateBatchHeader(r.ctx, batch.Hash, batchHeader.Encode()); err != nil {
+		return nil, fmt.Errorf("failed to store re-derived batch header, err: %w", err)
+	}
+	r.metrics.rollupL2BatchesHeaderRederivedTotal.Inc()
+	log.Info("Re-derived batch header from the blocks", "index", batch.Index, "hash", batch.Hash)
+	return batchHeader, nil
+}
+
+// checkParentBatchHash checks that the batch chains onto its parent, i.e. the parent batch is committed
+// and its hash matches both the parent batch hash of the batch and the one in the batch header.
+func (r *Layer2Relayer) checkParentBatchHash(batch *orm.Batch, parentBatch *orm.Batch, batchHeader *types.BatchHeader) error {
+	if batch.Index == 0 {
+		return nil
+	}
+
+	var err error
+	switch types.RollupStatus(parentBatch.RollupStatus) {
+	case types.RollupUndefined, types.RollupPending, types.RollupCommitFailed:
+		err = fmt.Errorf("parent batch is not committed, parent index: %v, parent rollup status: %v", parentBatch.Index, types.RollupStatus(parentBatch.RollupStatus))
+	default:
+		if batch.ParentBatchHash != parentBatch.Hash {
+			err = fmt.Errorf("parent batch hash mismatch, parent batch hash: %v, committed parent hash: %v", batch.ParentBatchHash, parentBatch.Hash)
+		} else if batchHeader.ParentBatchHash().Hex() != parentBatch.Hash {
+			err = fmt.Errorf("batch header parent hash mismatch, header parent hash: %v, committed parent hash: %v", batchHeader.ParentBatchHash().Hex(), parentBatch.Hash)
+		}
+	}
+	if err != nil {
+		r.metrics.rollupL2BatchesParentHashMismatchTotal.Inc()
+	}
+	return err
+}
+
+// retryCommitFailedBatch moves a batch whose commit tx failed back to RollupPending once the retry delay
+// has passed, it returns whether the batch can be re-committed now.
+func (r *Layer2Relayer) retryCommitFailedBatch(batch *orm.Batch) bool {
+	if time.Since(batch.UpdatedAt) < time.Duration(r.cfg.CommitFailedRetryDelaySec)*time.Second {
+		return false
+	}
+
+	retries := r.commitFailedRetries[batch.Hash]
+	if r.cfg.MaxCommitFailedRetries > 0 && retries >= r.cfg.MaxCommitFailedRetries {
+		log.Error("Batch commit failed permanently, halting further committing",
+			"index", batch.Index, "hash", batch.Hash, "tx hash", batch.CommitTxHash, "retries", retries)
+		return false
+	}
+
+	if err := r.batchOrm.UpdateRollupStatus(r.ctx, batch.Hash, types.RollupPending); err != nil {
+		log.Error("Failed to move commit failed batch back to pending", "index", batch.Index, "hash", batch.Hash, "err", err)
+		return false
+	}
+	r.commitFailedRetries[batch.Hash] = retries + 1
+	r.metrics.rollupL2BatchesCommitFailedRetriedTotal.Inc()
+	log.Warn("Moved commit failed batch back to pending", "index", batch.Index, "hash", batch.Hash, "tx hash", batch.CommitTxHash, "retries", retries+1)
+	return true
+}
+
+// recoverUnknownConfirmation rebuilds the confirmation of an unknown sender type from the sent transaction of its
+// tx hash, it returns nil if the transaction isn't found or wasn't sent by a sender of the relayer.
+func (r *Layer2Relayer) recoverUnknownConfirmation(cfm *sender.Confirmation) *sender.Confirmation {
+	tx, err := r.pendingTransactionOrm.GetTransactionByTxHash(r.ctx, cfm.TxHash)
+	if err != nil {
+		log.Warn("Failed to get the transaction of the unknown confirmation", "confirmation", cfm, "err", err)
+		return nil
+	}
+	if tx == nil {
+		return nil
+	}
+	switch tx.SenderType {
+	case types.SenderTypeCommitBatch, types.SenderTypeFinalizeBatch, types.SenderTypeL2GasOracle:
+	default:
+		return nil
+	}
+
+	recovered := *cfm
+	recovered.SenderType = tx.SenderType
+	recovered.ContextID = tx.ContextID
+	return &recovered
+}
+
+// retryFailedGasOracle reports whether the batch whose gas oracle update failed in layer1 should be moved back
+// to GasOraclePending, which is allowed GasOracleMaxRetry times per batch.
+func (r *Layer2Relayer) retryFailedGasOracle(batchHash string) bool {
+	retries := r.gasOracleFailedRetries[batchHash]
+	if retries >= r.gasOracleMaxRetry {
+		if r.gasOracleMaxRetry > 0 {
+			log.Error("Gas oracle update failed permanently", "hash", batchHash, "retries", retries)
+		}
+		delete(r.gasOracleFailedRetries, batchHash)
+		return false
+	}
+
+	r.gasOracleFailedRetries[batchHash] = retries + 1
+	r.gasOracleRetrying.Store(true)
+	r.metrics.rollupL2UpdateGasOracleRetriedTotal.Inc()
+	log.Warn("Moved failed gas oracle update back to pending", "hash", batchHash, "retries", retries+1)
+	return true
+}
+
 // ProcessCommittedBatches submit proof to layer 1 rollup contract
 func (r *Layer2Relayer) ProcessCommittedBatches() {
 	// retrieves the earliest batch whose rollup status is 'committed'
@@ -452,6 +1047,12 @@ func (r *Layer2Relayer) ProcessCommittedBatches() {
 	r.metrics.rollupL2RelayerProcessCommittedBatchesTotal.Inc()
 
 	batch := batches[0]
+	if r.cfg.CheckFinalizeIndexRegression {
+		if err = r.checkFinalizeIndexRegression(batch); err != nil {
+			log.Error("Refuse to finalize batch", "index", batch.Index, "hash", batch.Hash, "err", err)
+			return
+		}
+	}
 	status := types.ProvingStatus(batch.ProvingStatus)
 	switch status {
 	case types.ProvingTaskUnassigned, types.ProvingTaskAssigned:
@@ -461,17 +1062,43 @@ func (r *Layer2Relayer) ProcessCommittedBatches() {
 		}
 
 		if r.cfg.EnableTestEnvBypassFeatures && utils.NowUTC().Sub(*batch.CommittedAt) > time.Duration(r.cfg.FinalizeBatchWithoutProofTimeoutSec)*time.Second {
+			if !r.finalizeBreaker.allow() || !r.hasRollupTxCapacity(types.SenderTypeFinalizeBatch) {
+				return
+			}
 			if err := r.finalizeBatch(batch, false); err != nil {
 				log.Error("Failed to finalize timeout batch without proof", "index", batch.Index, "hash", batch.Hash, "err", err)
+				return
 			}
+			r.clearFinalizeSkips(batch.Hash)
+			return
 		}
+		r.recordFinalizeSkip(batch, types.FinalizeSkipReasonTimePolicy)
 
 	case types.ProvingTaskVerified:
+		if len(batch.Proof) == 0 {
+			r.recordFinalizeSkip(batch, types.FinalizeSkipReasonUploadFailed)
+			r.handleProofMissingBatch(batch)
+			return
+		}
+
+		if !r.finalizeBreaker.allow() {
+			log.Debug("Finalizing is halted by the circuit breaker", "index", batch.Index, "hash", batch.Hash)
+			return
+		}
+		if !r.hasRollupTxCapacity(types.SenderTypeFinalizeBatch) {
+			return
+		}
+		if !r.finalizeIntervalElapsed() {
+			return
+		}
+
 		log.Info("Start to roll up zk proof", "hash", batch.Hash)
 		r.metrics.rollupL2RelayerProcessCommittedBatchesFinalizedTotal.Inc()
 		if err := r.finalizeBatch(batch, true); err != nil {
 			log.Error("Failed to finalize batch with proof", "index", batch.Index, "hash", batch.Hash, "err", err)
+			return
 		}
+		r.clearFinalizeSkips(batch.Hash)
 
 	case types.ProvingTaskFailed:
 		// We were unable to prove this batch. There are two possibilities:
@@ -490,12 +1117,189 @@ func (r *Layer2Relayer) ProcessCommittedBatches() {
 			"ProvedAt", batch.ProvedAt,
 			"ProofTimeSec", batch.ProofTimeSec,
 		)
+		r.recordFinalizeSkip(batch, types.FinalizeSkipReasonProofFailed)
 
 	default:
 		log.Error("encounter unreachable case in ProcessCommittedBatches", "proving status", status)
 	}
 }
 
+// finalizeIntervalElapsed checks FinalizeBatchIntervalSec has elapsed since the latest finalized batch was finalized
+// in layer1, rather than since it was created, a backlog of batches is finalized as soon as the interval allows.
+// The next batch is finalized right away if no batch is finalized yet.
+func (r *Layer2Relayer) finalizeIntervalElapsed() bool {
+	if r.cfg.FinalizeBatchIntervalSec == 0 {
+		return true
+	}
+	lastFinalized, err := r.batchOrm.GetLatestFinalizedBatch(r.ctx)
+	if err != nil {
+		log.Error("Failed to get the latest finalized batch", "err", err)
+		return false
+	}
+	if lastFinalized == nil || lastFinalized.FinalizedAt == nil {
+		return true
+	}
+
+	interval := time.Duration(r.cfg.FinalizeBatchIntervalSec) * time.Second
+	if elapsed := utils.NowUTC().Sub(*lastFinalized.FinalizedAt); elapsed < interval {
+		log.Debug("Defer finalizing within the finalize interval", "last finalized index", lastFinalized.Index,
+			"last finalized at", lastFinalized.FinalizedAt, "elapsed", elapsed, "interval", interval)
+		return false
+	}
+	return true
+}
+
+// checkFinalizeIndexRegression checks the index of the batch to finalize is greater than the one of the latest
+// finalized batch, finalizing it otherwise would regress the finalized state, which is raised as an alert.
+func (r *Layer2Relayer) checkFinalizeIndexRegression(batch *orm.Batch) error {
+	lastFinalized, err := r.batchOrm.GetLatestFinalizedBatch(r.ctx)
+	if err != nil {
+		return err
+	}
+	if lastFinalized != nil && batch.Index <= lastFinalized.Index {
+		r.metrics.rollupL2FinalizeIndexRegressionTotal.Inc()
+		return fmt.Errorf("batch index regression, batch index: %v, last finalized batch index: %v", batch.Index, lastFinalized.Index)
+	}
+	return nil
+}
+
+// recordFinalizeSkip counts the times the earliest committed batch is skipped by finalization,
+// an alert is raised once the batch has been skipped FinalizeSkipAlertThreshold times.
+// The reason is stored against the batch whenever it changes, and the skip is counted as explicit
+// if the batch is held back by the time policy or as failed if its proving or proof upload failed.
+func (r *Layer2Relayer) recordFinalizeSkip(batch *orm.Batch, reason types.FinalizeSkipReason) {
+	r.updateFinalizeSkipReason(batch, reason)
+
+	skips, ok := r.finalizeSkips[batch.Hash]
+	if !ok {
+		// only the earliest committed batch is tracked, forget the batches before it.
+		r.finalizeSkips = make(map[string]uint64)
+	}
+	skips++
+	r.finalizeSkips[batch.Hash] = skips
+	r.metrics.rollupL2BatchesFinalizeSkippedTotal.Inc()
+	if reason == types.FinalizeSkipReasonTimePolicy {
+		r.metrics.rollupL2BatchesFinalizeSkippedExplicitTotal.Inc()
+	} else {
+		r.metrics.rollupL2BatchesFinalizeSkippedFailedTotal.Inc()
+	}
+	r.metrics.rollupL2BatchFinalizeSkipCount.Set(float64(skips))
+
+	if r.cfg.FinalizeSkipAlertThreshold == 0 || skips < r.cfg.FinalizeSkipAlertThreshold {
+		log.Debug("Batch is skipped by finalization", "index", batch.Index, "hash", batch.Hash, "reason", reason, "skips", skips)
+		return
+	}
+	if skips == r.cfg.FinalizeSkipAlertThreshold {
+		r.metrics.rollupL2BatchesFinalizeSkipAlertTotal.Inc()
+	}
+	log.Error("Batch is repeatedly skipped by finalization", "index", batch.Index, "hash", batch.Hash, "reason", reason,
+		"skips", skips, "threshold", r.cfg.FinalizeSkipAlertThreshold)
+}
+
+// updateFinalizeSkipReason stores the reason why the batch is skipped by finalization if it differs from the stored one.
+func (r *Layer2Relayer) updateFinalizeSkipReason(batch *orm.Batch, reason types.FinalizeSkipReason) {
+	if types.FinalizeSkipReason(batch.FinalizeSkipReason) == reason {
+		return
+	}
+	if err := r.batchOrm.UpdateFinalizeSkipReason(r.ctx, batch.Hash, reason); err != nil {
+		log.Error("UpdateFinalizeSkipReason failed", "index", batch.Index, "hash", batch.Hash, "reason", reason, "err", err)
+		return
+	}
+	batch.FinalizeSkipReason = int16(reason)
+}
+
+// clearFinalizeSkips forgets the skips of a batch once its finalize tx is sent.
+func (r *Layer2Relayer) clearFinalizeSkips(batchHash string) {
+	delete(r.finalizeSkips, batchHash)
+	r.metrics.rollupL2BatchFinalizeSkipCount.Set(0)
+}
+
+// hasRollupTxCapacity checks whether a commit or finalize tx can be sent under MaxInFlightRollupTxs.
+// The last in-flight slot is kept for the prioritized action as long as it has a tx waiting to be sent.
+func (r *Layer2Relayer) hasRollupTxCapacity(senderType types.SenderType) bool {
+	if r.cfg.MaxInFlightRollupTxs == 0 {
+		return true
+	}
+
+	inFlight, err := r.pendingTransactionOrm.GetPendingTransactionCountBySenderTypes(r.ctx, []types.SenderType{types.SenderTypeCommitBatch, types.SenderTypeFinalizeBatch})
+	if err != nil {
+		log.Error("Failed to count in-flight rollup txs", "err", err)
+		return false
+	}
+	if uint64(inFlight) >= r.cfg.MaxInFlightRollupTxs {
+		log.Debug("Max in-flight rollup txs reached", "sender type", senderType, "in-flight", inFlight)
+		return false
+	}
+
+	var prioritized types.SenderType
+	switch r.cfg.RollupTxPriority {
+	case config.RollupTxPriorityCommit:
+		prioritized = types.SenderTypeCommitBatch
+	case config.RollupTxPriorityFinalize:
+		prioritized = types.SenderTypeFinalizeBatch
+	default:
+		return true
+	}
+	if prioritized == senderType || uint64(inFlight)+1 < r.cfg.MaxInFlightRollupTxs {
+		return true
+	}
+
+	waiting, err := r.hasWaitingRollupTx(prioritized)
+	if err != nil {
+		log.Error("Failed to check waiting rollup txs", "sender type", prioritized, "err", err)
+		return false
+	}
+	if waiting {
+		log.Debug("Last in-flight rollup tx slot is kept for the prioritized action", "sender type", senderType, "prioritized", prioritized)
+		return false
+	}
+	return true
+}
+
+// hasWaitingRollupTx checks whether there is a commit or finalize tx ready to be sent.
+func (r *Layer2Relayer) hasWaitingRollupTx(senderType types.SenderType) (bool, error) {
+	switch senderType {
+	case types.SenderTypeCommitBatch:
+		batches, err := r.batchOrm.GetFailedAndPendingBatches(r.ctx, 1)
+		if err != nil {
+			return false, err
+		}
+		return len(batches) > 0, nil
+	case types.SenderTypeFinalizeBatch:
+		fields := map[string]interface{}{
+			"rollup_status": types.RollupCommitted,
+		}
+		batches, err := r.batchOrm.GetBatches(r.ctx, fields, []string{"index ASC"}, 1)
+		if err != nil {
+			return false, err
+		}
+		return len(batches) > 0 && types.ProvingStatus(batches[0].ProvingStatus) == types.ProvingTaskVerified, nil
+	default:
+		return false, fmt.Errorf("unexpected sender type: %v", senderType)
+	}
+}
+
+// handleProofMissingBatch marks the verified batch whose proof is missing as RollupProofMissing
+// once the proof is still missing after ProofMissingTimeoutSec.
+func (r *Layer2Relayer) handleProofMissingBatch(batch *orm.Batch) {
+	verifiedAt := batch.ProvedAt
+	if verifiedAt == nil {
+		verifiedAt = batch.CommittedAt
+	}
+	if r.cfg.ProofMissingTimeoutSec == 0 || verifiedAt == nil ||
+		utils.NowUTC().Sub(*verifiedAt) <= time.Duration(r.cfg.ProofMissingTimeoutSec)*time.Second {
+		log.Warn("batch is verified but its proof is not ready", "index", batch.Index, "hash", batch.Hash)
+		return
+	}
+
+	r.metrics.rollupL2BatchesProofMissingTotal.Inc()
+	log.Error("batch is verified but its proof is missing, mark it as proof missing",
+		"index", batch.Index, "hash", batch.Hash, "verified at", verifiedAt, "timeout sec", r.cfg.ProofMissingTimeoutSec)
+	if err := r.batchOrm.UpdateRollupStatus(r.ctx, batch.Hash, types.RollupProofMissing); err != nil {
+		log.Error("UpdateRollupStatus failed", "index", batch.Index, "hash", batch.Hash, "err", err)
+	}
+}
+
 func (r *Layer2Relayer) finalizeBatch(batch *orm.Batch, withProof bool) error {
 	// Check batch status before send `finalizeBatch` tx.
 	if r.cfg.ChainMonitor.Enabled {
@@ -523,6 +1327,19 @@ func (r *Layer2Relayer) finalizeBatch(batch *orm.Batch, withProof bool) error {
 			return err
 		}
 		parentBatchStateRoot = parentBatch.StateRoot
+
+		// the batches are finalized in index order, a batch whose parent is neither finalized nor being finalized,
+		// e.g. the parent is skipped, is rejected by the contract. With several finalize sender accounts the finalize
+		// txs of different accounts may be mined out of order, so the parent must be finalized already.
+		parentStatus := types.RollupStatus(parentBatch.RollupStatus)
+		parentFinalizingAccepted := len(r.extraFinalizeSenders) == 0 && parentStatus == types.RollupFinalizing
+		if parentStatus != types.RollupFinalized && !parentFinalizingAccepted {
+			r.metrics.rollupL2FinalizeParentNotFinalizedTotal.Inc()
+			log.Warn("Defer finalizing batch until its parent batch is finalized", "index", batch.Index, "hash", batch.Hash,
+				"parent hash", parentBatch.Hash, "parent rollup status", parentStatus)
+			r.updateFinalizeSkipReason(batch, types.FinalizeSkipReasonParentNotFinalized)
+			return fmt.Errorf("%w, parent batch index: %v, rollup status: %v", errParentBatchNotFinalized, parentBatch.Index, parentStatus)
+		}
 	}
 
 	var txCalldata []byte
@@ -534,10 +1351,26 @@ func (r *Layer2Relayer) finalizeBatch(batch *orm.Batch, withProof bool) error {
 		}
 
 		if err = aggProof.SanityCheck(); err != nil {
-			log.Error("agg_proof sanity check fails", "hash", batch.Hash, "error", err)
+			if !errors.Is(err, message.ErrMalformedProof) {
+				log.Error("agg_proof sanity check fails", "hash", batch.Hash, "error", err)
+				return err
+			}
+			// a malformed proof never becomes valid, mark the batch so that it's not retried forever.
+			r.metrics.rollupL2BatchesMalformedProofTotal.Inc()
+			log.Error("agg_proof is malformed, mark batch as proof malformed", "index", batch.Index, "hash", batch.Hash, "err", err)
+			if updateErr := r.batchOrm.UpdateRollupStatus(r.ctx, batch.Hash, types.RollupProofMalformed); updateErr != nil {
+				log.Error("UpdateRollupStatus failed", "index", batch.Index, "hash", batch.Hash, "err", updateErr)
+			}
+			r.updateFinalizeSkipReason(batch, types.FinalizeSkipReasonProofMalformed)
 			return err
 		}
 
+		if r.cfg.VerifyProofPublicInputs {
+			if err = r.checkProofPublicInputs(batch, parentBatchStateRoot, aggProof); err != nil {
+				return err
+			}
+		}
+
 		txCalldata, err = r.l1RollupABI.Pack(
 			"finalizeBatchWithProof",
 			batch.BatchHeader,
@@ -565,8 +1398,30 @@ func (r *Layer2Relayer) finalizeBatch(batch *orm.Batch, withProof bool) error {
 		}
 	}
 
+	finalizeSender := r.nextFinalizeSender()
+	if withProof && r.cfg.SimulateFinalizeTx {
+		if err := finalizeSender.SimulateTransaction(&r.cfg.RollupContractAddress, big.NewInt(0), txCalldata); err != nil {
+			if !strings.Contains(err.Error(), "execution reverted") {
+				log.Error("Failed to simulate finalizeBatchWithProof", "index", batch.Index, "hash", batch.Hash, "err", err)
+				return err
+			}
+			r.metrics.rollupL2BatchesProofRejectedTotal.Inc()
+			log.Error("finalizeBatchWithProof simulation reverted, mark batch as proof rejected", "index", batch.Index, "hash", batch.Hash, "revert reason", err)
+			if updateErr := r.batchOrm.UpdateRollupStatus(r.ctx, batch.Hash, types.RollupProofRejected); updateErr != nil {
+				log.Error("UpdateRollupStatus failed", "index", batch.Index, "hash", batch.Hash, "err", updateErr)
+			}
+			r.updateFinalizeSkipReason(batch, types.FinalizeSkipReasonProofRejected)
+			return err
+		}
+	}
+
+	if r.cfg.DryRun {
+		r.logDryRunTx(r.l1RollupABI, types.SenderTypeFinalizeBatch, batch.Hash, r.cfg.RollupContractAddress, txCalldata)
+		return nil
+	}
+
 	// add suffix `-finalize` to avoid duplication with commit tx in unit tests
-	txHash, err := r.finalizeSender.SendTransaction(batch.Hash, &r.cfg.RollupContractAddress, big.NewInt(0), txCalldata, 0)
+	txHash, err := finalizeSender.SendTransaction(batch.Hash, &r.cfg.RollupContractAddress, big.NewInt(0), txCalldata, 0)
 	finalizeTxHash := &txHash
 	if err != nil {
 		log.Error(
@@ -588,7 +1443,8 @@ func (r *Layer2Relayer) finalizeBatch(batch *orm.Batch, withProof bool) error {
 		)
 		return err
 	}
-	log.Info("finalizeBatch in layer1", "with proof", withProof, "index", batch.Index, "batch hash", batch.Hash, "tx hash", batch.Hash)
+	log.Info("finalizeBatch in layer1", "with proof", withProof, "index", batch.Index, "batch hash", batch.Hash, "tx hash", finalizeTxHash.String(), "sender", finalizeSender.GetAccount())
+	r.recordTxSent(types.SenderTypeFinalizeBatch, batch.Hash)
 
 	// record and sync with db, @todo handle db error
 	if err := r.batchOrm.UpdateFinalizeTxHashAndRollupStatus(r.ctx, batch.Hash, finalizeTxHash.String(), types.RollupFinalizing); err != nil {
@@ -599,6 +1455,44 @@ func (r *Layer2Relayer) finalizeBatch(batch *orm.Batch, withProof bool) error {
 	return nil
 }
 
+// checkProofPublicInputs checks the public input hash in the instances of the proof matches the one of the roots
+// recorded for the batch, catching a proof computed for another batch or stale data. The batch is marked as
+// RollupProofMismatch on a mismatch so that it's not finalized with the proof.
+func (r *Layer2Relayer) checkProofPublicInputs(batch *orm.Batch, parentBatchStateRoot string, aggProof *message.BatchProof) error {
+	if r.l2ChainID == 0 {
+		chainID, err := r.l2Client.ChainID(r.ctx)
+		if err != nil {
+			log.Error("Failed to fetch layer2 chain id", "err", err)
+			return err
+		}
+		r.l2ChainID = chainID.Uint64()
+	}
+
+	batchHeader, err := types.DecodeBatchHeader(batch.BatchHeader)
+	if err != nil {
+		log.Error("Failed to decode batch header", "index", batch.Index, "hash", batch.Hash, "err", err)
+		return err
+	}
+	expected := message.BatchPublicInputHash(r.l2ChainID, common.HexToHash(parentBatchStateRoot), common.HexToHash(batch.StateRoot),
+		common.HexToHash(batch.WithdrawRoot), batchHeader.DataHash())
+
+	actual, err := aggProof.PublicInputHash()
+	if err == nil && actual != expected {
+		err = fmt.Errorf("public input hash mismatch, proof: %v, batch: %v", actual.Hex(), expected.Hex())
+	}
+	if err == nil {
+		return nil
+	}
+
+	r.metrics.rollupL2BatchesProofMismatchTotal.Inc()
+	log.Error("Proof doesn't match the batch roots, mark batch as proof mismatch", "index", batch.Index, "hash", batch.Hash, "err", err)
+	if updateErr := r.batchOrm.UpdateRollupStatus(r.ctx, batch.Hash, types.RollupProofMismatch); updateErr != nil {
+		log.Error("UpdateRollupStatus failed", "index", batch.Index, "hash", batch.Hash, "err", updateErr)
+	}
+	r.updateFinalizeSkipReason(batch, types.FinalizeSkipReasonProofMismatch)
+	return err
+}
+
 // batchStatusResponse the response schema
 type batchStatusResponse struct {
 	ErrCode int    `json:"errcode"`
@@ -646,80 +1540,628 @@ func (r *Layer2Relayer) getBatchStatusByIndex(batch *orm.Batch) (bool, error) {
 }
 
 func (r *Layer2Relayer) handleConfirmation(cfm *sender.Confirmation) {
+	key := sentTxKey{senderType: cfm.SenderType, contextID: cfm.ContextID}
+	if r.cfg.VerifyConfirmationReceipt {
+		if err := r.verifyConfirmationReceipt(cfm); err != nil {
+			if errors.Is(err, errConfirmationMismatch) {
+				r.metrics.rollupL2ConfirmationReceiptMismatchTotal.Inc()
+				log.Error("Re-fetched receipt disagrees with confirmation, kept to re-check", "confirmation", cfm, "err", err)
+			} else {
+				log.Warn("Failed to verify confirmation receipt, kept to re-check", "confirmation", cfm, "err", err)
+			}
+			r.keepUncheckedConfirmation(key, cfm)
+			return
+		}
+	}
+	if r.cfg.ConfirmationQuorum > 0 {
+		if err := r.checkConfirmationQuorum(cfm); err != nil {
+			r.metrics.rollupL2ConfirmationQuorumNotReachedTotal.Inc()
+			log.Warn("Quorum of layer1 nodes doesn't agree with confirmation yet, kept to re-check", "confirmation", cfm, "err", err)
+			r.keepUncheckedConfirmation(key, cfm)
+			return
+		}
+	}
+
+	// superseded by the confirmation of a later tx of the same context.
+	delete(r.uncheckedConfirmations, key)
+	r.metrics.rollupL2ConfirmationUncheckedPending.Set(float64(len(r.uncheckedConfirmations)))
+
 	switch cfm.SenderType {
 	case types.SenderTypeCommitBatch:
+		if cfm.IsCancelled {
+			// the commit tx is replaced by the cancel tx, reset the batch to pending so that it's committed again.
+			log.Info("CommitBatchTxType transaction cancelled in layer1", "confirmation", cfm)
+			err := r.updateConfirmation(cfm, func() error {
+				return r.batchOrm.UpdateCommitTxHashAndRollupStatus(r.ctx, cfm.ContextID, "", types.RollupPending)
+			})
+			if err != nil {
+				log.Warn("UpdateCommitTxHashAndRollupStatus failed, kept for retry", "confirmation", cfm, "err", err)
+			}
+			return
+		}
 		var status types.RollupStatus
 		if cfm.IsSuccessful {
 			status = types.RollupCommitted
 			r.metrics.rollupL2BatchesCommittedConfirmedTotal.Inc()
+			r.commitBreaker.recordSuccess()
 		} else {
 			status = types.RollupCommitFailed
 			r.metrics.rollupL2BatchesCommittedConfirmedFailedTotal.Inc()
+			r.commitBreaker.recordFailure()
 			log.Warn("CommitBatchTxType transaction confirmed but failed in layer1", "confirmation", cfm)
 		}
 
-		err := r.batchOrm.UpdateCommitTxHashAndRollupStatus(r.ctx, cfm.ContextID, cfm.TxHash.String(), status)
+		err := r.updateConfirmation(cfm, func() error {
+			return r.batchOrm.UpdateCommitTxHashAndRollupStatus(r.ctx, cfm.ContextID, cfm.TxHash.String(), status)
+		})
 		if err != nil {
-			log.Warn("UpdateCommitTxHashAndRollupStatus failed", "confirmation", cfm, "err", err)
+			log.Warn("UpdateCommitTxHashAndRollupStatus failed, kept for retry", "confirmation", cfm, "err", err)
+		}
+		if r.cfg.RecordL1Cost {
+			r.recordL1Cost(cfm)
 		}
 	case types.SenderTypeFinalizeBatch:
 		var status types.RollupStatus
-		if cfm.IsSuccessful {
+		if cfm.IsSuccessful && r.cfg.VerifyFinalizeEvent && !r.hasFinalizeBatchEvent(cfm.ContextID, cfm.Receipt) {
+			status = types.RollupFinalizeDiscrepancy
+			r.metrics.rollupL2BatchesFinalizedConfirmedDiscrepancyTotal.Inc()
+			log.Error("FinalizeBatchTxType transaction confirmed but the FinalizeBatch event of the batch is missing", "confirmation", cfm)
+		} else if cfm.IsSuccessful {
 			status = types.RollupFinalized
 			r.metrics.rollupL2BatchesFinalizedConfirmedTotal.Inc()
+			r.finalizeBreaker.recordSuccess()
 		} else {
 			status = types.RollupFinalizeFailed
 			r.metrics.rollupL2BatchesFinalizedConfirmedFailedTotal.Inc()
+			r.finalizeBreaker.recordFailure()
 			log.Warn("FinalizeBatchTxType transaction confirmed but failed in layer1", "confirmation", cfm)
 		}
 
-		err := r.batchOrm.UpdateFinalizeTxHashAndRollupStatus(r.ctx, cfm.ContextID, cfm.TxHash.String(), status)
+		err := r.updateConfirmation(cfm, func() error {
+			return r.batchOrm.UpdateFinalizeTxHashAndRollupStatus(r.ctx, cfm.ContextID, cfm.TxHash.String(), status)
+		})
 		if err != nil {
-			log.Warn("UpdateFinalizeTxHashAndRollupStatus failed", "confirmation", cfm, "err", err)
+			log.Warn("UpdateFinalizeTxHashAndRollupStatus failed, kept for retry", "confirmation", cfm, "err", err)
+		}
+		if r.cfg.RecordL1Cost {
+			r.recordL1Cost(cfm)
+		}
+		if status == types.RollupFinalized && err == nil {
+			r.observeFinalizeLatency(cfm.ContextID)
+		}
+		if status == types.RollupFinalized && r.proofPublisher != nil {
+			go r.publishFinalizedProof(cfm.ContextID, cfm.TxHash.String())
 		}
 	case types.SenderTypeL2GasOracle:
 		batchHash := cfm.ContextID
+		isSuccessful := cfm.IsSuccessful
+		if isSuccessful && r.cfg.VerifyGasOracleValue {
+			if err := r.verifyGasOracleValue(cfm); err != nil {
+				isSuccessful = false
+				r.metrics.rollupL2GasOracleValueMismatchTotal.Inc()
+				log.Error("UpdateGasOracleTxType transaction confirmed but the l2 base fee isn't applied", "confirmation", cfm, "err", err)
+			}
+		}
 		var status types.GasOracleStatus
-		if cfm.IsSuccessful {
+		if isSuccessful {
 			status = types.GasOracleImported
 			r.metrics.rollupL2UpdateGasOracleConfirmedTotal.Inc()
+			delete(r.gasOracleFailedRetries, batchHash)
 		} else {
 			status = types.GasOracleImportedFailed
 			r.metrics.rollupL2UpdateGasOracleConfirmedFailedTotal.Inc()
 			log.Warn("UpdateGasOracleTxType transaction confirmed but failed in layer1", "confirmation", cfm)
+			if r.retryFailedGasOracle(batchHash) {
+				status = types.GasOraclePending
+			}
 		}
 
-		err := r.batchOrm.UpdateL2GasOracleStatusAndOracleTxHash(r.ctx, batchHash, status, cfm.TxHash.String())
+		err := r.updateConfirmation(cfm, func() error {
+			return r.batchOrm.UpdateL2GasOracleStatusAndOracleTxHash(r.ctx, batchHash, status, cfm.TxHash.String())
+		})
 		if err != nil {
-			log.Warn("UpdateL2GasOracleStatusAndOracleTxHash failed", "confirmation", cfm, "err", err)
+			log.Warn("UpdateL2GasOracleStatusAndOracleTxHash failed, kept for retry", "confirmation", cfm, "err", err)
 		}
 	default:
+		r.metrics.rollupL2UnknownConfirmationTotal.Inc()
+		if r.cfg.RecoverUnknownConfirmations {
+			if recovered := r.recoverUnknownConfirmation(cfm); recovered != nil {
+				r.metrics.rollupL2UnknownConfirmationRecoveredTotal.Inc()
+				log.Info("Recovered unknown confirmation", "confirmation", cfm, "type", recovered.SenderType, "context id", recovered.ContextID)
+				r.handleConfirmation(recovered)
+				return
+			}
+		}
 		log.Warn("Unknown transaction type", "confirmation", cfm)
 	}
 
+	if latency, ok := r.confirmationLatency(cfm); ok {
+		log.Info("Transaction confirmed in layer1", "type", cfm.SenderType, "latency", latency, "confirmation", cfm)
+		return
+	}
 	log.Info("Transaction confirmed in layer1", "confirmation", cfm)
 }
 
+// sentTxKey identifies a tx waiting for confirmation, the context id alone is shared by the txs of a batch.
+type sentTxKey struct {
+	senderType types.SenderType
+	contextID  string
+}
+
+// failedConfirmation a confirmation whose db update failed, kept to retry the update.
+type failedConfirmation struct {
+	cfm    *sender.Confirmation
+	update func() error
+}
+
+// updateConfirmation applies the db update of the confirmation, retrying it with backoff up to ConfirmationDBMaxRetry
+// times. An update still failing is kept in failedConfirmations and retried by the confirmation loop.
+func (r *Layer2Relayer) updateConfirmation(cfm *sender.Confirmation, update func() error) error {
+	key := sentTxKey{senderType: cfm.SenderType, contextID: cfm.ContextID}
+	backoff := confirmationDBRetryBackoff
+	err := update()
+	for i := uint64(0); err != nil && i < r.cfg.ConfirmationDBMaxRetry; i++ {
+		log.Warn("Failed to apply confirmation to db, retrying", "confirmation", cfm, "retry", i+1, "backoff", backoff, "err", err)
+		select {
+		case <-r.ctx.Done():
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		err = update()
+	}
+
+	if err != nil {
+		r.metrics.rollupL2ConfirmationDBUpdateFailedTotal.Inc()
+		r.failedConfirmations[key] = &failedConfirmation{cfm: cfm, update: update}
+	} else {
+		// superseded by the confirmation of a later tx of the same context.
+		delete(r.failedConfirmations, key)
+	}
+	r.metrics.rollupL2ConfirmationDBUpdatePending.Set(float64(len(r.failedConfirmations)))
+	return err
+}
+
+// retryFailedConfirmations retries the db updates of the confirmations kept by updateConfirmation.
+func (r *Layer2Relayer) retryFailedConfirmations() {
+	for key, failed := range r.failedConfirmations {
+		if err := failed.update(); err != nil {
+			log.Warn("Failed to apply kept confirmation to db", "confirmation", failed.cfm, "err", err)
+			continue
+		}
+		delete(r.failedConfirmations, key)
+		log.Info("Applied kept confirmation to db", "confirmation", failed.cfm)
+	}
+	r.metrics.rollupL2ConfirmationDBUpdatePending.Set(float64(len(r.failedConfirmations)))
+}
+
+// keepUncheckedConfirmation keeps a confirmation not verified yet, so that it's re-checked by the confirmation
+// loop instead of being dropped, a layer1 node which hasn't caught up yet only delays it.
+func (r *Layer2Relayer) keepUncheckedConfirmation(key sentTxKey, cfm *sender.Confirmation) {
+	r.uncheckedConfirmations[key] = cfm
+	r.metrics.rollupL2ConfirmationUncheckedPending.Set(float64(len(r.uncheckedConfirmations)))
+}
+
+// recheckUncheckedConfirmations handles the confirmations kept by keepUncheckedConfirmation again,
+// those still not verified are kept for the next re-check.
+func (r *Layer2Relayer) recheckUncheckedConfirmations() {
+	unchecked := make([]*sender.Confirmation, 0, len(r.uncheckedConfirmations))
+	for _, cfm := range r.uncheckedConfirmations {
+		unchecked = append(unchecked, cfm)
+	}
+	for _, cfm := range unchecked {
+		r.handleConfirmation(cfm)
+	}
+}
+
+// logDryRunTx logs the tx which would have been sent in dry-run mode, with the arguments decoded by the abi of the
+// called contract, it returns the synthetic hash logged as the tx hash.
+func (r *Layer2Relayer) logDryRunTx(contractABI *abi.ABI, senderType types.SenderType, contextID string, target common.Address, calldata []byte) common.Hash {
+	r.metrics.rollupL2RelayerDryRunTxTotal.Inc()
+	txHash := crypto.Keccak256Hash([]byte(contextID), calldata)
+
+	method, err := contractABI.MethodById(calldata)
+	if err != nil {
+		log.Warn("Dry run, tx not sent, failed to decode calldata", "type", senderType, "context id", contextID, "target", target, "tx hash", txHash, "err", err)
+		return txHash
+	}
+	args, err := method.Inputs.Unpack(calldata[4:])
+	if err != nil {
+		log.Warn("Dry run, tx not sent, failed to decode arguments", "type", senderType, "context id", contextID, "target", target, "method", method.Name, "tx hash", txHash, "err", err)
+		return txHash
+	}
+	log.Info("Dry run, tx not sent", "type", senderType, "context id", contextID, "target", target, "method", method.Name, "args", args, "tx hash", txHash)
+	return txHash
+}
+
+// recordTxSent records the time a tx is sent, the resubmissions of the tx by the sender keep the time of the first one.
+func (r *Layer2Relayer) recordTxSent(senderType types.SenderType, contextID string) {
+	r.txSentTimes.Store(sentTxKey{senderType: senderType, contextID: contextID}, time.Now())
+}
+
+// confirmationLatency returns the time from sending the confirmed tx to its confirmation,
+// false if the tx wasn't sent by this relayer, e.g. it's sent before a restart.
+func (r *Layer2Relayer) confirmationLatency(cfm *sender.Confirmation) (time.Duration, bool) {
+	sentAt, ok := r.txSentTimes.LoadAndDelete(sentTxKey{senderType: cfm.SenderType, contextID: cfm.ContextID})
+	if !ok {
+		return 0, false
+	}
+	return time.Since(sentAt.(time.Time)), true
+}
+
+// observeFinalizeLatency records the time from the commit to the finalization of the batch.
+func (r *Layer2Relayer) observeFinalizeLatency(batchHash string) {
+	batches, err := r.batchOrm.GetBatches(r.ctx, map[string]interface{}{"hash": batchHash}, nil, 1)
+	if err != nil || len(batches) == 0 {
+		log.Warn("Failed to fetch finalized batch for finalize latency", "hash", batchHash, "err", err)
+		return
+	}
+	if batches[0].CommittedAt == nil {
+		return
+	}
+	latency := time.Since(*batches[0].CommittedAt)
+	r.metrics.rollupL2BatchFinalizeLatency.Observe(latency.Seconds())
+	log.Debug("Batch finalized", "index", batches[0].Index, "hash", batchHash, "finalize latency", latency)
+}
+
+// publishFinalizedProof publishes the proof bundle of a finalized batch, it's best-effort and
+// failures are only logged.
+func (r *Layer2Relayer) publishFinalizedProof(batchHash string, finalizeTxHash string) {
+	batches, err := r.batchOrm.GetBatches(r.ctx, map[string]interface{}{"hash": batchHash}, nil, 1)
+	if err != nil || len(batches) == 0 {
+		log.Warn("Failed to fetch finalized batch for publishing proof", "hash", batchHash, "err", err)
+		r.metrics.rollupL2BatchesProofPublishFailedTotal.Inc()
+		return
+	}
+	batch := batches[0]
+
+	proof, err := r.batchOrm.GetVerifiedProofByHash(r.ctx, batchHash)
+	if err != nil {
+		log.Warn("Failed to fetch proof of finalized batch for publishing", "hash", batchHash, "err", err)
+		r.metrics.rollupL2BatchesProofPublishFailedTotal.Inc()
+		return
+	}
+
+	bundle := &FinalizedBatchProof{
+		BatchIndex:      batch.Index,
+		BatchHash:       batch.Hash,
+		ParentBatchHash: batch.ParentBatchHash,
+		StateRoot:       batch.StateRoot,
+		WithdrawRoot:    batch.WithdrawRoot,
+		BatchHeader:     batch.BatchHeader,
+		FinalizeTxHash:  finalizeTxHash,
+		Proof:           proof,
+	}
+	if err = r.proofPublisher.PublishProof(r.ctx, bundle); err != nil {
+		log.Warn("Failed to publish proof of finalized batch", "index", batch.Index, "hash", batchHash, "err", err)
+		r.metrics.rollupL2BatchesProofPublishFailedTotal.Inc()
+		return
+	}
+	r.metrics.rollupL2BatchesProofPublishedTotal.Inc()
+	log.Info("Published proof of finalized batch", "index", batch.Index, "hash", batchHash)
+}
+
+// hasFinalizeBatchEvent checks whether the receipt contains the FinalizeBatch event of the given batch
+// emitted by the rollup contract.
+func (r *Layer2Relayer) hasFinalizeBatchEvent(batchHash string, receipt *gethTypes.Receipt) bool {
+	if receipt == nil {
+		return false
+	}
+	for _, vLog := range receipt.Logs {
+		if vLog.Address != r.cfg.RollupContractAddress || len(vLog.Topics) < 3 {
+			continue
+		}
+		if vLog.Topics[0] == bridgeAbi.L1FinalizeBatchEventSignature && vLog.Topics[2] == common.HexToHash(batchHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// l1Cost returns the gas used and the fee paid in wei by the transaction of the receipt, including the blob fee.
+func l1Cost(receipt *gethTypes.Receipt) (uint64, *big.Int) {
+	fee := new(big.Int)
+	if receipt.EffectiveGasPrice != nil {
+		fee.Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	}
+	if receipt.BlobGasPrice != nil {
+		fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(receipt.BlobGasUsed), receipt.BlobGasPrice))
+	}
+	return receipt.GasUsed, fee
+}
+
+// recordL1Cost stores the l1 gas used and fee paid by the confirmed commit or finalize tx against the batch,
+// a failed tx is recorded too since its fee is paid all the same.
+func (r *Layer2Relayer) recordL1Cost(cfm *sender.Confirmation) {
+	if cfm.Receipt == nil {
+		log.Warn("Skip recording l1 cost of confirmation without receipt", "confirmation", cfm)
+		return
+	}
+	gasUsed, fee := l1Cost(cfm.Receipt)
+
+	var err error
+	if cfm.SenderType == types.SenderTypeCommitBatch {
+		err = r.batchOrm.UpdateCommitL1Cost(r.ctx, cfm.ContextID, gasUsed, fee)
+	} else {
+		err = r.batchOrm.UpdateFinalizeL1Cost(r.ctx, cfm.ContextID, gasUsed, fee)
+	}
+	if err != nil {
+		log.Warn("Failed to record l1 cost", "confirmation", cfm, "gas used", gasUsed, "fee", fee, "err", err)
+		return
+	}
+	log.Info("Recorded l1 cost of batch", "batch hash", cfm.ContextID, "sender type", cfm.SenderType, "gas used", gasUsed, "fee", fee)
+}
+
+// verifyConfirmationReceipt re-fetches the receipt of the confirmed tx from layer1 and
+// cross-checks its status and block inclusion against the confirmation.
+func (r *Layer2Relayer) verifyConfirmationReceipt(cfm *sender.Confirmation) error {
+	var s *sender.Sender
+	switch cfm.SenderType {
+	case types.SenderTypeCommitBatch:
+		s = r.commitSender
+	case types.SenderTypeFinalizeBatch:
+		s = r.finalizeSender
+	case types.SenderTypeL2GasOracle:
+		s = r.gasOracleSender
+	}
+	if s == nil {
+		return fmt.Errorf("no sender of type %s", cfm.SenderType)
+	}
+
+	// a failed or not found re-fetch is retried by the re-check, only a disagreeing receipt is a mismatch.
+	receipt, err := s.GetTransactionReceipt(cfm.TxHash)
+	if err != nil {
+		return fmt.Errorf("failed to re-fetch receipt: %w", err)
+	}
+	if isSuccessful := receipt.Status == gethTypes.ReceiptStatusSuccessful; isSuccessful != cfm.IsSuccessful {
+		return fmt.Errorf("%w, receipt status successful: %v, confirmation successful: %v", errConfirmationMismatch, isSuccessful, cfm.IsSuccessful)
+	}
+	if cfm.Receipt != nil && receipt.BlockHash != cfm.Receipt.BlockHash {
+		return fmt.Errorf("%w, receipt included in block %s, confirmation included in block %s", errConfirmationMismatch, receipt.BlockHash.Hex(), cfm.Receipt.BlockHash.Hex())
+	}
+	return nil
+}
+
+// verifyGasOracleValue reads the l2 base fee stored by the gas price oracle at the block of the confirmed
+// setL2BaseFee tx, and checks it matches the value pushed by the tx. A value that can't be read isn't flagged.
+func (r *Layer2Relayer) verifyGasOracleValue(cfm *sender.Confirmation) error {
+	pendingTx, err := r.pendingTransactionOrm.GetTransactionByTxHash(r.ctx, cfm.TxHash)
+	if err != nil || pendingTx == nil {
+		log.Warn("Failed to get the setL2BaseFee tx to verify", "tx hash", cfm.TxHash.Hex(), "err", err)
+		return nil
+	}
+	tx := new(gethTypes.Transaction)
+	if err = tx.DecodeRLP(rlp.NewStream(bytes.NewReader(pendingTx.RLPEncoding), 0)); err != nil {
+		log.Warn("Failed to decode the setL2BaseFee tx to verify", "tx hash", cfm.TxHash.Hex(), "err", err)
+		return nil
+	}
+	method, err := r.l2GasOracleABI.MethodById(tx.Data())
+	if err != nil || method.Name != "setL2BaseFee" {
+		log.Warn("Unexpected gas oracle tx to verify", "tx hash", cfm.TxHash.Hex(), "err", err)
+		return nil
+	}
+	args, err := method.Inputs.Unpack(tx.Data()[4:])
+	if err != nil || len(args) != 1 {
+		log.Warn("Failed to unpack the setL2BaseFee tx to verify", "tx hash", cfm.TxHash.Hex(), "err", err)
+		return nil
+	}
+	pushed, ok := args[0].(*big.Int)
+	if !ok {
+		log.Warn("Unexpected setL2BaseFee argument", "tx hash", cfm.TxHash.Hex(), "arg", args[0])
+		return nil
+	}
+
+	calldata, err := r.l2GasOracleABI.Pack("l2BaseFee")
+	if err != nil {
+		return fmt.Errorf("failed to pack l2BaseFee: %w", err)
+	}
+	var blockNumber *big.Int
+	if cfm.Receipt != nil {
+		blockNumber = cfm.Receipt.BlockNumber
+	}
+	result, err := r.gasOracleSender.CallContract(&r.cfg.GasPriceOracleContractAddress, calldata, blockNumber)
+	if err != nil {
+		log.Warn("Failed to read the l2 base fee to verify", "tx hash", cfm.TxHash.Hex(), "err", err)
+		return nil
+	}
+	stored := new(big.Int).SetBytes(result)
+	if stored.Cmp(pushed) != 0 {
+		return fmt.Errorf("l2 base fee mismatch, pushed: %v, stored: %v", pushed, stored)
+	}
+	return nil
+}
+
+// checkConfirmationQuorum fetches the receipt of the confirmed tx from the quorum layer1 clients, and checks
+// at least ConfirmationQuorum of them agree with the confirmation on the status and the block inclusion.
+func (r *Layer2Relayer) checkConfirmationQuorum(cfm *sender.Confirmation) error {
+	var agreed int
+	var disagreements []string
+	for i, client := range r.quorumL1Clients {
+		receipt, err := client.TransactionReceipt(r.ctx, cfm.TxHash)
+		switch {
+		case err != nil:
+			disagreements = append(disagreements, fmt.Sprintf("endpoint %d: %v", i, err))
+		case (receipt.Status == gethTypes.ReceiptStatusSuccessful) != cfm.IsSuccessful:
+			disagreements = append(disagreements, fmt.Sprintf("endpoint %d: receipt status %d", i, receipt.Status))
+		case cfm.Receipt != nil && receipt.BlockHash != cfm.Receipt.BlockHash:
+			disagreements = append(disagreements, fmt.Sprintf("endpoint %d: included in block %s", i, receipt.BlockHash.Hex()))
+		default:
+			agreed++
+		}
+		if agreed >= r.cfg.ConfirmationQuorum {
+			return nil
+		}
+	}
+	return fmt.Errorf("%d of %d endpoints agree, quorum: %d, disagreements: %s",
+		agreed, len(r.quorumL1Clients), r.cfg.ConfirmationQuorum, strings.Join(disagreements, "; "))
+}
+
+// nextFinalizeSender picks the finalize senders in turn, so that the finalize txs are spread across
+// the finalize sender accounts and each account keeps its own nonce sequence.
+func (r *Layer2Relayer) nextFinalizeSender() *sender.Sender {
+	if len(r.extraFinalizeSenders) == 0 {
+		return r.finalizeSender
+	}
+	i := r.finalizeSenderIndex % (len(r.extraFinalizeSenders) + 1)
+	r.finalizeSenderIndex = i + 1
+	if i == 0 {
+		return r.finalizeSender
+	}
+	return r.extraFinalizeSenders[i-1]
+}
+
+func (r *Layer2Relayer) senders() []*sender.Sender {
+	var senders []*sender.Sender
+	for _, s := range []*sender.Sender{r.commitSender, r.finalizeSender, r.gasOracleSender} {
+		if s != nil {
+			senders = append(senders, s)
+		}
+	}
+	return append(senders, r.extraFinalizeSenders...)
+}
+
+// isL2HeadStale checks whether the latest layer2 block is older than MaxL2HeadAgeSec, e.g. the sequencer is down,
+// so that the relayer doesn't act on a frozen chain. The layer2 head is treated as stale if it can't be fetched.
+func (r *Layer2Relayer) isL2HeadStale() bool {
+	if r.cfg.MaxL2HeadAgeSec == 0 {
+		return false
+	}
+
+	var age time.Duration
+	header, err := r.l2Client.HeaderByNumber(r.ctx, nil)
+	if err != nil {
+		log.Error("Failed to fetch the latest layer2 block header", "err", err)
+	} else {
+		age = utils.NowUTC().Sub(time.Unix(int64(header.Time), 0))
+	}
+
+	stale := err != nil || age > time.Duration(r.cfg.MaxL2HeadAgeSec)*time.Second
+	if stale && !r.l2HeadStale {
+		r.metrics.rollupL2HeadStaleTotal.Inc()
+		log.Error("Layer2 head is stale, pausing committing and gas oracle updates until the chain resumes",
+			"age", age, "max age sec", r.cfg.MaxL2HeadAgeSec, "err", err)
+	} else if !stale && r.l2HeadStale {
+		log.Info("Layer2 head is fresh again, resuming committing and gas oracle updates", "age", age)
+	}
+	r.l2HeadStale = stale
+	if stale {
+		r.metrics.rollupL2HeadStale.Set(1)
+	} else {
+		r.metrics.rollupL2HeadStale.Set(0)
+	}
+	return stale
+}
+
+// ResetCircuitBreakers resumes committing and finalizing after they were halted by the circuit breakers.
+func (r *Layer2Relayer) ResetCircuitBreakers() {
+	r.commitBreaker.reset()
+	r.finalizeBreaker.reset()
+}
+
+// GetNonceInfos returns the locally tracked nonces and the on-chain nonces of the sender accounts.
+func (r *Layer2Relayer) GetNonceInfos(ctx context.Context) ([]*sender.NonceInfo, error) {
+	var nonceInfos []*sender.NonceInfo
+	for _, s := range r.senders() {
+		nonceInfo, err := s.GetNonceInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		nonceInfos = append(nonceInfos, nonceInfo)
+	}
+	return nonceInfos, nil
+}
+
+// ResetNonce re-syncs the locally tracked nonce of the given sender account to the on-chain value.
+func (r *Layer2Relayer) ResetNonce(ctx context.Context, account common.Address) error {
+	for _, s := range r.senders() {
+		if s.GetAccount() == account {
+			return s.ResetNonce(ctx)
+		}
+	}
+	return fmt.Errorf("unknown sender account: %s", account.Hex())
+}
+
+// CancelPendingCommit cancels the in-flight commit tx of the batch, e.g. the layer2 blocks of the batch are reorged,
+// by replacing it with a no-op tx of the same nonce. The batch stays committing until one of them is confirmed,
+// the confirmation of the no-op tx resets the batch to pending so that it's committed again, while the batch is
+// committed as usual if the original commit tx is mined before the replacement.
+func (r *Layer2Relayer) CancelPendingCommit(batchHash string) error {
+	statuses, err := r.batchOrm.GetRollupStatusByHashList(r.ctx, []string{batchHash})
+	if err != nil {
+		return fmt.Errorf("failed to get rollup status of batch %s, err: %w", batchHash, err)
+	}
+	if len(statuses) != 1 {
+		return fmt.Errorf("unknown batch: %s", batchHash)
+	}
+	if statuses[0] != types.RollupCommitting {
+		return fmt.Errorf("batch %s has no commit tx in flight, rollup status: %v", batchHash, statuses[0])
+	}
+
+	cancelTxHash, err := r.commitSender.CancelTransaction(batchHash)
+	if err != nil {
+		return fmt.Errorf("failed to cancel commit tx of batch %s, err: %w", batchHash, err)
+	}
+	r.metrics.rollupL2CommitCancelledTotal.Inc()
+	log.Warn("Cancelled commit tx of batch", "hash", batchHash, "cancel tx hash", cancelTxHash.String())
+	return nil
+}
+
 func (r *Layer2Relayer) handleL2GasOracleConfirmLoop(ctx context.Context) {
+	retryTicker := time.NewTicker(failedConfirmationRetryInterval)
+	defer retryTicker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case cfm := <-r.gasOracleSender.ConfirmChan():
 			r.handleConfirmation(cfm)
+		case <-retryTicker.C:
+			r.retryFailedConfirmations()
+			r.recheckUncheckedConfirmations()
 		}
+		r.confirmLoopHeartbeat.Store(time.Now().UnixNano())
 	}
 }
 
 func (r *Layer2Relayer) handleL2RollupRelayerConfirmLoop(ctx context.Context) {
+	// merge the confirmations of the extra finalize senders, so that all confirmations are handled in this loop.
+	extraFinalizeConfirmCh := make(chan *sender.Confirmation)
+	var mergeWg sync.WaitGroup
+	defer mergeWg.Wait()
+	for _, s := range r.extraFinalizeSenders {
+		mergeWg.Add(1)
+		go func(s *sender.Sender) {
+			defer mergeWg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case cfm := <-s.ConfirmChan():
+					select {
+					case <-ctx.Done():
+						return
+					case extraFinalizeConfirmCh <- cfm:
+					}
+				}
+			}
+		}(s)
+	}
+
+	retryTicker := time.NewTicker(failedConfirmationRetryInterval)
+	defer retryTicker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-retryTicker.C:
+			r.retryFailedConfirmations()
+			r.recheckUncheckedConfirmations()
 		case cfm := <-r.commitSender.ConfirmChan():
 			r.handleConfirmation(cfm)
 		case cfm := <-r.finalizeSender.ConfirmChan():
 			r.handleConfirmation(cfm)
+		case cfm := <-extraFinalizeConfirmCh:
+			r.handleConfirmation(cfm)
 		}
+		r.confirmLoopHeartbeat.Store(time.Now().UnixNano())
 	}
 }
@@ -10,6 +10,8 @@ import (
 type l2RelayerMetrics struct {
 	rollupL2RelayerProcessPendingBatchTotal                     prometheus.Counter
 	rollupL2RelayerProcessPendingBatchSuccessTotal              prometheus.Counter
+	rollupL2RelayerCommitHeartbeatTimestamp                     prometheus.Gauge
+	rollupL2RelayerCommitHeartbeatPendingBatches                prometheus.Gauge
 	rollupL2RelayerGasPriceOraclerRunTotal                      prometheus.Counter
 	rollupL2RelayerLastGasPrice                                 prometheus.Gauge
 	rollupL2RelayerProcessCommittedBatchesTotal                 prometheus.Counter
@@ -17,10 +19,44 @@ type l2RelayerMetrics struct {
 	rollupL2RelayerProcessCommittedBatchesFinalizedSuccessTotal prometheus.Counter
 	rollupL2BatchesCommittedConfirmedTotal                      prometheus.Counter
 	rollupL2BatchesCommittedConfirmedFailedTotal                prometheus.Counter
+	rollupL2CommitCancelledTotal                                prometheus.Counter
+	rollupL2BatchesCommitFailedRetriedTotal                     prometheus.Counter
+	rollupL2BatchesHeaderRederivedTotal                         prometheus.Counter
+	rollupL2BatchesHeaderRederiveFailedTotal                    prometheus.Counter
+	rollupL2BatchesParentHashMismatchTotal                      prometheus.Counter
 	rollupL2BatchesFinalizedConfirmedTotal                      prometheus.Counter
 	rollupL2BatchesFinalizedConfirmedFailedTotal                prometheus.Counter
+	rollupL2BatchesFinalizedConfirmedDiscrepancyTotal           prometheus.Counter
+	rollupL2BatchesFinalizeSkippedTotal                         prometheus.Counter
+	rollupL2BatchesFinalizeSkippedFailedTotal                   prometheus.Counter
+	rollupL2BatchesFinalizeSkippedExplicitTotal                 prometheus.Counter
+	rollupL2BatchesFinalizeSkipAlertTotal                       prometheus.Counter
+	rollupL2BatchFinalizeSkipCount                              prometheus.Gauge
+	rollupL2CommitCircuitBreakerTrippedTotal                    prometheus.Counter
+	rollupL2FinalizeCircuitBreakerTrippedTotal                  prometheus.Counter
+	rollupL2HeadStaleTotal                                      prometheus.Counter
+	rollupL2HeadStale                                           prometheus.Gauge
+	rollupL2BatchesProofMissingTotal                            prometheus.Counter
+	rollupL2BatchesProofRejectedTotal                           prometheus.Counter
+	rollupL2BatchesProofMismatchTotal                           prometheus.Counter
+	rollupL2BatchesMalformedProofTotal                          prometheus.Counter
+	rollupL2BatchesProofPublishedTotal                          prometheus.Counter
+	rollupL2BatchesProofPublishFailedTotal                      prometheus.Counter
 	rollupL2UpdateGasOracleConfirmedTotal                       prometheus.Counter
+	rollupL2ConfirmationReceiptMismatchTotal                    prometheus.Counter
+	rollupL2ConfirmationQuorumNotReachedTotal                   prometheus.Counter
+	rollupL2FinalizeIndexRegressionTotal                        prometheus.Counter
+	rollupL2FinalizeParentNotFinalizedTotal                     prometheus.Counter
 	rollupL2UpdateGasOracleConfirmedFailedTotal                 prometheus.Counter
+	rollupL2UpdateGasOracleRetriedTotal                         prometheus.Counter
+	rollupL2UnknownConfirmationTotal                            prometheus.Counter
+	rollupL2GasOracleValueMismatchTotal                         prometheus.Counter
+	rollupL2UnknownConfirmationRecoveredTotal                   prometheus.Counter
+	rollupL2ConfirmationDBUpdateFailedTotal                     prometheus.Counter
+	rollupL2ConfirmationDBUpdatePending                         prometheus.Gauge
+	rollupL2ConfirmationUncheckedPending                        prometheus.Gauge
+	rollupL2RelayerDryRunTxTotal                                prometheus.Counter
+	rollupL2BatchFinalizeLatency                                prometheus.Histogram
 	rollupL2ChainMonitorLatestFailedCall                        prometheus.Counter
 	rollupL2ChainMonitorLatestFailedBatchStatus                 prometheus.Counter
 }
@@ -37,6 +73,14 @@ func initL2RelayerMetrics(reg prometheus.Registerer) *l2RelayerMetrics {
 				Name: "rollup_layer2_process_pending_batch_total",
 				Help: "The total number of layer2 process pending batch",
 			}),
+			rollupL2RelayerCommitHeartbeatTimestamp: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+				Name: "rollup_layer2_commit_heartbeat_timestamp",
+				Help: "The unix timestamp of the latest commit cycle of the layer2 relayer, a flat line means the relayer stopped cycling",
+			}),
+			rollupL2RelayerCommitHeartbeatPendingBatches: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+				Name: "rollup_layer2_commit_heartbeat_pending_batches",
+				Help: "The number of pending or commit failed batches found by the latest commit cycle, at most the fetched batches of a cycle",
+			}),
 			rollupL2RelayerProcessPendingBatchSuccessTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
 				Name: "rollup_layer2_process_pending_batch_success_total",
 				Help: "The total number of layer2 process pending success batch",
@@ -69,6 +113,26 @@ func initL2RelayerMetrics(reg prometheus.Registerer) *l2RelayerMetrics {
 				Name: "rollup_layer2_process_committed_batches_confirmed_failed_total",
 				Help: "The total number of layer2 process committed batches confirmed failed total",
 			}),
+			rollupL2CommitCancelledTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_commit_cancelled_total",
+				Help: "The total number of in-flight commit txs cancelled by a no-op replacement",
+			}),
+			rollupL2BatchesCommitFailedRetriedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_commit_failed_retried_total",
+				Help: "The total number of layer2 commit failed batches moved back to pending to be re-committed",
+			}),
+			rollupL2BatchesHeaderRederivedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_header_rederived_total",
+				Help: "The total number of layer2 batches whose header is re-derived from the blocks before committing",
+			}),
+			rollupL2BatchesHeaderRederiveFailedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_header_rederive_failed_total",
+				Help: "The total number of layer2 batches whose re-derived header doesn't match the batch hash",
+			}),
+			rollupL2BatchesParentHashMismatchTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_parent_hash_mismatch_total",
+				Help: "The total number of layer2 batches not committed since they don't chain onto the committed parent batch",
+			}),
 			rollupL2BatchesFinalizedConfirmedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
 				Name: "rollup_layer2_process_finalized_batches_confirmed_total",
 				Help: "The total number of layer2 process finalized batches confirmed total",
@@ -77,6 +141,70 @@ func initL2RelayerMetrics(reg prometheus.Registerer) *l2RelayerMetrics {
 				Name: "rollup_layer2_process_finalized_batches_confirmed_failed_total",
 				Help: "The total number of layer2 process finalized batches confirmed failed total",
 			}),
+			rollupL2BatchesFinalizedConfirmedDiscrepancyTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_process_finalized_batches_confirmed_discrepancy_total",
+				Help: "The total number of layer2 process finalized batches confirmed without the expected finalize event total",
+			}),
+			rollupL2BatchesFinalizeSkippedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_finalize_skipped_total",
+				Help: "The total number of times the earliest committed batch is skipped by finalization",
+			}),
+			rollupL2BatchesFinalizeSkippedFailedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_finalize_skipped_failed_total",
+				Help: "The total number of times the earliest committed batch is skipped by finalization since its proving or proof upload failed",
+			}),
+			rollupL2BatchesFinalizeSkippedExplicitTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_finalize_skipped_explicit_total",
+				Help: "The total number of times the earliest committed batch is skipped by finalization by the time policy",
+			}),
+			rollupL2BatchesFinalizeSkipAlertTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_finalize_skip_alert_total",
+				Help: "The total number of layer2 batches skipped by finalization more than the alert threshold",
+			}),
+			rollupL2CommitCircuitBreakerTrippedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_commit_circuit_breaker_tripped_total",
+				Help: "The total number of times committing is halted after repeated on-chain commit failures",
+			}),
+			rollupL2FinalizeCircuitBreakerTrippedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_finalize_circuit_breaker_tripped_total",
+				Help: "The total number of times finalizing is halted after repeated on-chain finalize failures",
+			}),
+			rollupL2HeadStaleTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_head_stale_total",
+				Help: "The total number of times the layer2 head became older than the max age",
+			}),
+			rollupL2HeadStale: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+				Name: "rollup_layer2_head_stale",
+				Help: "Whether committing and gas oracle updates are paused since the layer2 head is stale",
+			}),
+			rollupL2BatchFinalizeSkipCount: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+				Name: "rollup_layer2_batch_finalize_skip_count",
+				Help: "The number of times the earliest committed batch has been skipped by finalization",
+			}),
+			rollupL2BatchesProofMissingTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_proof_missing_total",
+				Help: "The total number of layer2 verified batches marked as proof missing",
+			}),
+			rollupL2BatchesProofMismatchTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_proof_mismatch_total",
+				Help: "The total number of layer2 batches whose proof doesn't match the batch roots",
+			}),
+			rollupL2BatchesMalformedProofTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_malformed_proof_total",
+				Help: "The total number of layer2 batches whose proof or instances buffer is malformed",
+			}),
+			rollupL2BatchesProofRejectedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_proof_rejected_total",
+				Help: "The total number of layer2 batches whose finalize simulation reverted",
+			}),
+			rollupL2BatchesProofPublishedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_proof_published_total",
+				Help: "The total number of layer2 finalized batches whose proof is published",
+			}),
+			rollupL2BatchesProofPublishFailedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_batches_proof_publish_failed_total",
+				Help: "The total number of layer2 finalized batches whose proof failed to be published",
+			}),
 			rollupL2UpdateGasOracleConfirmedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
 				Name: "rollup_layer2_update_layer1_gas_oracle_confirmed_total",
 				Help: "The total number of updating layer2 gas oracle confirmed",
@@ -85,6 +213,59 @@ func initL2RelayerMetrics(reg prometheus.Registerer) *l2RelayerMetrics {
 				Name: "rollup_layer2_update_layer1_gas_oracle_confirmed_failed_total",
 				Help: "The total number of updating layer2 gas oracle confirmed failed",
 			}),
+			rollupL2UpdateGasOracleRetriedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_update_layer1_gas_oracle_retried_total",
+				Help: "The total number of failed layer2 gas oracle updates moved back to pending",
+			}),
+			rollupL2GasOracleValueMismatchTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_gas_oracle_value_mismatch_total",
+				Help: "The total number of confirmed layer2 gas oracle updates whose l2 base fee isn't applied on-chain",
+			}),
+			rollupL2UnknownConfirmationTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_unknown_confirmation_total",
+				Help: "The total number of confirmations of an unknown transaction type",
+			}),
+			rollupL2UnknownConfirmationRecoveredTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_unknown_confirmation_recovered_total",
+				Help: "The total number of unknown confirmations recovered from the sent transactions",
+			}),
+			rollupL2ConfirmationDBUpdateFailedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_confirmation_db_update_failed_total",
+				Help: "The total number of confirmations whose db update failed after the retries and is kept for retry",
+			}),
+			rollupL2ConfirmationDBUpdatePending: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+				Name: "rollup_layer2_confirmation_db_update_pending",
+				Help: "The number of confirmations whose db update is kept for retry",
+			}),
+			rollupL2ConfirmationUncheckedPending: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+				Name: "rollup_layer2_confirmation_unchecked_pending",
+				Help: "The number of confirmations not verified yet, kept to re-check",
+			}),
+			rollupL2RelayerDryRunTxTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_relayer_dry_run_tx_total",
+				Help: "The total number of txs logged but not sent in dry-run mode",
+			}),
+			rollupL2BatchFinalizeLatency: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+				Name:    "rollup_layer2_batch_finalize_latency_seconds",
+				Help:    "The time in seconds from the commit to the finalization of layer2 batches",
+				Buckets: prometheus.ExponentialBuckets(60, 2, 12), // 1 minute to ~34 hours
+			}),
+			rollupL2FinalizeIndexRegressionTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_finalize_index_regression_total",
+				Help: "The total number of committed batches refused by finalization since their index regressed",
+			}),
+			rollupL2FinalizeParentNotFinalizedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_finalize_parent_not_finalized_total",
+				Help: "The total number of times finalizing a batch is deferred since its parent batch is not finalized",
+			}),
+			rollupL2ConfirmationQuorumNotReachedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_confirmation_quorum_not_reached_total",
+				Help: "The total number of times not enough layer1 nodes agree with a layer1 confirmation",
+			}),
+			rollupL2ConfirmationReceiptMismatchTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "rollup_layer2_confirmation_receipt_mismatch_total",
+				Help: "The total number of times the re-fetched receipt of a layer1 confirmation disagrees with it",
+			}),
 			rollupL2ChainMonitorLatestFailedCall: promauto.With(reg).NewCounter(prometheus.CounterOpts{
 				Name: "rollup_layer2_chain_monitor_latest_failed_batch_call",
 				Help: "The total number of failed call chain_monitor api",
@@ -0,0 +1,148 @@
+package relayer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
+
+	"scroll-tech/common/types"
+
+	"scroll-tech/rollup/internal/orm"
+)
+
+// exportStatePageSize is the number of batches read from the db at a time when exporting the rollup state.
+const exportStatePageSize = 100
+
+// BatchState is the relayer-relevant state of a batch, the rollup state is exported as one json object per line.
+type BatchState struct {
+	Index           uint64 `json:"index"`
+	Hash            string `json:"hash"`
+	ParentBatchHash string `json:"parent_batch_hash"`
+	StartChunkIndex uint64 `json:"start_chunk_index"`
+	EndChunkIndex   uint64 `json:"end_chunk_index"`
+	StateRoot       string `json:"state_root"`
+	WithdrawRoot    string `json:"withdraw_root"`
+
+	ProvingStatus    string     `json:"proving_status"`
+	ProofAvailable   bool       `json:"proof_available"`
+	ProverAssignedAt *time.Time `json:"prover_assigned_at,omitempty"`
+	ProvedAt         *time.Time `json:"proved_at,omitempty"`
+
+	RollupStatus   string     `json:"rollup_status"`
+	CommitTxHash   string     `json:"commit_tx_hash,omitempty"`
+	CommittedAt    *time.Time `json:"committed_at,omitempty"`
+	FinalizeTxHash string     `json:"finalize_tx_hash,omitempty"`
+	FinalizedAt    *time.Time `json:"finalized_at,omitempty"`
+
+	OracleStatus string `json:"oracle_status"`
+	OracleTxHash string `json:"oracle_tx_hash,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newBatchState(batch *orm.Batch) *BatchState {
+	return &BatchState{
+		Index:            batch.Index,
+		Hash:             batch.Hash,
+		ParentBatchHash:  batch.ParentBatchHash,
+		StartChunkIndex:  batch.StartChunkIndex,
+		EndChunkIndex:    batch.EndChunkIndex,
+		StateRoot:        batch.StateRoot,
+		WithdrawRoot:     batch.WithdrawRoot,
+		ProvingStatus:    types.ProvingStatus(batch.ProvingStatus).String(),
+		ProofAvailable:   len(batch.Proof) > 0,
+		ProverAssignedAt: batch.ProverAssignedAt,
+		ProvedAt:         batch.ProvedAt,
+		RollupStatus:     types.RollupStatus(batch.RollupStatus).String(),
+		CommitTxHash:     batch.CommitTxHash,
+		CommittedAt:      batch.CommittedAt,
+		FinalizeTxHash:   batch.FinalizeTxHash,
+		FinalizedAt:      batch.FinalizedAt,
+		OracleStatus:     types.GasOracleStatus(batch.OracleStatus).String(),
+		OracleTxHash:     batch.OracleTxHash,
+		CreatedAt:        batch.CreatedAt,
+	}
+}
+
+// ExportState writes the state of the batches with index in [fromIndex, toIndex] to w, one json object per line.
+// The batches are read from the db page by page, so that large ranges are streamed.
+func (r *Layer2Relayer) ExportState(w io.Writer, fromIndex, toIndex uint64) (uint64, error) {
+	return exportBatchStates(r.ctx, r.batchOrm, w, fromIndex, toIndex)
+}
+
+// ExportBatchStates writes the state of the batches with index in [fromIndex, toIndex] in the db to w,
+// it's the same as Layer2Relayer.ExportState without setting up the relayer.
+func ExportBatchStates(ctx context.Context, db *gorm.DB, w io.Writer, fromIndex, toIndex uint64) (uint64, error) {
+	return exportBatchStates(ctx, orm.NewBatch(db), w, fromIndex, toIndex)
+}
+
+func exportBatchStates(ctx context.Context, batchOrm *orm.Batch, w io.Writer, fromIndex, toIndex uint64) (uint64, error) {
+	if fromIndex > toIndex {
+		return 0, fmt.Errorf("invalid batch index range, from: %v, to: %v", fromIndex, toIndex)
+	}
+
+	encoder := json.NewEncoder(w)
+	var exported uint64
+	for next := fromIndex; next <= toIndex; {
+		batches, err := batchOrm.GetBatchesInRange(ctx, next, toIndex, exportStatePageSize)
+		if err != nil {
+			return exported, err
+		}
+		if len(batches) == 0 {
+			break
+		}
+		for _, batch := range batches {
+			if err = encoder.Encode(newBatchState(batch)); err != nil {
+				return exported, fmt.Errorf("failed to write state of batch %v, err: %w", batch.Index, err)
+			}
+			exported++
+		}
+		last := batches[len(batches)-1].Index
+		if last == toIndex {
+			break
+		}
+		next = last + 1
+	}
+	return exported, nil
+}
+
+// VerifyBatchStates checks the batch states exported by ExportState against the db, it returns the number
+// of verified batches and an error on the first batch which is missing or differs.
+func VerifyBatchStates(ctx context.Context, db *gorm.DB, rd io.Reader) (uint64, error) {
+	batchOrm := orm.NewBatch(db)
+	decoder := json.NewDecoder(rd)
+	var verified uint64
+	for {
+		var exported BatchState
+		if err := decoder.Decode(&exported); err != nil {
+			if errors.Is(err, io.EOF) {
+				return verified, nil
+			}
+			return verified, fmt.Errorf("failed to read exported batch state, err: %w", err)
+		}
+
+		batch, err := batchOrm.GetBatchByIndex(ctx, exported.Index)
+		if err != nil {
+			return verified, err
+		}
+		// compare the encodings so that the timestamps are compared regardless of their location.
+		expected, err := json.Marshal(&exported)
+		if err != nil {
+			return verified, err
+		}
+		actual, err := json.Marshal(newBatchState(batch))
+		if err != nil {
+			return verified, err
+		}
+		if !bytes.Equal(expected, actual) {
+			return verified, fmt.Errorf("state of batch %v differs, exported: %s, db: %s", exported.Index, expected, actual)
+		}
+		verified++
+	}
+}
@@ -1,16 +1,30 @@
 package relayer
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"encoding/json"
 	"errors"
 	"math/big"
 	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/common"
+	gethTypes "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/rpc"
 	"github.com/smartystreets/goconvey/convey"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
@@ -22,6 +36,8 @@ import (
 
 	"scroll-tech/database/migrate"
 
+	bridgeAbi "scroll-tech/rollup/abi"
+	"scroll-tech/rollup/internal/config"
 	"scroll-tech/rollup/internal/controller/sender"
 	"scroll-tech/rollup/internal/orm"
 )
@@ -43,6 +59,28 @@ func testCreateNewRelayer(t *testing.T) {
 	assert.NotNil(t, relayer)
 }
 
+func testCreateNewRelayerCheckContractCode(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.CheckContractCode = true
+
+	// no code at the rollup contract address.
+	relayerCfg.RollupContractAddress = common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	_, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.ErrorContains(t, err, "no code at rollup contract address")
+
+	// the rollup contract is deployed.
+	patchGuard := gomonkey.ApplyMethodFunc(&sender.Sender{}, "IsContract", func(addr common.Address) (bool, error) {
+		return addr == relayerCfg.RollupContractAddress, nil
+	})
+	defer patchGuard.Reset()
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, relayer)
+}
+
 func testL2RelayerProcessPendingBatches(t *testing.T) {
 	db := setupL2RelayerDB(t)
 	defer database.CloseDB(db)
@@ -77,6 +115,205 @@ func testL2RelayerProcessPendingBatches(t *testing.T) {
 	assert.Equal(t, types.RollupCommitting, statuses[0])
 }
 
+func testL2RelayerProcessPendingBatchesVerifyParentHash(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.VerifyParentBatchHash = true
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	l2BlockOrm := orm.NewL2Block(db)
+	err = l2BlockOrm.InsertL2Blocks(context.Background(), []*types.WrappedBlock{wrappedBlock1, wrappedBlock2})
+	assert.NoError(t, err)
+	chunkOrm := orm.NewChunk(db)
+	dbChunk1, err := chunkOrm.InsertChunk(context.Background(), chunk1)
+	assert.NoError(t, err)
+	dbChunk2, err := chunkOrm.InsertChunk(context.Background(), chunk2)
+	assert.NoError(t, err)
+	batchOrm := orm.NewBatch(db)
+	parentBatch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1}, &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  dbChunk1.Hash,
+		EndChunkIndex:   0,
+		EndChunkHash:    dbChunk1.Hash,
+	})
+	assert.NoError(t, err)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk2}, &types.BatchMeta{
+		StartChunkIndex: 1,
+		StartChunkHash:  dbChunk2.Hash,
+		EndChunkIndex:   1,
+		EndChunkHash:    dbChunk2.Hash,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, parentBatch.Hash, batch.ParentBatchHash)
+	assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), parentBatch.Hash, types.RollupCommitted))
+
+	batchHeader, err := types.DecodeBatchHeader(batch.BatchHeader)
+	assert.NoError(t, err)
+	committedParent, err := batchOrm.GetBatchByIndex(context.Background(), 0)
+	assert.NoError(t, err)
+
+	convey.Convey("correctly chained batch passes the check", t, func() {
+		assert.NoError(t, relayer.checkParentBatchHash(batch, committedParent, batchHeader))
+	})
+
+	convey.Convey("parent batch which isn't committed fails the check", t, func() {
+		pendingParent := *committedParent
+		pendingParent.RollupStatus = int16(types.RollupPending)
+		assert.Error(t, relayer.checkParentBatchHash(batch, &pendingParent, batchHeader))
+	})
+
+	convey.Convey("batch header with a broken parent fails the check", t, func() {
+		brokenParent := *committedParent
+		brokenParent.Hash = common.HexToHash("0x1234").Hex()
+		brokenBatch := *batch
+		brokenBatch.ParentBatchHash = brokenParent.Hash
+		assert.Error(t, relayer.checkParentBatchHash(&brokenBatch, &brokenParent, batchHeader))
+	})
+
+	convey.Convey("batch with a broken parent isn't committed", t, func() {
+		err = db.Model(&orm.Batch{}).Where("hash = ?", batch.Hash).Update("parent_batch_hash", common.HexToHash("0x1234").Hex()).Error
+		assert.NoError(t, err)
+		relayer.ProcessPendingBatches()
+		statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+		assert.NoError(t, err)
+		assert.Equal(t, []types.RollupStatus{types.RollupPending}, statuses)
+	})
+
+	convey.Convey("correctly chained batch is committed", t, func() {
+		err = db.Model(&orm.Batch{}).Where("hash = ?", batch.Hash).Update("parent_batch_hash", parentBatch.Hash).Error
+		assert.NoError(t, err)
+		relayer.ProcessPendingBatches()
+		statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+		assert.NoError(t, err)
+		assert.Equal(t, []types.RollupStatus{types.RollupCommitting}, statuses)
+	})
+}
+
+func testL2RelayerProcessPendingBatchesRederiveHeader(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	l2BlockOrm := orm.NewL2Block(db)
+	err = l2BlockOrm.InsertL2Blocks(context.Background(), []*types.WrappedBlock{wrappedBlock1, wrappedBlock2})
+	assert.NoError(t, err)
+	chunkOrm := orm.NewChunk(db)
+	dbChunk1, err := chunkOrm.InsertChunk(context.Background(), chunk1)
+	assert.NoError(t, err)
+	dbChunk2, err := chunkOrm.InsertChunk(context.Background(), chunk2)
+	assert.NoError(t, err)
+	batchMeta := &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  dbChunk1.Hash,
+		EndChunkIndex:   1,
+		EndChunkHash:    dbChunk2.Hash,
+	}
+	batchOrm := orm.NewBatch(db)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+	assert.NoError(t, err)
+
+	convey.Convey("complete batch header is used as is", t, func() {
+		batchHeader, err := relayer.getCommittableBatchHeader(batch, &orm.Batch{}, []*types.Chunk{chunk1, chunk2})
+		assert.NoError(t, err)
+		assert.Equal(t, batch.BatchHeader, batchHeader.Encode())
+	})
+
+	convey.Convey("inconsistent batch header can't be re-derived from mismatched blocks", t, func() {
+		partialBatch := *batch
+		partialBatch.BatchHeader = nil
+		_, err := relayer.getCommittableBatchHeader(&partialBatch, &orm.Batch{}, []*types.Chunk{chunk1})
+		assert.Error(t, err)
+	})
+
+	convey.Convey("missing batch header is re-derived from the blocks and committed", t, func() {
+		assert.NoError(t, batchOrm.UpdateBatchHeader(context.Background(), batch.Hash, nil))
+
+		relayer.ProcessPendingBatches()
+
+		batches, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batch.Hash}, nil, 0)
+		assert.NoError(t, err)
+		assert.Len(t, batches, 1)
+		assert.Equal(t, batch.BatchHeader, batches[0].BatchHeader)
+		assert.Equal(t, types.RollupCommitting, types.RollupStatus(batches[0].RollupStatus))
+	})
+}
+
+func testL2RelayerProcessPendingBatchesCommitFailedRetry(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.CommitFailedRetryDelaySec = 1
+	relayerCfg.MaxCommitFailedRetries = 1
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	l2BlockOrm := orm.NewL2Block(db)
+	err = l2BlockOrm.InsertL2Blocks(context.Background(), []*types.WrappedBlock{wrappedBlock1, wrappedBlock2})
+	assert.NoError(t, err)
+	chunkOrm := orm.NewChunk(db)
+	dbChunk1, err := chunkOrm.InsertChunk(context.Background(), chunk1)
+	assert.NoError(t, err)
+	dbChunk2, err := chunkOrm.InsertChunk(context.Background(), chunk2)
+	assert.NoError(t, err)
+	batchMeta := &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  dbChunk1.Hash,
+		EndChunkIndex:   1,
+		EndChunkHash:    dbChunk2.Hash,
+	}
+	batchOrm := orm.NewBatch(db)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+	assert.NoError(t, err)
+
+	var sentCount int
+	patchGuard := gomonkey.ApplyMethodFunc(relayer.commitSender, "SendTransaction", func(contextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (common.Hash, error) {
+		sentCount++
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+	defer patchGuard.Reset()
+
+	failCommit := func() {
+		relayer.handleConfirmation(&sender.Confirmation{
+			ContextID:    batch.Hash,
+			IsSuccessful: false,
+			TxHash:       common.HexToHash("0x123456789abcdef"),
+			SenderType:   types.SenderTypeCommitBatch,
+		})
+	}
+	checkStatus := func(expected types.RollupStatus) {
+		statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+		assert.NoError(t, err)
+		assert.Equal(t, []types.RollupStatus{expected}, statuses)
+	}
+
+	failCommit()
+	checkStatus(types.RollupCommitFailed)
+
+	// the batch is not re-committed before the delay.
+	relayer.ProcessPendingBatches()
+	assert.Equal(t, 0, sentCount)
+	checkStatus(types.RollupCommitFailed)
+
+	// the batch is moved back to pending and re-committed after the delay.
+	time.Sleep(2 * time.Second)
+	relayer.ProcessPendingBatches()
+	assert.Equal(t, 1, sentCount)
+	checkStatus(types.RollupCommitting)
+
+	// the retries are used up, the failure is permanent.
+	failCommit()
+	time.Sleep(2 * time.Second)
+	relayer.ProcessPendingBatches()
+	assert.Equal(t, 1, sentCount)
+	checkStatus(types.RollupCommitFailed)
+}
+
 func testL2RelayerProcessCommittedBatches(t *testing.T) {
 	db := setupL2RelayerDB(t)
 	defer database.CloseDB(db)
@@ -121,53 +358,31 @@ func testL2RelayerProcessCommittedBatches(t *testing.T) {
 	assert.Equal(t, types.RollupFinalizing, statuses[0])
 }
 
-func testL2RelayerFinalizeTimeoutBatches(t *testing.T) {
+func testL2RelayerProcessCommittedBatchesMultipleFinalizeSenders(t *testing.T) {
 	db := setupL2RelayerDB(t)
 	defer database.CloseDB(db)
 
-	l2Cfg := cfg.L2Config
-	l2Cfg.RelayerConfig.EnableTestEnvBypassFeatures = true
-	l2Cfg.RelayerConfig.FinalizeBatchWithoutProofTimeoutSec = 0
-	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, l2Cfg.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
-	assert.NoError(t, err)
-	batchMeta := &types.BatchMeta{
-		StartChunkIndex: 0,
-		StartChunkHash:  chunkHash1.Hex(),
-		EndChunkIndex:   1,
-		EndChunkHash:    chunkHash2.Hex(),
-	}
-	batchOrm := orm.NewBatch(db)
-	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+	extraFinalizeSenderPrivateKey, err := crypto.ToECDSA(common.FromHex("1212121212121212121212121212121212121212121212121212121212121212"))
 	assert.NoError(t, err)
-
-	err = batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupCommitted)
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.ExtraFinalizeSenderPrivateKeys = []*ecdsa.PrivateKey{extraFinalizeSenderPrivateKey}
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
 	assert.NoError(t, err)
+	assert.Len(t, relayer.extraFinalizeSenders, 1)
 
-	// Check the database for the updated status using TryTimes.
-	ok := utils.TryTimes(5, func() bool {
-		relayer.ProcessCommittedBatches()
-		statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
-		return err == nil && len(statuses) == 1 && statuses[0] == types.RollupFinalizing
-	})
-	assert.True(t, ok)
-}
-
-func testL2RelayerCommitConfirm(t *testing.T) {
-	db := setupL2RelayerDB(t)
-	defer database.CloseDB(db)
-
-	// Create and set up the Layer2 Relayer.
-	l2Cfg := cfg.L2Config
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	l2Relayer, err := NewLayer2Relayer(ctx, l2Cli, db, l2Cfg.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
-	assert.NoError(t, err)
+	nonces := make(map[string]uint64)
+	for _, s := range []*sender.Sender{relayer.finalizeSender, relayer.extraFinalizeSenders[0]} {
+		nonceInfo, err := s.GetNonceInfo(context.Background())
+		assert.NoError(t, err)
+		nonces[nonceInfo.Account.String()] = nonceInfo.LocalNonce
+	}
 
-	// Simulate message confirmations.
-	isSuccessful := []bool{true, false}
 	batchOrm := orm.NewBatch(db)
-	batchHashes := make([]string, len(isSuccessful))
-	for i := range batchHashes {
+	proof := &message.BatchProof{
+		Proof: []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+	}
+	var hashes []string
+	for i := 0; i < 2; i++ {
 		batchMeta := &types.BatchMeta{
 			StartChunkIndex: 0,
 			StartChunkHash:  chunkHash1.Hex(),
@@ -176,170 +391,2314 @@ func testL2RelayerCommitConfirm(t *testing.T) {
 		}
 		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
 		assert.NoError(t, err)
-		batchHashes[i] = batch.Hash
-	}
-
-	for i, batchHash := range batchHashes {
-		l2Relayer.commitSender.SendConfirmation(&sender.Confirmation{
-			ContextID:    batchHash,
-			IsSuccessful: isSuccessful[i],
-			TxHash:       common.HexToHash("0x123456789abcdef"),
-			SenderType:   types.SenderTypeCommitBatch,
-		})
+		assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupCommitted))
+		assert.NoError(t, batchOrm.UpdateProvingStatus(context.Background(), batch.Hash, types.ProvingTaskVerified))
+		assert.NoError(t, batchOrm.UpdateProofByHash(context.Background(), batch.Hash, proof, 100))
+		hashes = append(hashes, batch.Hash)
 	}
 
-	// Check the database for the updated status using TryTimes.
-	ok := utils.TryTimes(5, func() bool {
-		expectedStatuses := []types.RollupStatus{
-			types.RollupCommitted,
-			types.RollupCommitFailed,
-		}
+	// the second batch is held until the finalize tx of the first one, sent by another account, is confirmed.
+	relayer.ProcessCommittedBatches()
+	relayer.ProcessCommittedBatches()
+	pendingTxOrm := orm.NewPendingTransaction(db)
+	txs, err := pendingTxOrm.GetPendingOrReplacedTransactionsBySenderType(context.Background(), types.SenderTypeFinalizeBatch, 10)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 1)
+	statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), hashes)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RollupStatus{types.RollupFinalizing, types.RollupCommitted}, statuses)
 
-		for i, batchHash := range batchHashes {
-			batchInDB, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batchHash}, nil, 0)
-			if err != nil || len(batchInDB) != 1 || types.RollupStatus(batchInDB[0].RollupStatus) != expectedStatuses[i] {
-				return false
-			}
-		}
-		return true
-	})
-	assert.True(t, ok)
+	assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), hashes[0], types.RollupFinalized))
+	relayer.ProcessCommittedBatches()
+	txs, err = pendingTxOrm.GetPendingOrReplacedTransactionsBySenderType(context.Background(), types.SenderTypeFinalizeBatch, 10)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 2)
+	assert.NotEqual(t, txs[0].SenderAddress, txs[1].SenderAddress)
+	for _, tx := range txs {
+		// each account uses its own next nonce.
+		nonce, ok := nonces[tx.SenderAddress]
+		assert.True(t, ok)
+		assert.Equal(t, nonce, tx.Nonce)
+	}
 }
 
-func testL2RelayerFinalizeConfirm(t *testing.T) {
+func testL2RelayerProcessCommittedBatchesFinalizeSkipAlert(t *testing.T) {
 	db := setupL2RelayerDB(t)
 	defer database.CloseDB(db)
 
-	// Create and set up the Layer2 Relayer.
-	l2Cfg := cfg.L2Config
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	l2Relayer, err := NewLayer2Relayer(ctx, l2Cli, db, l2Cfg.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.FinalizeSkipAlertThreshold = 3
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
 	assert.NoError(t, err)
-
-	// Simulate message confirmations.
-	isSuccessful := []bool{true, false}
+	batchMeta := &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  chunkHash1.Hex(),
+		EndChunkIndex:   1,
+		EndChunkHash:    chunkHash2.Hex(),
+	}
 	batchOrm := orm.NewBatch(db)
-	batchHashes := make([]string, len(isSuccessful))
-	for i := range batchHashes {
-		batchMeta := &types.BatchMeta{
-			StartChunkIndex: 0,
-			StartChunkHash:  chunkHash1.Hex(),
-			EndChunkIndex:   1,
-			EndChunkHash:    chunkHash2.Hex(),
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+	assert.NoError(t, err)
+
+	err = batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupCommitted)
+	assert.NoError(t, err)
+
+	err = batchOrm.UpdateProvingStatus(context.Background(), batch.Hash, types.ProvingTaskVerified)
+	assert.NoError(t, err)
+
+	// the proof is not ready, the batch is skipped on every pass and the alert fires once at the threshold.
+	alerts := testutil.ToFloat64(relayer.metrics.rollupL2BatchesFinalizeSkipAlertTotal)
+	for i := uint64(1); i <= 4; i++ {
+		relayer.ProcessCommittedBatches()
+		assert.Equal(t, i, relayer.finalizeSkips[batch.Hash])
+		assert.Equal(t, float64(i), testutil.ToFloat64(relayer.metrics.rollupL2BatchFinalizeSkipCount))
+		if i < relayerCfg.FinalizeSkipAlertThreshold {
+			assert.Equal(t, alerts, testutil.ToFloat64(relayer.metrics.rollupL2BatchesFinalizeSkipAlertTotal))
+		} else {
+			assert.Equal(t, alerts+1, testutil.ToFloat64(relayer.metrics.rollupL2BatchesFinalizeSkipAlertTotal))
 		}
-		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
-		assert.NoError(t, err)
-		batchHashes[i] = batch.Hash
 	}
 
-	for i, batchHash := range batchHashes {
-		l2Relayer.finalizeSender.SendConfirmation(&sender.Confirmation{
-			ContextID:    batchHash,
-			IsSuccessful: isSuccessful[i],
-			TxHash:       common.HexToHash("0x123456789abcdef"),
-			SenderType:   types.SenderTypeFinalizeBatch,
-		})
+	// the skip count is cleared once the batch is finalized.
+	proof := &message.BatchProof{
+		Proof: []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
 	}
+	err = batchOrm.UpdateProofByHash(context.Background(), batch.Hash, proof, 100)
+	assert.NoError(t, err)
 
-	// Check the database for the updated status using TryTimes.
-	ok := utils.TryTimes(5, func() bool {
-		expectedStatuses := []types.RollupStatus{
-			types.RollupFinalized,
-			types.RollupFinalizeFailed,
+	relayer.ProcessCommittedBatches()
+	statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RollupStatus{types.RollupFinalizing}, statuses)
+	assert.NotContains(t, relayer.finalizeSkips, batch.Hash)
+}
+
+func testL2RelayerProcessCommittedBatchesProofMissing(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.ProofMissingTimeoutSec = 60
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+	batchMeta := &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  chunkHash1.Hex(),
+		EndChunkIndex:   1,
+		EndChunkHash:    chunkHash2.Hex(),
+	}
+	batchOrm := orm.NewBatch(db)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+	assert.NoError(t, err)
+
+	err = batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupCommitted)
+	assert.NoError(t, err)
+
+	err = batchOrm.UpdateProvingStatus(context.Background(), batch.Hash, types.ProvingTaskVerified)
+	assert.NoError(t, err)
+
+	// the proof is missing within the timeout, rollup status remains the same
+	relayer.ProcessCommittedBatches()
+	statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(statuses))
+	assert.Equal(t, types.RollupCommitted, statuses[0])
+
+	// the proof is still missing past the timeout
+	err = db.Model(&orm.Batch{}).Where("hash = ?", batch.Hash).Update("proved_at", time.Now().Add(-2*time.Minute)).Error
+	assert.NoError(t, err)
+	relayer.ProcessCommittedBatches()
+	statuses, err = batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(statuses))
+	assert.Equal(t, types.RollupProofMissing, statuses[0])
+}
+
+func testL2RelayerProcessCommittedBatchesFinalizeSkipReason(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+	batchMeta := &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  chunkHash1.Hex(),
+		EndChunkIndex:   1,
+		EndChunkHash:    chunkHash2.Hex(),
+	}
+	batchOrm := orm.NewBatch(db)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+	assert.NoError(t, err)
+	assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupCommitted))
+
+	skipReason := func() types.FinalizeSkipReason {
+		batches, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batch.Hash}, nil, 1)
+		assert.NoError(t, err)
+		assert.Len(t, batches, 1)
+		return types.FinalizeSkipReason(batches[0].FinalizeSkipReason)
+	}
+	assert.Equal(t, types.FinalizeSkipReasonUndefined, skipReason())
+	explicitSkips := testutil.ToFloat64(relayer.metrics.rollupL2BatchesFinalizeSkippedExplicitTotal)
+	failedSkips := testutil.ToFloat64(relayer.metrics.rollupL2BatchesFinalizeSkippedFailedTotal)
+
+	// the proof is not ready and the batch is not due to be finalized without proof.
+	relayer.ProcessCommittedBatches()
+	assert.Equal(t, types.FinalizeSkipReasonTimePolicy, skipReason())
+	assert.Equal(t, explicitSkips+1, testutil.ToFloat64(relayer.metrics.rollupL2BatchesFinalizeSkippedExplicitTotal))
+	assert.Equal(t, failedSkips, testutil.ToFloat64(relayer.metrics.rollupL2BatchesFinalizeSkippedFailedTotal))
+
+	// the batch is verified but the proof is not uploaded.
+	assert.NoError(t, batchOrm.UpdateProvingStatus(context.Background(), batch.Hash, types.ProvingTaskVerified))
+	relayer.ProcessCommittedBatches()
+	assert.Equal(t, types.FinalizeSkipReasonUploadFailed, skipReason())
+	assert.Equal(t, explicitSkips+1, testutil.ToFloat64(relayer.metrics.rollupL2BatchesFinalizeSkippedExplicitTotal))
+	assert.Equal(t, failedSkips+1, testutil.ToFloat64(relayer.metrics.rollupL2BatchesFinalizeSkippedFailedTotal))
+
+	// the batch proving failed.
+	assert.NoError(t, batchOrm.UpdateProvingStatus(context.Background(), batch.Hash, types.ProvingTaskFailed))
+	relayer.ProcessCommittedBatches()
+	assert.Equal(t, types.FinalizeSkipReasonProofFailed, skipReason())
+	assert.Equal(t, explicitSkips+1, testutil.ToFloat64(relayer.metrics.rollupL2BatchesFinalizeSkippedExplicitTotal))
+	assert.Equal(t, failedSkips+2, testutil.ToFloat64(relayer.metrics.rollupL2BatchesFinalizeSkippedFailedTotal))
+
+	// the rollup status remains the same.
+	statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RollupStatus{types.RollupCommitted}, statuses)
+}
+
+func testL2RelayerProcessCommittedBatchesSimulateFinalize(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.SimulateFinalizeTx = true
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	batchOrm := orm.NewBatch(db)
+	proof := &message.BatchProof{
+		Proof: []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+	}
+	insertVerifiedBatch := func() string {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   1,
+			EndChunkHash:    chunkHash2.Hex(),
 		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+		assert.NoError(t, err)
+		assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupCommitted))
+		assert.NoError(t, batchOrm.UpdateProvingStatus(context.Background(), batch.Hash, types.ProvingTaskVerified))
+		assert.NoError(t, batchOrm.UpdateProofByHash(context.Background(), batch.Hash, proof, 100))
+		return batch.Hash
+	}
 
-		for i, batchHash := range batchHashes {
-			batchInDB, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batchHash}, nil, 0)
-			if err != nil || len(batchInDB) != 1 || types.RollupStatus(batchInDB[0].RollupStatus) != expectedStatuses[i] {
-				return false
-			}
+	var sentCount int
+	patchGuard := gomonkey.ApplyMethodFunc(relayer.finalizeSender, "SendTransaction", func(contextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (common.Hash, error) {
+		sentCount++
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+	defer patchGuard.Reset()
+
+	var rejectedBatchHash string
+	convey.Convey("simulation reverts, finalize tx is not sent", t, func() {
+		batchHash := insertVerifiedBatch()
+		rejectedBatchHash = batchHash
+		patchGuard.ApplyMethodFunc(relayer.finalizeSender, "SimulateTransaction", func(target *common.Address, value *big.Int, data []byte) error {
+			return errors.New("execution reverted: Invalid proof")
+		})
+		relayer.ProcessCommittedBatches()
+		assert.Equal(t, 0, sentCount)
+		statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batchHash})
+		assert.NoError(t, err)
+		assert.Equal(t, []types.RollupStatus{types.RollupProofRejected}, statuses)
+		batches, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batchHash}, nil, 1)
+		assert.NoError(t, err)
+		assert.Len(t, batches, 1)
+		assert.Equal(t, types.FinalizeSkipReasonProofRejected, types.FinalizeSkipReason(batches[0].FinalizeSkipReason))
+	})
+
+	convey.Convey("simulation succeeds, finalize tx is sent", t, func() {
+		// the parent batch must be finalized for the batch to be finalized.
+		assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), rejectedBatchHash, types.RollupFinalized))
+		batchHash := insertVerifiedBatch()
+		patchGuard.ApplyMethodFunc(relayer.finalizeSender, "SimulateTransaction", func(target *common.Address, value *big.Int, data []byte) error {
+			return nil
+		})
+		relayer.ProcessCommittedBatches()
+		assert.Equal(t, 1, sentCount)
+		statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batchHash})
+		assert.NoError(t, err)
+		assert.Equal(t, []types.RollupStatus{types.RollupFinalizing}, statuses)
+	})
+}
+
+func testL2RelayerFinalizeBatchVerifyProofPublicInputs(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.VerifyProofPublicInputs = true
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	chainID, err := l2Cli.ChainID(context.Background())
+	assert.NoError(t, err)
+
+	batchOrm := orm.NewBatch(db)
+	insertVerifiedBatch := func(proofStateRoot string) *orm.Batch {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   1,
+			EndChunkHash:    chunkHash2.Hex(),
 		}
-		return true
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+		assert.NoError(t, err)
+		assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupCommitted))
+		assert.NoError(t, batchOrm.UpdateProvingStatus(context.Background(), batch.Hash, types.ProvingTaskVerified))
+
+		if proofStateRoot == "" {
+			proofStateRoot = batch.StateRoot
+		}
+		var parentStateRoot common.Hash
+		if batch.Index > 0 {
+			parentBatch, err := batchOrm.GetBatchByIndex(context.Background(), batch.Index-1)
+			assert.NoError(t, err)
+			parentStateRoot = common.HexToHash(parentBatch.StateRoot)
+		}
+		batchHeader, err := types.DecodeBatchHeader(batch.BatchHeader)
+		assert.NoError(t, err)
+		piHash := message.BatchPublicInputHash(chainID.Uint64(), parentStateRoot, common.HexToHash(proofStateRoot), common.HexToHash(batch.WithdrawRoot), batchHeader.DataHash())
+		instances := make([]byte, (12+32)*32)
+		for i, b := range piHash {
+			instances[(12+i)*32+31] = b
+		}
+		proof := &message.BatchProof{
+			Proof:     make([]byte, 32),
+			Instances: instances,
+		}
+		assert.NoError(t, batchOrm.UpdateProofByHash(context.Background(), batch.Hash, proof, 100))
+		return batch
+	}
+
+	var sentCount int
+	patchGuard := gomonkey.ApplyMethodFunc(relayer.finalizeSender, "SendTransaction", func(contextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (common.Hash, error) {
+		sentCount++
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+	defer patchGuard.Reset()
+
+	var mismatchedBatchHash string
+	convey.Convey("proof of other roots, finalize tx is not sent", t, func() {
+		batch := insertVerifiedBatch("0x1234")
+		mismatchedBatchHash = batch.Hash
+		assert.Error(t, relayer.finalizeBatch(batch, true))
+		assert.Equal(t, 0, sentCount)
+		batches, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batch.Hash}, nil, 1)
+		assert.NoError(t, err)
+		assert.Len(t, batches, 1)
+		assert.Equal(t, types.RollupProofMismatch, types.RollupStatus(batches[0].RollupStatus))
+		assert.Equal(t, types.FinalizeSkipReasonProofMismatch, types.FinalizeSkipReason(batches[0].FinalizeSkipReason))
+	})
+
+	convey.Convey("proof of the batch roots, finalize tx is sent", t, func() {
+		// the parent batch must be finalized for the batch to be finalized.
+		assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), mismatchedBatchHash, types.RollupFinalized))
+		batch := insertVerifiedBatch("")
+		assert.NoError(t, relayer.finalizeBatch(batch, true))
+		assert.Equal(t, 1, sentCount)
+		statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+		assert.NoError(t, err)
+		assert.Equal(t, []types.RollupStatus{types.RollupFinalizing}, statuses)
 	})
+}
+
+func testL2RelayerConfirmationLatency(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	// capture the latency logged on confirmation.
+	var latencies []interface{}
+	handler := log.Root().GetHandler()
+	defer log.Root().SetHandler(handler)
+	log.Root().SetHandler(log.FuncHandler(func(r *log.Record) error {
+		if r.Msg != "Transaction confirmed in layer1" {
+			return nil
+		}
+		var latency interface{}
+		for i := 0; i+1 < len(r.Ctx); i += 2 {
+			if r.Ctx[i] == "latency" {
+				latency = r.Ctx[i+1]
+			}
+		}
+		latencies = append(latencies, latency)
+		return nil
+	}))
+
+	relayer.recordTxSent(types.SenderTypeCommitBatch, "batch-1")
+	time.Sleep(10 * time.Millisecond)
+	relayer.handleConfirmation(&sender.Confirmation{ContextID: "batch-1", IsSuccessful: true, SenderType: types.SenderTypeCommitBatch})
+	assert.Len(t, latencies, 1)
+	latency, ok := latencies[0].(time.Duration)
+	assert.True(t, ok)
+	assert.GreaterOrEqual(t, latency, 10*time.Millisecond)
+
+	// the latency is keyed by the sender type, and it's not logged for a tx without the send time.
+	relayer.recordTxSent(types.SenderTypeCommitBatch, "batch-2")
+	relayer.handleConfirmation(&sender.Confirmation{ContextID: "batch-2", IsSuccessful: true, SenderType: types.SenderTypeFinalizeBatch})
+	relayer.handleConfirmation(&sender.Confirmation{ContextID: "batch-1", IsSuccessful: true, SenderType: types.SenderTypeCommitBatch})
+	assert.Len(t, latencies, 3)
+	assert.Nil(t, latencies[1])
+	assert.Nil(t, latencies[2])
+	_, ok = relayer.confirmationLatency(&sender.Confirmation{ContextID: "batch-2", SenderType: types.SenderTypeCommitBatch})
 	assert.True(t, ok)
 }
 
-func testL2RelayerGasOracleConfirm(t *testing.T) {
+func testL2RelayerSharedSenderKeys(t *testing.T) {
 	db := setupL2RelayerDB(t)
 	defer database.CloseDB(db)
 
-	batchMeta1 := &types.BatchMeta{
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.FinalizeSenderPrivateKey = relayerCfg.CommitSenderPrivateKey
+	_, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.ErrorContains(t, err, "shared by several sender roles")
+
+	// the gas oracle service is rejected too, its account is shared with the rollup relayer service.
+	relayerCfg = *cfg.L2Config.RelayerConfig
+	relayerCfg.CommitSenderPrivateKey = relayerCfg.GasOracleSenderPrivateKey
+	_, err = NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2GasOracle, nil)
+	assert.ErrorContains(t, err, "shared by several sender roles")
+
+	relayerCfg.AllowSharedSenderKeys = true
+	_, err = NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2GasOracle, nil)
+	assert.NoError(t, err)
+}
+
+func testL2RelayerRollupTxPriority(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.MaxInFlightRollupTxs = 2
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	// a verified batch waiting to be finalized, and a pending batch waiting to be committed.
+	batchOrm := orm.NewBatch(db)
+	batchHashes := make([]string, 2)
+	for i := range batchHashes {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   1,
+			EndChunkHash:    chunkHash2.Hex(),
+		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+		assert.NoError(t, err)
+		batchHashes[i] = batch.Hash
+	}
+	assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batchHashes[0], types.RollupCommitted))
+	assert.NoError(t, batchOrm.UpdateProvingStatus(context.Background(), batchHashes[0], types.ProvingTaskVerified))
+
+	pendingTransactionOrm := orm.NewPendingTransaction(db)
+	insertInFlightTx := func(nonce uint64, senderType types.SenderType) {
+		tx := gethTypes.NewTx(&gethTypes.DynamicFeeTx{
+			Nonce:     nonce,
+			To:        &common.Address{},
+			Gas:       21000,
+			Value:     big.NewInt(0),
+			ChainID:   big.NewInt(1),
+			GasTipCap: big.NewInt(0),
+			GasFeeCap: big.NewInt(1),
+		})
+		senderMeta := &orm.SenderMeta{Name: "test", Service: "test", Address: common.HexToAddress("0x1"), Type: senderType}
+		assert.NoError(t, pendingTransactionOrm.InsertPendingTransaction(context.Background(), tx.Hash().String(), senderMeta, tx, 0))
+	}
+
+	// one slot left, it goes to the prioritized action.
+	insertInFlightTx(0, types.SenderTypeCommitBatch)
+
+	relayer.cfg.RollupTxPriority = ""
+	assert.True(t, relayer.hasRollupTxCapacity(types.SenderTypeCommitBatch))
+	assert.True(t, relayer.hasRollupTxCapacity(types.SenderTypeFinalizeBatch))
+
+	relayer.cfg.RollupTxPriority = config.RollupTxPriorityFinalize
+	assert.False(t, relayer.hasRollupTxCapacity(types.SenderTypeCommitBatch))
+	assert.True(t, relayer.hasRollupTxCapacity(types.SenderTypeFinalizeBatch))
+
+	relayer.cfg.RollupTxPriority = config.RollupTxPriorityCommit
+	assert.True(t, relayer.hasRollupTxCapacity(types.SenderTypeCommitBatch))
+	assert.False(t, relayer.hasRollupTxCapacity(types.SenderTypeFinalizeBatch))
+
+	// the prioritized action has nothing to send, the slot goes to the other one.
+	assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batchHashes[1], types.RollupCommitting))
+	assert.True(t, relayer.hasRollupTxCapacity(types.SenderTypeFinalizeBatch))
+
+	// no slot left.
+	insertInFlightTx(1, types.SenderTypeFinalizeBatch)
+	assert.False(t, relayer.hasRollupTxCapacity(types.SenderTypeCommitBatch))
+	assert.False(t, relayer.hasRollupTxCapacity(types.SenderTypeFinalizeBatch))
+}
+
+func testL2RelayerFinalizeTimeoutBatches(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	l2Cfg := cfg.L2Config
+	l2Cfg.RelayerConfig.EnableTestEnvBypassFeatures = true
+	l2Cfg.RelayerConfig.FinalizeBatchWithoutProofTimeoutSec = 0
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, l2Cfg.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+	batchMeta := &types.BatchMeta{
 		StartChunkIndex: 0,
 		StartChunkHash:  chunkHash1.Hex(),
-		EndChunkIndex:   0,
-		EndChunkHash:    chunkHash1.Hex(),
+		EndChunkIndex:   1,
+		EndChunkHash:    chunkHash2.Hex(),
 	}
 	batchOrm := orm.NewBatch(db)
-	batch1, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1}, batchMeta1)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
 	assert.NoError(t, err)
 
-	batchMeta2 := &types.BatchMeta{
-		StartChunkIndex: 1,
-		StartChunkHash:  chunkHash2.Hex(),
-		EndChunkIndex:   1,
-		EndChunkHash:    chunkHash2.Hex(),
-	}
-	batch2, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk2}, batchMeta2)
+	err = batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupCommitted)
 	assert.NoError(t, err)
 
+	// Check the database for the updated status using TryTimes.
+	ok := utils.TryTimes(5, func() bool {
+		relayer.ProcessCommittedBatches()
+		statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+		return err == nil && len(statuses) == 1 && statuses[0] == types.RollupFinalizing
+	})
+	assert.True(t, ok)
+}
+
+func testL2RelayerCommitConfirm(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
 	// Create and set up the Layer2 Relayer.
 	l2Cfg := cfg.L2Config
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	l2Relayer, err := NewLayer2Relayer(ctx, l2Cli, db, l2Cfg.RelayerConfig, false, ServiceTypeL2GasOracle, nil)
+	l2Relayer, err := NewLayer2Relayer(ctx, l2Cli, db, l2Cfg.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
 	assert.NoError(t, err)
 
 	// Simulate message confirmations.
-	type BatchConfirmation struct {
-		batchHash    string
-		isSuccessful bool
+	isSuccessful := []bool{true, false}
+	batchOrm := orm.NewBatch(db)
+	batchHashes := make([]string, len(isSuccessful))
+	for i := range batchHashes {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   1,
+			EndChunkHash:    chunkHash2.Hex(),
+		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+		assert.NoError(t, err)
+		batchHashes[i] = batch.Hash
+	}
+
+	for i, batchHash := range batchHashes {
+		l2Relayer.commitSender.SendConfirmation(&sender.Confirmation{
+			ContextID:    batchHash,
+			IsSuccessful: isSuccessful[i],
+			TxHash:       common.HexToHash("0x123456789abcdef"),
+			SenderType:   types.SenderTypeCommitBatch,
+		})
+	}
+
+	// Check the database for the updated status using TryTimes.
+	ok := utils.TryTimes(5, func() bool {
+		expectedStatuses := []types.RollupStatus{
+			types.RollupCommitted,
+			types.RollupCommitFailed,
+		}
+
+		for i, batchHash := range batchHashes {
+			batchInDB, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batchHash}, nil, 0)
+			if err != nil || len(batchInDB) != 1 || types.RollupStatus(batchInDB[0].RollupStatus) != expectedStatuses[i] {
+				return false
+			}
+		}
+		return true
+	})
+	assert.True(t, ok)
+}
+
+func testL2RelayerConfirmRecordL1Cost(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.RecordL1Cost = true
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l2Relayer, err := NewLayer2Relayer(ctx, l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	batchMeta := &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  chunkHash1.Hex(),
+		EndChunkIndex:   1,
+		EndChunkHash:    chunkHash2.Hex(),
+	}
+	batchOrm := orm.NewBatch(db)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+	assert.NoError(t, err)
+
+	// the commit tx carries blobs, the blob fee is part of the cost.
+	l2Relayer.handleConfirmation(&sender.Confirmation{
+		ContextID:    batch.Hash,
+		IsSuccessful: true,
+		TxHash:       common.HexToHash("0x123456789abcdef"),
+		SenderType:   types.SenderTypeCommitBatch,
+		Receipt: &gethTypes.Receipt{
+			Status:            gethTypes.ReceiptStatusSuccessful,
+			GasUsed:           200000,
+			EffectiveGasPrice: big.NewInt(30000000000),
+			BlobGasUsed:       131072,
+			BlobGasPrice:      big.NewInt(10),
+		},
+	})
+	batchInDB, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batch.Hash}, nil, 0)
+	assert.NoError(t, err)
+	assert.Len(t, batchInDB, 1)
+	assert.Equal(t, types.RollupCommitted, types.RollupStatus(batchInDB[0].RollupStatus))
+	assert.Equal(t, uint64(200000), batchInDB[0].CommitL1GasUsed)
+	assert.Equal(t, "6000000001310720", batchInDB[0].CommitL1Fee)
+	assert.Equal(t, uint64(0), batchInDB[0].FinalizeL1GasUsed)
+	assert.Equal(t, "0", batchInDB[0].FinalizeL1Fee)
+
+	// a failed finalize tx is paid for all the same.
+	l2Relayer.handleConfirmation(&sender.Confirmation{
+		ContextID:    batch.Hash,
+		IsSuccessful: false,
+		TxHash:       common.HexToHash("0x123456789abcdef0"),
+		SenderType:   types.SenderTypeFinalizeBatch,
+		Receipt: &gethTypes.Receipt{
+			Status:            gethTypes.ReceiptStatusFailed,
+			GasUsed:           300000,
+			EffectiveGasPrice: big.NewInt(20000000000),
+		},
+	})
+	batchInDB, err = batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batch.Hash}, nil, 0)
+	assert.NoError(t, err)
+	assert.Len(t, batchInDB, 1)
+	assert.Equal(t, types.RollupFinalizeFailed, types.RollupStatus(batchInDB[0].RollupStatus))
+	assert.Equal(t, uint64(300000), batchInDB[0].FinalizeL1GasUsed)
+	assert.Equal(t, "6000000000000000", batchInDB[0].FinalizeL1Fee)
+	assert.Equal(t, "6000000001310720", batchInDB[0].CommitL1Fee)
+
+	// the cost isn't recorded when the option is disabled.
+	l2Relayer.cfg.RecordL1Cost = false
+	l2Relayer.handleConfirmation(&sender.Confirmation{
+		ContextID:    batch.Hash,
+		IsSuccessful: true,
+		TxHash:       common.HexToHash("0x123456789abcdef1"),
+		SenderType:   types.SenderTypeFinalizeBatch,
+		Receipt: &gethTypes.Receipt{
+			Status:            gethTypes.ReceiptStatusSuccessful,
+			GasUsed:           400000,
+			EffectiveGasPrice: big.NewInt(20000000000),
+		},
+	})
+	batchInDB, err = batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batch.Hash}, nil, 0)
+	assert.NoError(t, err)
+	assert.Len(t, batchInDB, 1)
+	assert.Equal(t, types.RollupFinalized, types.RollupStatus(batchInDB[0].RollupStatus))
+	assert.Equal(t, uint64(300000), batchInDB[0].FinalizeL1GasUsed)
+}
+
+func testL2RelayerFinalizeConfirm(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	// Create and set up the Layer2 Relayer.
+	l2Cfg := cfg.L2Config
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l2Relayer, err := NewLayer2Relayer(ctx, l2Cli, db, l2Cfg.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	// Simulate message confirmations.
+	isSuccessful := []bool{true, false}
+	batchOrm := orm.NewBatch(db)
+	batchHashes := make([]string, len(isSuccessful))
+	for i := range batchHashes {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   1,
+			EndChunkHash:    chunkHash2.Hex(),
+		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+		assert.NoError(t, err)
+		batchHashes[i] = batch.Hash
+	}
+
+	for i, batchHash := range batchHashes {
+		l2Relayer.finalizeSender.SendConfirmation(&sender.Confirmation{
+			ContextID:    batchHash,
+			IsSuccessful: isSuccessful[i],
+			TxHash:       common.HexToHash("0x123456789abcdef"),
+			SenderType:   types.SenderTypeFinalizeBatch,
+		})
+	}
+
+	// Check the database for the updated status using TryTimes.
+	ok := utils.TryTimes(5, func() bool {
+		expectedStatuses := []types.RollupStatus{
+			types.RollupFinalized,
+			types.RollupFinalizeFailed,
+		}
+
+		for i, batchHash := range batchHashes {
+			batchInDB, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batchHash}, nil, 0)
+			if err != nil || len(batchInDB) != 1 || types.RollupStatus(batchInDB[0].RollupStatus) != expectedStatuses[i] {
+				return false
+			}
+		}
+		return true
+	})
+	assert.True(t, ok)
+}
+
+func testL2RelayerFinalizeConfirmVerifyEvent(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	// Create and set up the Layer2 Relayer with finalize event verification enabled.
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.VerifyFinalizeEvent = true
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l2Relayer, err := NewLayer2Relayer(ctx, l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	batchOrm := orm.NewBatch(db)
+	batchHashes := make([]string, 3)
+	for i := range batchHashes {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   1,
+			EndChunkHash:    chunkHash2.Hex(),
+		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+		assert.NoError(t, err)
+		batchHashes[i] = batch.Hash
+	}
+
+	finalizeBatchLog := func(batchHash string) *gethTypes.Log {
+		return &gethTypes.Log{
+			Address: relayerCfg.RollupContractAddress,
+			Topics: []common.Hash{
+				bridgeAbi.L1FinalizeBatchEventSignature,
+				common.BigToHash(big.NewInt(1)),
+				common.HexToHash(batchHash),
+			},
+		}
+	}
+	receipts := []*gethTypes.Receipt{
+		// the receipt contains the expected event.
+		{Status: gethTypes.ReceiptStatusSuccessful, Logs: []*gethTypes.Log{finalizeBatchLog(batchHashes[0])}},
+		// the receipt only contains the event of another batch.
+		{Status: gethTypes.ReceiptStatusSuccessful, Logs: []*gethTypes.Log{finalizeBatchLog(batchHashes[0])}},
+		// the receipt contains no event.
+		{Status: gethTypes.ReceiptStatusSuccessful},
+	}
+	for i, batchHash := range batchHashes {
+		l2Relayer.finalizeSender.SendConfirmation(&sender.Confirmation{
+			ContextID:    batchHash,
+			IsSuccessful: true,
+			TxHash:       common.HexToHash("0x123456789abcdef"),
+			SenderType:   types.SenderTypeFinalizeBatch,
+			Receipt:      receipts[i],
+		})
+	}
+
+	// Check the database for the updated status using TryTimes.
+	ok := utils.TryTimes(5, func() bool {
+		expectedStatuses := []types.RollupStatus{
+			types.RollupFinalized,
+			types.RollupFinalizeDiscrepancy,
+			types.RollupFinalizeDiscrepancy,
+		}
+
+		for i, batchHash := range batchHashes {
+			batchInDB, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batchHash}, nil, 0)
+			if err != nil || len(batchInDB) != 1 || types.RollupStatus(batchInDB[0].RollupStatus) != expectedStatuses[i] {
+				return false
+			}
+		}
+		return true
+	})
+	assert.True(t, ok)
+}
+
+func testL2RelayerFinalizeConfirmVerifyReceipt(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	// Create and set up the Layer2 Relayer with confirmation receipt verification enabled.
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.VerifyConfirmationReceipt = true
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l2Relayer, err := NewLayer2Relayer(ctx, l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	batchOrm := orm.NewBatch(db)
+	batchHashes := make([]string, 4)
+	for i := range batchHashes {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   1,
+			EndChunkHash:    chunkHash2.Hex(),
+		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+		assert.NoError(t, err)
+		batchHashes[i] = batch.Hash
+	}
+
+	blockHash := common.HexToHash("0xb1")
+	txHashes := []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02"), common.HexToHash("0x03"), common.HexToHash("0x04")}
+	refetched := map[common.Hash]*gethTypes.Receipt{
+		// the re-fetched receipt agrees with the confirmation.
+		txHashes[0]: {Status: gethTypes.ReceiptStatusSuccessful, BlockHash: blockHash},
+		// the re-fetched receipt reports a failed tx.
+		txHashes[1]: {Status: gethTypes.ReceiptStatusFailed, BlockHash: blockHash},
+		// the re-fetched receipt is included in another block.
+		txHashes[2]: {Status: gethTypes.ReceiptStatusSuccessful, BlockHash: common.HexToHash("0xb2")},
+		// the receipt of txHashes[3] is not found.
+	}
+	patchGuard := gomonkey.ApplyMethodFunc(&sender.Sender{}, "GetTransactionReceipt", func(txHash common.Hash) (*gethTypes.Receipt, error) {
+		receipt, ok := refetched[txHash]
+		if !ok {
+			return nil, ethereum.NotFound
+		}
+		return receipt, nil
+	})
+	defer patchGuard.Reset()
+
+	for i, batchHash := range batchHashes {
+		l2Relayer.handleConfirmation(&sender.Confirmation{
+			ContextID:    batchHash,
+			IsSuccessful: true,
+			TxHash:       txHashes[i],
+			SenderType:   types.SenderTypeFinalizeBatch,
+			Receipt:      &gethTypes.Receipt{Status: gethTypes.ReceiptStatusSuccessful, BlockHash: blockHash},
+		})
+	}
+
+	// only the confirmation agreeing with the re-fetched receipt is applied, the others are kept to re-check.
+	statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), batchHashes)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RollupStatus{types.RollupFinalized, types.RollupPending, types.RollupPending, types.RollupPending}, statuses)
+	assert.Len(t, l2Relayer.uncheckedConfirmations, 3)
+	assert.Equal(t, float64(2), testutil.ToFloat64(l2Relayer.metrics.rollupL2ConfirmationReceiptMismatchTotal))
+
+	// the receipt of txHashes[3] is found once the node catches up.
+	refetched[txHashes[3]] = &gethTypes.Receipt{Status: gethTypes.ReceiptStatusSuccessful, BlockHash: blockHash}
+	l2Relayer.recheckUncheckedConfirmations()
+
+	statuses, err = batchOrm.GetRollupStatusByHashList(context.Background(), batchHashes)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RollupStatus{types.RollupFinalized, types.RollupPending, types.RollupPending, types.RollupFinalized}, statuses)
+	assert.Len(t, l2Relayer.uncheckedConfirmations, 2)
+}
+
+// mockL1ReceiptAPI serves tx receipts as the eth namespace of a layer1 node.
+type mockL1ReceiptAPI struct {
+	mu       sync.Mutex
+	receipts map[common.Hash]*gethTypes.Receipt
+}
+
+func (api *mockL1ReceiptAPI) GetTransactionReceipt(txHash common.Hash) (*gethTypes.Receipt, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.receipts[txHash], nil
+}
+
+func (api *mockL1ReceiptAPI) setReceipt(txHash common.Hash, receipt *gethTypes.Receipt) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.receipts[txHash] = receipt
+}
+
+func testL2RelayerFinalizeConfirmQuorum(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	blockHash := common.HexToHash("0xb1")
+	txHashes := []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02"), common.HexToHash("0x03"), common.HexToHash("0x04")}
+	agreed := &gethTypes.Receipt{Status: gethTypes.ReceiptStatusSuccessful, BlockHash: blockHash, Logs: []*gethTypes.Log{}}
+	forked := &gethTypes.Receipt{Status: gethTypes.ReceiptStatusSuccessful, BlockHash: common.HexToHash("0xb2"), Logs: []*gethTypes.Log{}}
+	failed := &gethTypes.Receipt{Status: gethTypes.ReceiptStatusFailed, BlockHash: blockHash, Logs: []*gethTypes.Log{}}
+	nodes := []map[common.Hash]*gethTypes.Receipt{
+		// all the nodes agree on txHashes[0], two of them on txHashes[1], one of them on txHashes[2],
+		// and txHashes[3] failed on two of them.
+		{txHashes[0]: agreed, txHashes[1]: agreed, txHashes[2]: agreed, txHashes[3]: failed},
+		{txHashes[0]: agreed, txHashes[1]: agreed, txHashes[2]: forked, txHashes[3]: failed},
+		{txHashes[0]: agreed, txHashes[1]: forked, txHashes[3]: agreed},
+	}
+	var endpoints []string
+	apis := make([]*mockL1ReceiptAPI, len(nodes))
+	for i, receipts := range nodes {
+		apis[i] = &mockL1ReceiptAPI{receipts: receipts}
+		server := rpc.NewServer()
+		assert.NoError(t, server.RegisterName("eth", apis[i]))
+		httpServer := httptest.NewServer(server)
+		defer httpServer.Close()
+		endpoints = append(endpoints, httpServer.URL)
+	}
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.ConfirmationQuorumEndpoints = endpoints
+	relayerCfg.ConfirmationQuorum = len(endpoints) + 1
+	_, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.Error(t, err)
+
+	relayerCfg.ConfirmationQuorum = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l2Relayer, err := NewLayer2Relayer(ctx, l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	batchOrm := orm.NewBatch(db)
+	batchHashes := make([]string, len(txHashes))
+	for i := range batchHashes {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   1,
+			EndChunkHash:    chunkHash2.Hex(),
+		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+		assert.NoError(t, err)
+		batchHashes[i] = batch.Hash
+	}
+
+	for i, batchHash := range batchHashes {
+		l2Relayer.handleConfirmation(&sender.Confirmation{
+			ContextID:    batchHash,
+			IsSuccessful: true,
+			TxHash:       txHashes[i],
+			SenderType:   types.SenderTypeFinalizeBatch,
+			Receipt:      &gethTypes.Receipt{Status: gethTypes.ReceiptStatusSuccessful, BlockHash: blockHash},
+		})
+	}
+
+	// only the confirmations a quorum of the nodes agrees with are applied, the others are kept to re-check.
+	statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), batchHashes)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RollupStatus{types.RollupFinalized, types.RollupFinalized, types.RollupPending, types.RollupPending}, statuses)
+	assert.Equal(t, float64(2), testutil.ToFloat64(l2Relayer.metrics.rollupL2ConfirmationQuorumNotReachedTotal))
+	assert.Len(t, l2Relayer.uncheckedConfirmations, 2)
+
+	// the node missing txHashes[2] catches up, so a quorum agrees on it.
+	apis[2].setReceipt(txHashes[2], agreed)
+	l2Relayer.recheckUncheckedConfirmations()
+
+	statuses, err = batchOrm.GetRollupStatusByHashList(context.Background(), batchHashes)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RollupStatus{types.RollupFinalized, types.RollupFinalized, types.RollupFinalized, types.RollupPending}, statuses)
+	assert.Equal(t, float64(3), testutil.ToFloat64(l2Relayer.metrics.rollupL2ConfirmationQuorumNotReachedTotal))
+	assert.Len(t, l2Relayer.uncheckedConfirmations, 1)
+}
+
+type mockProofPublisher struct {
+	bundles chan *FinalizedBatchProof
+}
+
+func (p *mockProofPublisher) PublishProof(ctx context.Context, bundle *FinalizedBatchProof) error {
+	p.bundles <- bundle
+	return nil
+}
+
+func testL2RelayerFinalizeConfirmPublishProof(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l2Relayer, err := NewLayer2Relayer(ctx, l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+	publisher := &mockProofPublisher{bundles: make(chan *FinalizedBatchProof, 2)}
+	l2Relayer.SetProofPublisher(publisher)
+
+	batchOrm := orm.NewBatch(db)
+	proof := &message.BatchProof{
+		Proof: []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+	}
+	batchHashes := make([]string, 2)
+	for i := range batchHashes {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   1,
+			EndChunkHash:    chunkHash2.Hex(),
+		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+		assert.NoError(t, err)
+		assert.NoError(t, batchOrm.UpdateProvingStatus(context.Background(), batch.Hash, types.ProvingTaskVerified))
+		assert.NoError(t, batchOrm.UpdateProofByHash(context.Background(), batch.Hash, proof, 100))
+		batchHashes[i] = batch.Hash
+	}
+
+	// the first finalize tx succeeds, the second one fails.
+	finalizeTxHash := common.HexToHash("0x123456789abcdef")
+	for i, batchHash := range batchHashes {
+		l2Relayer.finalizeSender.SendConfirmation(&sender.Confirmation{
+			ContextID:    batchHash,
+			IsSuccessful: i == 0,
+			TxHash:       finalizeTxHash,
+			SenderType:   types.SenderTypeFinalizeBatch,
+		})
+	}
+
+	select {
+	case bundle := <-publisher.bundles:
+		assert.Equal(t, batchHashes[0], bundle.BatchHash)
+		assert.Equal(t, finalizeTxHash.String(), bundle.FinalizeTxHash)
+		assert.Equal(t, proof.Proof, bundle.Proof.Proof)
+	case <-time.After(5 * time.Second):
+		t.Fatal("proof of the finalized batch is not published")
+	}
+
+	// nothing is published for the failed finalization.
+	select {
+	case bundle := <-publisher.bundles:
+		t.Fatalf("unexpected proof published for batch %s", bundle.BatchHash)
+	case <-time.After(time.Second):
+	}
+}
+
+func testL2RelayerGasOracleConfirm(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	batchMeta1 := &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  chunkHash1.Hex(),
+		EndChunkIndex:   0,
+		EndChunkHash:    chunkHash1.Hex(),
+	}
+	batchOrm := orm.NewBatch(db)
+	batch1, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1}, batchMeta1)
+	assert.NoError(t, err)
+
+	batchMeta2 := &types.BatchMeta{
+		StartChunkIndex: 1,
+		StartChunkHash:  chunkHash2.Hex(),
+		EndChunkIndex:   1,
+		EndChunkHash:    chunkHash2.Hex(),
+	}
+	batch2, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk2}, batchMeta2)
+	assert.NoError(t, err)
+
+	// Create and set up the Layer2 Relayer.
+	l2Cfg := cfg.L2Config
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l2Relayer, err := NewLayer2Relayer(ctx, l2Cli, db, l2Cfg.RelayerConfig, false, ServiceTypeL2GasOracle, nil)
+	assert.NoError(t, err)
+
+	// Simulate message confirmations.
+	type BatchConfirmation struct {
+		batchHash    string
+		isSuccessful bool
+	}
+
+	confirmations := []BatchConfirmation{
+		{batchHash: batch1.Hash, isSuccessful: true},
+		{batchHash: batch2.Hash, isSuccessful: false},
+	}
+
+	for _, confirmation := range confirmations {
+		l2Relayer.gasOracleSender.SendConfirmation(&sender.Confirmation{
+			ContextID:    confirmation.batchHash,
+			IsSuccessful: confirmation.isSuccessful,
+			SenderType:   types.SenderTypeL2GasOracle,
+		})
+	}
+	// Check the database for the updated status using TryTimes.
+	ok := utils.TryTimes(5, func() bool {
+		expectedStatuses := []types.GasOracleStatus{types.GasOracleImported, types.GasOracleImportedFailed}
+		for i, confirmation := range confirmations {
+			gasOracle, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": confirmation.batchHash}, nil, 0)
+			if err != nil || len(gasOracle) != 1 || types.GasOracleStatus(gasOracle[0].OracleStatus) != expectedStatuses[i] {
+				return false
+			}
+		}
+		return true
+	})
+	assert.True(t, ok)
+}
+
+func testL2RelayerRecoverImportingGasOracle(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	batchOrm := orm.NewBatch(db)
+	batchHashes := make([]string, 4)
+	for i := range batchHashes {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   0,
+			EndChunkHash:    chunkHash1.Hex(),
+		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1}, batchMeta)
+		assert.NoError(t, err)
+		batchHashes[i] = batch.Hash
+	}
+
+	newTx := func(nonce uint64, gasFeeCap int64) *gethTypes.Transaction {
+		return gethTypes.NewTx(&gethTypes.DynamicFeeTx{
+			Nonce:     nonce,
+			To:        &common.Address{},
+			Gas:       21000,
+			Value:     big.NewInt(0),
+			ChainID:   big.NewInt(1),
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(gasFeeCap),
+		})
+	}
+	var (
+		replacedTx  = newTx(0, 10) // replaced by confirmedTx, never mined
+		confirmedTx = newTx(0, 20)
+		failedTx    = newTx(1, 10)
+		lostTx      = newTx(2, 10)
+		inFlightTx  = newTx(3, 10)
+	)
+	senderMeta := &orm.SenderMeta{
+		Name:    "gas_oracle_sender",
+		Service: "l2_relayer",
+		Address: common.HexToAddress("0x1"),
+		Type:    types.SenderTypeL2GasOracle,
+	}
+	pendingTransactionOrm := orm.NewPendingTransaction(db)
+	// lostTx is lost before being recorded by the sender.
+	assert.NoError(t, pendingTransactionOrm.InsertPendingTransaction(context.Background(), batchHashes[0], senderMeta, replacedTx, 0))
+	assert.NoError(t, pendingTransactionOrm.InsertPendingTransaction(context.Background(), batchHashes[0], senderMeta, confirmedTx, 0))
+	assert.NoError(t, pendingTransactionOrm.InsertPendingTransaction(context.Background(), batchHashes[1], senderMeta, failedTx, 0))
+	assert.NoError(t, pendingTransactionOrm.InsertPendingTransaction(context.Background(), batchHashes[3], senderMeta, inFlightTx, 0))
+	assert.NoError(t, pendingTransactionOrm.UpdatePendingTransactionStatusByTxHash(context.Background(), replacedTx.Hash(), types.TxStatusConfirmedFailed))
+	assert.NoError(t, pendingTransactionOrm.UpdatePendingTransactionStatusByTxHash(context.Background(), confirmedTx.Hash(), types.TxStatusConfirmed))
+	assert.NoError(t, pendingTransactionOrm.UpdatePendingTransactionStatusByTxHash(context.Background(), failedTx.Hash(), types.TxStatusConfirmed))
+
+	// the confirmations were lost by a crash before the batches were updated.
+	for i, tx := range []*gethTypes.Transaction{replacedTx, failedTx, lostTx, inFlightTx} {
+		assert.NoError(t, batchOrm.UpdateL2GasOracleStatusAndOracleTxHash(context.Background(), batchHashes[i], types.GasOracleImporting, tx.Hash().String()))
+	}
+
+	receipts := map[common.Hash]*gethTypes.Receipt{
+		confirmedTx.Hash(): {Status: gethTypes.ReceiptStatusSuccessful},
+		failedTx.Hash():    {Status: gethTypes.ReceiptStatusFailed},
+		inFlightTx.Hash():  {Status: gethTypes.ReceiptStatusSuccessful},
+	}
+	patchGuard := gomonkey.ApplyMethodFunc(&sender.Sender{}, "GetTransactionReceipt", func(txHash common.Hash) (*gethTypes.Receipt, error) {
+		receipt, ok := receipts[txHash]
+		if !ok {
+			return nil, ethereum.NotFound
+		}
+		return receipt, nil
+	})
+	defer patchGuard.Reset()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := NewLayer2Relayer(ctx, l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2GasOracle, nil)
+	assert.NoError(t, err)
+
+	expected := []struct {
+		status       types.GasOracleStatus
+		oracleTxHash string
+	}{
+		{types.GasOracleImported, confirmedTx.Hash().String()},
+		{types.GasOracleImportedFailed, failedTx.Hash().String()},
+		{types.GasOraclePending, ""},
+		// left to the confirmation of the sender.
+		{types.GasOracleImporting, inFlightTx.Hash().String()},
+	}
+	for i, batchHash := range batchHashes {
+		batches, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batchHash}, nil, 0)
+		assert.NoError(t, err)
+		assert.Len(t, batches, 1)
+		assert.Equal(t, expected[i].status, types.GasOracleStatus(batches[0].OracleStatus))
+		assert.Equal(t, expected[i].oracleTxHash, batches[0].OracleTxHash)
+	}
+}
+
+func testLayer2RelayerProcessGasPriceOracle(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2GasOracle, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, relayer)
+
+	var batchOrm *orm.Batch
+	convey.Convey("Failed to GetLatestBatch", t, func() {
+		targetErr := errors.New("GetLatestBatch error")
+		patchGuard := gomonkey.ApplyMethodFunc(batchOrm, "GetLatestBatch", func(context.Context) (*orm.Batch, error) {
+			return nil, targetErr
+		})
+		defer patchGuard.Reset()
+		relayer.ProcessGasPriceOracle()
+	})
+
+	patchGuard := gomonkey.ApplyMethodFunc(batchOrm, "GetLatestBatch", func(context.Context) (*orm.Batch, error) {
+		batch := orm.Batch{
+			OracleStatus: int16(types.GasOraclePending),
+			Hash:         "0x0000000000000000000000000000000000000000",
+		}
+		return &batch, nil
+	})
+	defer patchGuard.Reset()
+
+	convey.Convey("Failed to fetch SuggestGasPrice from l2geth", t, func() {
+		targetErr := errors.New("SuggestGasPrice error")
+		patchGuard.ApplyMethodFunc(relayer.l2Client, "SuggestGasPrice", func(ctx context.Context) (*big.Int, error) {
+			return nil, targetErr
+		})
+		relayer.ProcessGasPriceOracle()
+	})
+
+	patchGuard.ApplyMethodFunc(relayer.l2Client, "SuggestGasPrice", func(ctx context.Context) (*big.Int, error) {
+		return big.NewInt(100), nil
+	})
+
+	convey.Convey("Failed to pack setL2BaseFee", t, func() {
+		targetErr := errors.New("setL2BaseFee error")
+		patchGuard.ApplyMethodFunc(relayer.l2GasOracleABI, "Pack", func(name string, args ...interface{}) ([]byte, error) {
+			return nil, targetErr
+		})
+		relayer.ProcessGasPriceOracle()
+	})
+
+	patchGuard.ApplyMethodFunc(relayer.l2GasOracleABI, "Pack", func(name string, args ...interface{}) ([]byte, error) {
+		return nil, nil
+	})
+
+	convey.Convey("Failed to send setL2BaseFee tx to layer2", t, func() {
+		targetErr := errors.New("failed to send setL2BaseFee tx to layer2 error")
+		patchGuard.ApplyMethodFunc(relayer.gasOracleSender, "SendTransaction", func(ContextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (hash common.Hash, err error) {
+			return common.Hash{}, targetErr
+		})
+		relayer.ProcessGasPriceOracle()
+	})
+
+	patchGuard.ApplyMethodFunc(relayer.gasOracleSender, "SendTransaction", func(ContextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (hash common.Hash, err error) {
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+
+	convey.Convey("UpdateGasOracleStatusAndOracleTxHash failed", t, func() {
+		targetErr := errors.New("UpdateL2GasOracleStatusAndOracleTxHash error")
+		patchGuard.ApplyMethodFunc(batchOrm, "UpdateL2GasOracleStatusAndOracleTxHash", func(ctx context.Context, hash string, status types.GasOracleStatus, txHash string) error {
+			return targetErr
+		})
+		relayer.ProcessGasPriceOracle()
+	})
+
+	patchGuard.ApplyMethodFunc(batchOrm, "UpdateL2GasOracleStatusAndOracleTxHash", func(ctx context.Context, hash string, status types.GasOracleStatus, txHash string) error {
+		return nil
+	})
+	relayer.ProcessGasPriceOracle()
+}
+
+func testLayer2RelayerProcessGasPriceOracleMinInterval(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.GasOracleConfig = &config.GasOracleConfig{
+		MinGasPrice:           0,
+		GasPriceDiff:          50000,  // 5%
+		MinUpdateIntervalSec:  300,    // 5 minutes
+		EmergencyGasPriceDiff: 500000, // 50%
+	}
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2GasOracle, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, relayer)
+
+	var batchOrm *orm.Batch
+	patchGuard := gomonkey.ApplyMethodFunc(batchOrm, "GetLatestBatch", func(context.Context) (*orm.Batch, error) {
+		batch := orm.Batch{
+			OracleStatus: int16(types.GasOraclePending),
+			Hash:         "0x0000000000000000000000000000000000000000",
+		}
+		return &batch, nil
+	})
+	defer patchGuard.Reset()
+
+	var gasPrice int64
+	patchGuard.ApplyMethodFunc(relayer.l2Client, "SuggestGasPrice", func(ctx context.Context) (*big.Int, error) {
+		return big.NewInt(gasPrice), nil
+	})
+	var sentCount int
+	patchGuard.ApplyMethodFunc(relayer.gasOracleSender, "SendTransaction", func(ContextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (hash common.Hash, err error) {
+		sentCount++
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+	patchGuard.ApplyMethodFunc(batchOrm, "UpdateL2GasOracleStatusAndOracleTxHash", func(ctx context.Context, hash string, status types.GasOracleStatus, txHash string) error {
+		return nil
+	})
+
+	// the first update is always pushed.
+	gasPrice = 100
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, 1, sentCount)
+	assert.Equal(t, uint64(100), relayer.lastGasPrice)
+
+	// a diff exceeding gas_price_diff is deferred within the interval.
+	gasPrice = 120
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, 1, sentCount)
+	assert.Equal(t, uint64(100), relayer.lastGasPrice)
+
+	// a diff exceeding emergency_gas_price_diff overrides the interval.
+	gasPrice = 200
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, 2, sentCount)
+	assert.Equal(t, uint64(200), relayer.lastGasPrice)
+
+	gasPrice = 50
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, 3, sentCount)
+	assert.Equal(t, uint64(50), relayer.lastGasPrice)
+
+	// the update is pushed once the interval passed.
+	relayer.lastGasPriceUpdateTime = time.Now().Add(-301 * time.Second)
+	gasPrice = 60
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, 4, sentCount)
+	assert.Equal(t, uint64(60), relayer.lastGasPrice)
+}
+
+func testLayer2RelayerProcessGasPriceOracleSkipReads(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.GasOracleConfig = &config.GasOracleConfig{
+		MinGasPrice:          0,
+		GasPriceDiff:         50000, // 5%
+		MinUpdateIntervalSec: 300,   // 5 minutes
+	}
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2GasOracle, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, relayer)
+
+	var batchOrm *orm.Batch
+	var getBatchCount int
+	patchGuard := gomonkey.ApplyMethodFunc(batchOrm, "GetLatestBatch", func(context.Context) (*orm.Batch, error) {
+		getBatchCount++
+		batch := orm.Batch{
+			OracleStatus: int16(types.GasOraclePending),
+			Hash:         "0x0000000000000000000000000000000000000000",
+		}
+		return &batch, nil
+	})
+	defer patchGuard.Reset()
+
+	var suggestCount int
+	patchGuard.ApplyMethodFunc(relayer.l2Client, "SuggestGasPrice", func(ctx context.Context) (*big.Int, error) {
+		suggestCount++
+		return big.NewInt(200), nil
+	})
+	var sentCount int
+	patchGuard.ApplyMethodFunc(relayer.gasOracleSender, "SendTransaction", func(ContextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (hash common.Hash, err error) {
+		sentCount++
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+	patchGuard.ApplyMethodFunc(batchOrm, "UpdateL2GasOracleStatusAndOracleTxHash", func(ctx context.Context, hash string, status types.GasOracleStatus, txHash string) error {
+		return nil
+	})
+
+	// neither the db nor l2geth is read within the min update interval.
+	relayer.lastGasPrice = 100
+	relayer.lastGasPriceUpdateTime = time.Now()
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, 0, getBatchCount)
+	assert.Equal(t, 0, suggestCount)
+	assert.Equal(t, 0, sentCount)
+
+	// the reads resume once the interval passed.
+	relayer.lastGasPriceUpdateTime = time.Now().Add(-301 * time.Second)
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, 1, getBatchCount)
+	assert.Equal(t, 1, suggestCount)
+	assert.Equal(t, 1, sentCount)
+	assert.Equal(t, uint64(200), relayer.lastGasPrice)
+
+	// the update just pushed starts a new interval.
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, 1, getBatchCount)
+	assert.Equal(t, 1, suggestCount)
+}
+
+func mockChainMonitorServer(baseURL string) (*http.Server, error) {
+	router := gin.New()
+	r := router.Group("/v1")
+	r.GET("/batch_status", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, struct {
+			ErrCode int    `json:"errcode"`
+			ErrMsg  string `json:"errmsg"`
+			Data    bool   `json:"data"`
+		}{
+			ErrCode: 0,
+			ErrMsg:  "",
+			Data:    true,
+		})
+	})
+	return utils.StartHTTPServer(strings.Split(baseURL, "//")[1], router)
+}
+
+func testGetBatchStatusByIndex(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	l2BlockOrm := orm.NewL2Block(db)
+	err := l2BlockOrm.InsertL2Blocks(context.Background(), []*types.WrappedBlock{wrappedBlock1, wrappedBlock2})
+	assert.NoError(t, err)
+	chunkOrm := orm.NewChunk(db)
+	dbChunk1, err := chunkOrm.InsertChunk(context.Background(), chunk1)
+	assert.NoError(t, err)
+	dbChunk2, err := chunkOrm.InsertChunk(context.Background(), chunk2)
+	assert.NoError(t, err)
+	batchMeta := &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  dbChunk1.Hash,
+		EndChunkIndex:   1,
+		EndChunkHash:    dbChunk2.Hash,
+	}
+	batchOrm := orm.NewBatch(db)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+	assert.NoError(t, err)
+
+	cfg.L2Config.RelayerConfig.ChainMonitor.Enabled = true
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, relayer)
+
+	status, err := relayer.getBatchStatusByIndex(batch)
+	assert.NoError(t, err)
+	assert.Equal(t, true, status)
+}
+
+func testL2RelayerExportState(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	batchOrm := orm.NewBatch(db)
+	batch1, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1}, &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  chunkHash1.Hex(),
+		EndChunkIndex:   0,
+		EndChunkHash:    chunkHash1.Hex(),
+	})
+	assert.NoError(t, err)
+	batch2, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk2}, &types.BatchMeta{
+		StartChunkIndex: 1,
+		StartChunkHash:  chunkHash2.Hex(),
+		EndChunkIndex:   1,
+		EndChunkHash:    chunkHash2.Hex(),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, batchOrm.UpdateCommitTxHashAndRollupStatus(context.Background(), batch1.Hash, "0x1234", types.RollupCommitted))
+	assert.NoError(t, batchOrm.UpdateProvingStatus(context.Background(), batch1.Hash, types.ProvingTaskVerified))
+	proof := &message.BatchProof{
+		Proof: []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+	}
+	assert.NoError(t, batchOrm.UpdateProofByHash(context.Background(), batch1.Hash, proof, 100))
+
+	var buf bytes.Buffer
+	exported, err := relayer.ExportState(&buf, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), exported)
+
+	// one batch state per line, matching the db.
+	var states []*BatchState
+	scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+	for scanner.Scan() {
+		var state BatchState
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &state))
+		states = append(states, &state)
+	}
+	assert.Len(t, states, 2)
+	assert.Equal(t, uint64(0), states[0].Index)
+	assert.Equal(t, batch1.Hash, states[0].Hash)
+	assert.Equal(t, types.RollupCommitted.String(), states[0].RollupStatus)
+	assert.Equal(t, "0x1234", states[0].CommitTxHash)
+	assert.NotNil(t, states[0].CommittedAt)
+	assert.Equal(t, types.ProvingTaskVerified.String(), states[0].ProvingStatus)
+	assert.True(t, states[0].ProofAvailable)
+	assert.Equal(t, uint64(1), states[1].Index)
+	assert.Equal(t, batch2.Hash, states[1].Hash)
+	assert.Equal(t, batch1.Hash, states[1].ParentBatchHash)
+	assert.Equal(t, types.RollupPending.String(), states[1].RollupStatus)
+	assert.False(t, states[1].ProofAvailable)
+
+	// the export is verified against the db.
+	verified, err := VerifyBatchStates(context.Background(), db, bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), verified)
+
+	// a sub range is exported.
+	var sub bytes.Buffer
+	exported, err = relayer.ExportState(&sub, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), exported)
+
+	// the verification fails once the db diverges from the export.
+	assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batch2.Hash, types.RollupCommitting))
+	verified, err = VerifyBatchStates(context.Background(), db, bytes.NewReader(buf.Bytes()))
+	assert.Error(t, err)
+	assert.Equal(t, uint64(1), verified)
+
+	_, err = relayer.ExportState(&sub, 2, 1)
+	assert.Error(t, err)
+}
+
+func testL2RelayerCircuitBreaker(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.CircuitBreakerThreshold = 2
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	l2BlockOrm := orm.NewL2Block(db)
+	err = l2BlockOrm.InsertL2Blocks(context.Background(), []*types.WrappedBlock{wrappedBlock1, wrappedBlock2})
+	assert.NoError(t, err)
+	chunkOrm := orm.NewChunk(db)
+	dbChunk1, err := chunkOrm.InsertChunk(context.Background(), chunk1)
+	assert.NoError(t, err)
+	dbChunk2, err := chunkOrm.InsertChunk(context.Background(), chunk2)
+	assert.NoError(t, err)
+	batchOrm := orm.NewBatch(db)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  dbChunk1.Hash,
+		EndChunkIndex:   1,
+		EndChunkHash:    dbChunk2.Hash,
+	})
+	assert.NoError(t, err)
+
+	var sentCount int
+	patchGuard := gomonkey.ApplyMethodFunc(relayer.commitSender, "SendTransaction", func(contextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (common.Hash, error) {
+		sentCount++
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+	defer patchGuard.Reset()
+
+	failCommit := func() {
+		relayer.handleConfirmation(&sender.Confirmation{
+			ContextID:    batch.Hash,
+			IsSuccessful: false,
+			TxHash:       common.HexToHash("0x123456789abcdef"),
+			SenderType:   types.SenderTypeCommitBatch,
+		})
+	}
+
+	// the failed batch is re-committed before the threshold.
+	failCommit()
+	relayer.ProcessPendingBatches()
+	assert.Equal(t, 1, sentCount)
+
+	// committing is halted once the threshold is reached.
+	failCommit()
+	relayer.ProcessPendingBatches()
+	relayer.ProcessPendingBatches()
+	assert.Equal(t, 1, sentCount)
+	assert.Equal(t, float64(1), testutil.ToFloat64(relayer.metrics.rollupL2CommitCircuitBreakerTrippedTotal))
+	statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RollupStatus{types.RollupCommitFailed}, statuses)
+
+	// finalizing isn't halted by the commit failures.
+	assert.True(t, relayer.finalizeBreaker.allow())
+
+	// committing is resumed after the reset.
+	relayer.ResetCircuitBreakers()
+	relayer.ProcessPendingBatches()
+	assert.Equal(t, 2, sentCount)
+}
+
+func testL2RelayerPauseOnStaleL2Head(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.MaxL2HeadAgeSec = 60
+	relayerCfg.GasOracleConfig = &config.GasOracleConfig{GasPriceDiff: 50000}
+
+	headTime := time.Now().Add(-time.Hour)
+	patchGuard := gomonkey.ApplyMethodFunc(l2Cli, "HeaderByNumber", func(ctx context.Context, number *big.Int) (*gethTypes.Header, error) {
+		return &gethTypes.Header{Number: big.NewInt(100), Time: uint64(headTime.Unix())}, nil
+	})
+	defer patchGuard.Reset()
+
+	convey.Convey("commit is paused while the l2 head is stale", t, func() {
+		relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+		assert.NoError(t, err)
+
+		l2BlockOrm := orm.NewL2Block(db)
+		err = l2BlockOrm.InsertL2Blocks(context.Background(), []*types.WrappedBlock{wrappedBlock1, wrappedBlock2})
+		assert.NoError(t, err)
+		chunkOrm := orm.NewChunk(db)
+		dbChunk1, err := chunkOrm.InsertChunk(context.Background(), chunk1)
+		assert.NoError(t, err)
+		dbChunk2, err := chunkOrm.InsertChunk(context.Background(), chunk2)
+		assert.NoError(t, err)
+		batchOrm := orm.NewBatch(db)
+		_, err = batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  dbChunk1.Hash,
+			EndChunkIndex:   1,
+			EndChunkHash:    dbChunk2.Hash,
+		})
+		assert.NoError(t, err)
+
+		var sentCount int
+		sendPatch := gomonkey.ApplyMethodFunc(relayer.commitSender, "SendTransaction", func(contextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (common.Hash, error) {
+			sentCount++
+			return common.HexToHash("0x56789abcdef1234"), nil
+		})
+		defer sendPatch.Reset()
+
+		headTime = time.Now().Add(-time.Hour)
+		relayer.ProcessPendingBatches()
+		assert.Equal(t, 0, sentCount)
+		assert.True(t, relayer.l2HeadStale)
+
+		// committing resumes once the l2 head is fresh.
+		headTime = time.Now()
+		relayer.ProcessPendingBatches()
+		assert.Equal(t, 1, sentCount)
+		assert.False(t, relayer.l2HeadStale)
+	})
+
+	convey.Convey("gas oracle is paused while the l2 head is stale", t, func() {
+		relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2GasOracle, nil)
+		assert.NoError(t, err)
+
+		var batchOrm *orm.Batch
+		oraclePatch := gomonkey.ApplyMethodFunc(batchOrm, "GetLatestBatch", func(context.Context) (*orm.Batch, error) {
+			return &orm.Batch{OracleStatus: int16(types.GasOraclePending), Hash: "0x0000000000000000000000000000000000000000"}, nil
+		})
+		defer oraclePatch.Reset()
+		oraclePatch.ApplyMethodFunc(relayer.l2Client, "SuggestGasPrice", func(ctx context.Context) (*big.Int, error) {
+			return big.NewInt(200), nil
+		})
+		var sentCount int
+		oraclePatch.ApplyMethodFunc(relayer.gasOracleSender, "SendTransaction", func(ContextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (hash common.Hash, err error) {
+			sentCount++
+			return common.HexToHash("0x56789abcdef1234"), nil
+		})
+		oraclePatch.ApplyMethodFunc(batchOrm, "UpdateL2GasOracleStatusAndOracleTxHash", func(ctx context.Context, hash string, status types.GasOracleStatus, txHash string) error {
+			return nil
+		})
+
+		headTime = time.Now().Add(-time.Hour)
+		relayer.ProcessGasPriceOracle()
+		assert.Equal(t, 0, sentCount)
+
+		headTime = time.Now()
+		relayer.ProcessGasPriceOracle()
+		assert.Equal(t, 1, sentCount)
+	})
+}
+
+func testL2RelayerFinalizeIndexRegression(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.CheckFinalizeIndexRegression = true
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	batchOrm := orm.NewBatch(db)
+	var hashes []string
+	for i := 0; i < 3; i++ {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   1,
+			EndChunkHash:    chunkHash2.Hex(),
+		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+		assert.NoError(t, err)
+		assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupCommitted))
+		assert.NoError(t, batchOrm.UpdateProvingStatus(context.Background(), batch.Hash, types.ProvingTaskVerified))
+		hashes = append(hashes, batch.Hash)
+	}
+
+	// the batch 2 is finalized out of order, the batch 0 mustn't be finalized after it.
+	assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), hashes[2], types.RollupFinalized))
+	regressions := testutil.ToFloat64(relayer.metrics.rollupL2FinalizeIndexRegressionTotal)
+	relayer.ProcessCommittedBatches()
+	assert.Equal(t, regressions+1, testutil.ToFloat64(relayer.metrics.rollupL2FinalizeIndexRegressionTotal))
+
+	statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), hashes[:1])
+	assert.NoError(t, err)
+	assert.Equal(t, types.RollupCommitted, statuses[0])
+}
+
+func testL2RelayerGasOracleRetry(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	batchMeta := &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  chunkHash1.Hex(),
+		EndChunkIndex:   0,
+		EndChunkHash:    chunkHash1.Hex(),
+	}
+	batchOrm := orm.NewBatch(db)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1}, batchMeta)
+	assert.NoError(t, err)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.GasOracleConfig = &config.GasOracleConfig{GasPriceDiff: 50000, GasOracleMaxRetry: 2}
+	l2Relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2GasOracle, nil)
+	assert.NoError(t, err)
+
+	oracleStatus := func() types.GasOracleStatus {
+		batches, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batch.Hash}, nil, 0)
+		assert.NoError(t, err)
+		assert.Len(t, batches, 1)
+		return types.GasOracleStatus(batches[0].OracleStatus)
+	}
+
+	cfm := &sender.Confirmation{
+		ContextID:    batch.Hash,
+		IsSuccessful: false,
+		SenderType:   types.SenderTypeL2GasOracle,
+		TxHash:       common.HexToHash("0x56789abcdef1234"),
+	}
+	retried := testutil.ToFloat64(l2Relayer.metrics.rollupL2UpdateGasOracleRetriedTotal)
+
+	// the failed update is moved back to pending until the retries are exhausted.
+	for i := 1; i <= 2; i++ {
+		l2Relayer.handleConfirmation(cfm)
+		assert.Equal(t, types.GasOraclePending, oracleStatus())
+		assert.Equal(t, uint64(i), l2Relayer.gasOracleFailedRetries[batch.Hash])
+		assert.Equal(t, retried+float64(i), testutil.ToFloat64(l2Relayer.metrics.rollupL2UpdateGasOracleRetriedTotal))
+		assert.True(t, l2Relayer.gasOracleRetrying.Load())
+	}
+
+	l2Relayer.handleConfirmation(cfm)
+	assert.Equal(t, types.GasOracleImportedFailed, oracleStatus())
+	assert.NotContains(t, l2Relayer.gasOracleFailedRetries, batch.Hash)
+	assert.Equal(t, retried+2, testutil.ToFloat64(l2Relayer.metrics.rollupL2UpdateGasOracleRetriedTotal))
+
+	// the retry is sent regardless of the gas price diff to the last gas price.
+	l2Relayer.lastGasPrice = 200
+	l2Relayer.lastGasPriceUpdateTime = time.Now()
+	assert.NoError(t, batchOrm.UpdateL2GasOracleStatusAndOracleTxHash(context.Background(), batch.Hash, types.GasOraclePending, ""))
+	patchGuard := gomonkey.ApplyMethodFunc(l2Relayer.l2Client, "SuggestGasPrice", func(ctx context.Context) (*big.Int, error) {
+		return big.NewInt(200), nil
+	})
+	defer patchGuard.Reset()
+	var sentCount int
+	patchGuard.ApplyMethodFunc(l2Relayer.gasOracleSender, "SendTransaction", func(contextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (common.Hash, error) {
+		sentCount++
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+	l2Relayer.ProcessGasPriceOracle()
+	assert.Equal(t, 1, sentCount)
+	assert.False(t, l2Relayer.gasOracleRetrying.Load())
+	assert.Equal(t, types.GasOracleImporting, oracleStatus())
+
+	// the gas price is unchanged, no update is sent without a retry.
+	assert.NoError(t, batchOrm.UpdateL2GasOracleStatusAndOracleTxHash(context.Background(), batch.Hash, types.GasOraclePending, ""))
+	l2Relayer.ProcessGasPriceOracle()
+	assert.Equal(t, 1, sentCount)
+}
+
+func testL2RelayerRecoverUnknownConfirmation(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.RecoverUnknownConfirmations = true
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	batchMeta := &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  chunkHash1.Hex(),
+		EndChunkIndex:   1,
+		EndChunkHash:    chunkHash2.Hex(),
+	}
+	batchOrm := orm.NewBatch(db)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+	assert.NoError(t, err)
+	assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupCommitting))
+
+	// the commit tx of the batch is known to the db, but the confirmation lost its sender type and context id.
+	commitTx := gethTypes.NewTx(&gethTypes.DynamicFeeTx{
+		To:        &common.Address{},
+		Gas:       21000,
+		Value:     big.NewInt(0),
+		ChainID:   big.NewInt(1),
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(10),
+	})
+	senderMeta := &orm.SenderMeta{
+		Name:    "commit_sender",
+		Service: "l2_relayer",
+		Address: common.HexToAddress("0x1"),
+		Type:    types.SenderTypeCommitBatch,
+	}
+	pendingTransactionOrm := orm.NewPendingTransaction(db)
+	assert.NoError(t, pendingTransactionOrm.InsertPendingTransaction(context.Background(), batch.Hash, senderMeta, commitTx, 0))
+
+	unknown := testutil.ToFloat64(relayer.metrics.rollupL2UnknownConfirmationTotal)
+	recovered := testutil.ToFloat64(relayer.metrics.rollupL2UnknownConfirmationRecoveredTotal)
+
+	// a confirmation of an unknown tx is only counted.
+	relayer.handleConfirmation(&sender.Confirmation{IsSuccessful: true, TxHash: common.HexToHash("0x1234"), SenderType: types.SenderTypeUnknown})
+	assert.Equal(t, unknown+1, testutil.ToFloat64(relayer.metrics.rollupL2UnknownConfirmationTotal))
+	assert.Equal(t, recovered, testutil.ToFloat64(relayer.metrics.rollupL2UnknownConfirmationRecoveredTotal))
+
+	relayer.handleConfirmation(&sender.Confirmation{IsSuccessful: true, TxHash: commitTx.Hash(), SenderType: types.SenderTypeUnknown})
+	assert.Equal(t, unknown+2, testutil.ToFloat64(relayer.metrics.rollupL2UnknownConfirmationTotal))
+	assert.Equal(t, recovered+1, testutil.ToFloat64(relayer.metrics.rollupL2UnknownConfirmationRecoveredTotal))
+
+	batches, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batch.Hash}, nil, 0)
+	assert.NoError(t, err)
+	assert.Len(t, batches, 1)
+	assert.Equal(t, types.RollupCommitted, types.RollupStatus(batches[0].RollupStatus))
+	assert.Equal(t, commitTx.Hash().String(), batches[0].CommitTxHash)
+}
+
+func testL2RelayerStop(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	batchMeta := &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  chunkHash1.Hex(),
+		EndChunkIndex:   1,
+		EndChunkHash:    chunkHash2.Hex(),
+	}
+	batchOrm := orm.NewBatch(db)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+	assert.NoError(t, err)
+
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	var stopped int
+	patchGuard := gomonkey.ApplyMethodFunc(relayer.commitSender, "Stop", func() {
+		stopped++
+	})
+	defer patchGuard.Reset()
+
+	relayer.Stop()
+	// the senders are only stopped once.
+	relayer.Stop()
+	assert.Equal(t, len(relayer.senders()), stopped)
+
+	// the confirmation loop has returned, a confirmation queued after Stop is left to the next run.
+	relayer.commitSender.SendConfirmation(&sender.Confirmation{
+		ContextID:    batch.Hash,
+		IsSuccessful: true,
+		TxHash:       common.HexToHash("0x56789abcdef1234"),
+		SenderType:   types.SenderTypeCommitBatch,
+	})
+	time.Sleep(100 * time.Millisecond)
+	statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+	assert.NoError(t, err)
+	assert.Equal(t, types.RollupPending, statuses[0])
+
+	// the queued confirmation is handled by the flush on stop.
+	relayer.flushConfirmations(relayer.commitSender)
+	statuses, err = batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+	assert.NoError(t, err)
+	assert.Equal(t, types.RollupCommitted, statuses[0])
+}
+
+func testL2RelayerFinalizeInterval(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.FinalizeBatchIntervalSec = 60
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	batchOrm := orm.NewBatch(db)
+	var hashes []string
+	for i := 0; i < 3; i++ {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   1,
+			EndChunkHash:    chunkHash2.Hex(),
+		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+		assert.NoError(t, err)
+		hashes = append(hashes, batch.Hash)
+	}
+
+	// no batch is finalized yet, the first batch is finalized right away.
+	assert.True(t, relayer.finalizeIntervalElapsed())
+
+	// the batch 0 was just finalized, the next batch waits for the interval.
+	assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), hashes[0], types.RollupFinalized))
+	assert.False(t, relayer.finalizeIntervalElapsed())
+
+	// the batch 0 was finalized long ago, the backlog is finalized although the batches were just created.
+	finalizedAt := utils.NowUTC().Add(-time.Hour)
+	assert.NoError(t, db.Model(&orm.Batch{}).Where("hash = ?", hashes[0]).Update("finalized_at", finalizedAt).Error)
+	assert.True(t, relayer.finalizeIntervalElapsed())
+
+	// the interval is measured from the latest finalized batch.
+	assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), hashes[1], types.RollupFinalized))
+	assert.False(t, relayer.finalizeIntervalElapsed())
+
+	relayer.cfg.FinalizeBatchIntervalSec = 0
+	assert.True(t, relayer.finalizeIntervalElapsed())
+}
+
+func testLayer2RelayerProcessGasPriceOracleMaxGasPrice(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.GasOracleConfig = &config.GasOracleConfig{
+		GasPriceDiff: 50000, // 5%
+		MaxGasPrice:  1000,
+	}
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2GasOracle, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, relayer)
+
+	var batchOrm *orm.Batch
+	patchGuard := gomonkey.ApplyMethodFunc(batchOrm, "GetLatestBatch", func(context.Context) (*orm.Batch, error) {
+		batch := orm.Batch{
+			OracleStatus: int16(types.GasOraclePending),
+			Hash:         "0x0000000000000000000000000000000000000000",
+		}
+		return &batch, nil
+	})
+	defer patchGuard.Reset()
+
+	var gasPrice int64
+	patchGuard.ApplyMethodFunc(relayer.l2Client, "SuggestGasPrice", func(ctx context.Context) (*big.Int, error) {
+		return big.NewInt(gasPrice), nil
+	})
+	var sentGasPrices []uint64
+	patchGuard.ApplyMethodFunc(relayer.gasOracleSender, "SendTransaction", func(ContextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (hash common.Hash, err error) {
+		// the calldata of setL2BaseFee is the selector followed by the base fee.
+		sentGasPrices = append(sentGasPrices, new(big.Int).SetBytes(data[4:]).Uint64())
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+	patchGuard.ApplyMethodFunc(batchOrm, "UpdateL2GasOracleStatusAndOracleTxHash", func(ctx context.Context, hash string, status types.GasOracleStatus, txHash string) error {
+		return nil
+	})
+
+	// below the cap.
+	gasPrice = 500
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, []uint64{500}, sentGasPrices)
+
+	// at the cap.
+	gasPrice = 1000
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, []uint64{500, 1000}, sentGasPrices)
+
+	// above the cap, the clamped gas price doesn't differ from the last one.
+	gasPrice = 100000
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, []uint64{500, 1000}, sentGasPrices)
+	assert.Equal(t, uint64(1000), relayer.lastGasPrice)
+
+	// above the cap after a lower gas price, the cap is set.
+	gasPrice = 600
+	relayer.ProcessGasPriceOracle()
+	gasPrice = 100000
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, []uint64{500, 1000, 600, 1000}, sentGasPrices)
+	assert.Equal(t, uint64(1000), relayer.lastGasPrice)
+}
+
+func testL2RelayerCommitHeartbeat(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	patchGuard := gomonkey.ApplyMethodFunc(relayer.commitSender, "SendTransaction", func(contextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (common.Hash, error) {
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+	defer patchGuard.Reset()
+
+	heartbeat := func() (float64, float64) {
+		relayer.metrics.rollupL2RelayerCommitHeartbeatTimestamp.Set(0)
+		relayer.ProcessPendingBatches()
+		return testutil.ToFloat64(relayer.metrics.rollupL2RelayerCommitHeartbeatTimestamp),
+			testutil.ToFloat64(relayer.metrics.rollupL2RelayerCommitHeartbeatPendingBatches)
+	}
+
+	// the heartbeat is updated by an idle cycle.
+	timestamp, pending := heartbeat()
+	assert.InDelta(t, float64(time.Now().Unix()), timestamp, 5)
+	assert.Equal(t, float64(0), pending)
+
+	chunkOrm := orm.NewChunk(db)
+	dbChunk1, err := chunkOrm.InsertChunk(context.Background(), chunk1)
+	assert.NoError(t, err)
+	dbChunk2, err := chunkOrm.InsertChunk(context.Background(), chunk2)
+	assert.NoError(t, err)
+	batchOrm := orm.NewBatch(db)
+	_, err = batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  dbChunk1.Hash,
+		EndChunkIndex:   1,
+		EndChunkHash:    dbChunk2.Hash,
+	})
+	assert.NoError(t, err)
+
+	timestamp, pending = heartbeat()
+	assert.InDelta(t, float64(time.Now().Unix()), timestamp, 5)
+	assert.Equal(t, float64(1), pending)
+
+	// the batch is committing, the cycle is idle again.
+	timestamp, pending = heartbeat()
+	assert.InDelta(t, float64(time.Now().Unix()), timestamp, 5)
+	assert.Equal(t, float64(0), pending)
+}
+
+func testL2RelayerVerifyGasOracleValue(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.VerifyGasOracleValue = true
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2GasOracle, nil)
+	assert.NoError(t, err)
+
+	batchMeta := &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  chunkHash1.Hex(),
+		EndChunkIndex:   0,
+		EndChunkHash:    chunkHash1.Hex(),
+	}
+	batchOrm := orm.NewBatch(db)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1}, batchMeta)
+	assert.NoError(t, err)
+
+	// the setL2BaseFee tx pushing a base fee of 100.
+	data, err := relayer.l2GasOracleABI.Pack("setL2BaseFee", big.NewInt(100))
+	assert.NoError(t, err)
+	tx := gethTypes.NewTx(&gethTypes.DynamicFeeTx{
+		To:        &relayerCfg.GasPriceOracleContractAddress,
+		Gas:       50000,
+		Value:     big.NewInt(0),
+		ChainID:   big.NewInt(1),
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(10),
+		Data:      data,
+	})
+	senderMeta := &orm.SenderMeta{
+		Name:    "gas_oracle_sender",
+		Service: "l2_relayer",
+		Address: common.HexToAddress("0x1"),
+		Type:    types.SenderTypeL2GasOracle,
+	}
+	assert.NoError(t, orm.NewPendingTransaction(db).InsertPendingTransaction(context.Background(), batch.Hash, senderMeta, tx, 0))
+
+	var stored int64
+	var readBlock *big.Int
+	patchGuard := gomonkey.ApplyMethodFunc(relayer.gasOracleSender, "CallContract", func(target *common.Address, data []byte, blockNumber *big.Int) ([]byte, error) {
+		readBlock = blockNumber
+		return common.LeftPadBytes(big.NewInt(stored).Bytes(), 32), nil
+	})
+	defer patchGuard.Reset()
+
+	oracleStatus := func() types.GasOracleStatus {
+		batches, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batch.Hash}, nil, 0)
+		assert.NoError(t, err)
+		assert.Len(t, batches, 1)
+		return types.GasOracleStatus(batches[0].OracleStatus)
+	}
+	cfm := &sender.Confirmation{
+		ContextID:    batch.Hash,
+		IsSuccessful: true,
+		TxHash:       tx.Hash(),
+		SenderType:   types.SenderTypeL2GasOracle,
+		Receipt:      &gethTypes.Receipt{BlockNumber: big.NewInt(42)},
+	}
+	mismatches := testutil.ToFloat64(relayer.metrics.rollupL2GasOracleValueMismatchTotal)
+
+	// the stored value doesn't match the pushed one.
+	stored = 90
+	relayer.handleConfirmation(cfm)
+	assert.Equal(t, types.GasOracleImportedFailed, oracleStatus())
+	assert.Equal(t, mismatches+1, testutil.ToFloat64(relayer.metrics.rollupL2GasOracleValueMismatchTotal))
+	assert.Equal(t, big.NewInt(42), readBlock)
+
+	// the stored value matches the pushed one.
+	stored = 100
+	relayer.handleConfirmation(cfm)
+	assert.Equal(t, types.GasOracleImported, oracleStatus())
+	assert.Equal(t, mismatches+1, testutil.ToFloat64(relayer.metrics.rollupL2GasOracleValueMismatchTotal))
+}
+
+func testL2RelayerFinalizeBatchMalformedProof(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	batchOrm := orm.NewBatch(db)
+	var parentBatchHash string
+	insertVerifiedBatch := func(proof *message.BatchProof) *orm.Batch {
+		// the parent batch is finalized so that only the proof decides whether the batch is finalized.
+		if parentBatchHash != "" {
+			assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), parentBatchHash, types.RollupFinalized))
+		}
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   1,
+			EndChunkHash:    chunkHash2.Hex(),
+		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+		assert.NoError(t, err)
+		assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupCommitted))
+		assert.NoError(t, batchOrm.UpdateProofByHash(context.Background(), batch.Hash, proof, 100))
+		assert.NoError(t, batchOrm.UpdateProvingStatus(context.Background(), batch.Hash, types.ProvingTaskVerified))
+		parentBatchHash = batch.Hash
+		return batch
+	}
+
+	var sentCount int
+	patchGuard := gomonkey.ApplyMethodFunc(relayer.finalizeSender, "SendTransaction", func(contextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (common.Hash, error) {
+		sentCount++
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+	defer patchGuard.Reset()
+
+	for _, length := range []int{31, 33} {
+		for _, proof := range []*message.BatchProof{
+			{Proof: make([]byte, length)},
+			{Proof: make([]byte, 32), Instances: make([]byte, length)},
+		} {
+			malformed := testutil.ToFloat64(relayer.metrics.rollupL2BatchesMalformedProofTotal)
+			batch := insertVerifiedBatch(proof)
+			err = relayer.finalizeBatch(batch, true)
+			assert.ErrorIs(t, err, message.ErrMalformedProof)
+			assert.Equal(t, 0, sentCount)
+			assert.Equal(t, malformed+1, testutil.ToFloat64(relayer.metrics.rollupL2BatchesMalformedProofTotal))
+
+			batches, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batch.Hash}, nil, 1)
+			assert.NoError(t, err)
+			assert.Len(t, batches, 1)
+			assert.Equal(t, types.RollupProofMalformed, types.RollupStatus(batches[0].RollupStatus))
+			assert.Equal(t, types.FinalizeSkipReasonProofMalformed, types.FinalizeSkipReason(batches[0].FinalizeSkipReason))
+		}
+	}
+
+	batch := insertVerifiedBatch(&message.BatchProof{Proof: make([]byte, 64), Instances: make([]byte, 32)})
+	assert.NoError(t, relayer.finalizeBatch(batch, true))
+	assert.Equal(t, 1, sentCount)
+	statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RollupStatus{types.RollupFinalizing}, statuses)
+}
+
+func testLayer2RelayerProcessGasPriceOracleLowerBaseFee(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.GasOracleConfig = &config.GasOracleConfig{
+		MinGasPrice:  100,
+		GasPriceDiff: 50000, // 5%
+	}
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2GasOracle, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, relayer)
+
+	var batchOrm *orm.Batch
+	patchGuard := gomonkey.ApplyMethodFunc(batchOrm, "GetLatestBatch", func(context.Context) (*orm.Batch, error) {
+		batch := orm.Batch{
+			OracleStatus: int16(types.GasOraclePending),
+			Hash:         "0x0000000000000000000000000000000000000000",
+		}
+		return &batch, nil
+	})
+	defer patchGuard.Reset()
+
+	var gasPrice int64
+	patchGuard.ApplyMethodFunc(relayer.l2Client, "SuggestGasPrice", func(ctx context.Context) (*big.Int, error) {
+		return big.NewInt(gasPrice), nil
+	})
+	var sentGasPrices []uint64
+	patchGuard.ApplyMethodFunc(relayer.gasOracleSender, "SendTransaction", func(ContextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (hash common.Hash, err error) {
+		args, err := relayer.l2GasOracleABI.Methods["setL2BaseFee"].Inputs.Unpack(data[4:])
+		assert.NoError(t, err)
+		sentGasPrices = append(sentGasPrices, args[0].(*big.Int).Uint64())
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+	patchGuard.ApplyMethodFunc(batchOrm, "UpdateL2GasOracleStatusAndOracleTxHash", func(ctx context.Context, hash string, status types.GasOracleStatus, txHash string) error {
+		return nil
+	})
+
+	gasPrice = 1000
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, []uint64{1000}, sentGasPrices)
+
+	// a downward update still above the floor is pushed.
+	gasPrice = 500
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, []uint64{1000, 500}, sentGasPrices)
+	assert.Equal(t, uint64(500), relayer.lastGasPrice)
+
+	// a downward diff within gas_price_diff isn't pushed.
+	gasPrice = 490
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, []uint64{1000, 500}, sentGasPrices)
+
+	// a suggested price below the floor lowers the base fee down to the min gas price.
+	gasPrice = 10
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, []uint64{1000, 500, 100}, sentGasPrices)
+	assert.Equal(t, uint64(100), relayer.lastGasPrice)
+
+	// nothing more to push once the base fee sits at the floor.
+	gasPrice = 50
+	relayer.ProcessGasPriceOracle()
+	assert.Equal(t, []uint64{1000, 500, 100}, sentGasPrices)
+}
+
+func testL2RelayerConfirmationDBRetry(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.ConfirmationDBMaxRetry = 2
+	l2Relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	batchOrm := orm.NewBatch(db)
+	batchMeta := &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  chunkHash1.Hex(),
+		EndChunkIndex:   1,
+		EndChunkHash:    chunkHash2.Hex(),
 	}
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+	assert.NoError(t, err)
+	assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupFinalizing))
 
-	confirmations := []BatchConfirmation{
-		{batchHash: batch1.Hash, isSuccessful: true},
-		{batchHash: batch2.Hash, isSuccessful: false},
-	}
+	var updateCalls int
+	patchGuard := gomonkey.ApplyMethodFunc(l2Relayer.batchOrm, "UpdateFinalizeTxHashAndRollupStatus", func(ctx context.Context, hash string, finalizeTxHash string, status types.RollupStatus) error {
+		updateCalls++
+		return errors.New("db is down")
+	})
 
-	for _, confirmation := range confirmations {
-		l2Relayer.gasOracleSender.SendConfirmation(&sender.Confirmation{
-			ContextID:    confirmation.batchHash,
-			IsSuccessful: confirmation.isSuccessful,
-			SenderType:   types.SenderTypeL2GasOracle,
-		})
+	failed := testutil.ToFloat64(l2Relayer.metrics.rollupL2ConfirmationDBUpdateFailedTotal)
+	cfm := &sender.Confirmation{
+		ContextID:    batch.Hash,
+		IsSuccessful: true,
+		TxHash:       common.HexToHash("0x123456789abcdef"),
+		SenderType:   types.SenderTypeFinalizeBatch,
 	}
-	// Check the database for the updated status using TryTimes.
-	ok := utils.TryTimes(5, func() bool {
-		expectedStatuses := []types.GasOracleStatus{types.GasOracleImported, types.GasOracleImportedFailed}
-		for i, confirmation := range confirmations {
-			gasOracle, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": confirmation.batchHash}, nil, 0)
-			if err != nil || len(gasOracle) != 1 || types.GasOracleStatus(gasOracle[0].OracleStatus) != expectedStatuses[i] {
-				return false
-			}
-		}
-		return true
-	})
-	assert.True(t, ok)
+	l2Relayer.handleConfirmation(cfm)
+	// the first attempt and the bounded retries.
+	assert.Equal(t, 3, updateCalls)
+	assert.Equal(t, failed+1, testutil.ToFloat64(l2Relayer.metrics.rollupL2ConfirmationDBUpdateFailedTotal))
+	key := sentTxKey{senderType: types.SenderTypeFinalizeBatch, contextID: batch.Hash}
+	assert.Contains(t, l2Relayer.failedConfirmations, key)
+	assert.Equal(t, cfm, l2Relayer.failedConfirmations[key].cfm)
+	assert.Equal(t, float64(1), testutil.ToFloat64(l2Relayer.metrics.rollupL2ConfirmationDBUpdatePending))
+
+	// the kept update is retried while the db is still down.
+	l2Relayer.retryFailedConfirmations()
+	assert.Equal(t, 4, updateCalls)
+	assert.Contains(t, l2Relayer.failedConfirmations, key)
+	statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RollupStatus{types.RollupFinalizing}, statuses)
+
+	// and applied once the db is back.
+	patchGuard.Reset()
+	l2Relayer.retryFailedConfirmations()
+	assert.Empty(t, l2Relayer.failedConfirmations)
+	assert.Equal(t, float64(0), testutil.ToFloat64(l2Relayer.metrics.rollupL2ConfirmationDBUpdatePending))
+	statuses, err = batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RollupStatus{types.RollupFinalized}, statuses)
 }
 
-func testLayer2RelayerProcessGasPriceOracle(t *testing.T) {
+func testL2RelayerHealthCheck(t *testing.T) {
 	db := setupL2RelayerDB(t)
 	defer database.CloseDB(db)
 
-	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2GasOracle, nil)
+	l2Relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
 	assert.NoError(t, err)
-	assert.NotNil(t, relayer)
+	assert.NoError(t, l2Relayer.HealthCheck(context.Background()))
 
-	var batchOrm *orm.Batch
-	convey.Convey("Failed to GetLatestBatch", t, func() {
-		targetErr := errors.New("GetLatestBatch error")
-		patchGuard := gomonkey.ApplyMethodFunc(batchOrm, "GetLatestBatch", func(context.Context) (*orm.Batch, error) {
-			return nil, targetErr
+	convey.Convey("layer2 node unreachable", t, func() {
+		patchGuard := gomonkey.ApplyMethodFunc(l2Relayer.l2Client, "BlockNumber", func(context.Context) (uint64, error) {
+			return 0, errors.New("connection refused")
 		})
 		defer patchGuard.Reset()
-		relayer.ProcessGasPriceOracle()
+		err := l2Relayer.HealthCheck(context.Background())
+		assert.ErrorContains(t, err, "layer2 node unreachable")
+	})
+
+	convey.Convey("db unreachable", t, func() {
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+		patchGuard := gomonkey.ApplyMethodFunc(sqlDB, "PingContext", func(context.Context) error {
+			return errors.New("connection refused")
+		})
+		defer patchGuard.Reset()
+		err = l2Relayer.HealthCheck(context.Background())
+		assert.ErrorContains(t, err, "db unreachable")
+	})
+
+	convey.Convey("confirmation loop stalled", t, func() {
+		l2Relayer.confirmLoopHeartbeat.Store(time.Now().Add(-confirmLoopHeartbeatTimeout - time.Second).UnixNano())
+		err := l2Relayer.HealthCheck(context.Background())
+		assert.ErrorContains(t, err, "confirmation loop stalled")
 	})
 
+	convey.Convey("confirmation loop stopped", t, func() {
+		l2Relayer.Stop()
+		err := l2Relayer.HealthCheck(context.Background())
+		assert.ErrorContains(t, err, "confirmation loop stopped")
+	})
+}
+
+func testLayer2RelayerProcessGasPriceOracleDiffPrecision(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	var batchOrm *orm.Batch
 	patchGuard := gomonkey.ApplyMethodFunc(batchOrm, "GetLatestBatch", func(context.Context) (*orm.Batch, error) {
 		batch := orm.Batch{
 			OracleStatus: int16(types.GasOraclePending),
@@ -348,102 +2707,413 @@ func testLayer2RelayerProcessGasPriceOracle(t *testing.T) {
 		return &batch, nil
 	})
 	defer patchGuard.Reset()
+	patchGuard.ApplyMethodFunc(batchOrm, "UpdateL2GasOracleStatusAndOracleTxHash", func(ctx context.Context, hash string, status types.GasOracleStatus, txHash string) error {
+		return nil
+	})
 
-	convey.Convey("Failed to fetch SuggestGasPrice from l2geth", t, func() {
-		targetErr := errors.New("SuggestGasPrice error")
+	// the same gas_price_diff of 1 is 1% with a precision of 100 and 0.1% with a precision of 1000,
+	// and a diff above the precision doesn't wrap the lower bound of the last gas price around.
+	for _, tc := range []struct {
+		diff      uint64
+		precision uint64
+		sent      int
+	}{
+		{diff: 1, precision: 100, sent: 1},
+		{diff: 1, precision: 1000, sent: 2},
+		{diff: 2000, precision: 1000, sent: 1},
+	} {
+		relayerCfg := *cfg.L2Config.RelayerConfig
+		relayerCfg.GasOracleConfig = &config.GasOracleConfig{
+			GasPriceDiff:          tc.diff,
+			GasPriceDiffPrecision: tc.precision,
+		}
+		relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2GasOracle, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.precision, relayer.gasPriceDiffPrecision)
+
+		var gasPrice int64
 		patchGuard.ApplyMethodFunc(relayer.l2Client, "SuggestGasPrice", func(ctx context.Context) (*big.Int, error) {
-			return nil, targetErr
+			return big.NewInt(gasPrice), nil
 		})
+		var sentCount int
+		patchGuard.ApplyMethodFunc(relayer.gasOracleSender, "SendTransaction", func(ContextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (hash common.Hash, err error) {
+			sentCount++
+			return common.HexToHash("0x56789abcdef1234"), nil
+		})
+
+		gasPrice = 1000
 		relayer.ProcessGasPriceOracle()
-	})
+		gasPrice = 1005
+		relayer.ProcessGasPriceOracle()
+		assert.Equal(t, tc.sent, sentCount, "diff %d, precision %d", tc.diff, tc.precision)
+		relayer.Stop()
+	}
 
-	patchGuard.ApplyMethodFunc(relayer.l2Client, "SuggestGasPrice", func(ctx context.Context) (*big.Int, error) {
-		return big.NewInt(100), nil
-	})
+	// the default precision is kept when it's not configured.
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.GasOracleConfig = &config.GasOracleConfig{GasPriceDiff: 50000}
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2GasOracle, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(defaultGasPriceDiffPrecision), relayer.gasPriceDiffPrecision)
+	relayer.Stop()
+}
 
-	convey.Convey("Failed to pack setL2BaseFee", t, func() {
-		targetErr := errors.New("setL2BaseFee error")
-		patchGuard.ApplyMethodFunc(relayer.l2GasOracleABI, "Pack", func(name string, args ...interface{}) ([]byte, error) {
-			return nil, targetErr
-		})
-		relayer.ProcessGasPriceOracle()
-	})
+func testL2RelayerRecoverRollupConfirmations(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
 
-	patchGuard.ApplyMethodFunc(relayer.l2GasOracleABI, "Pack", func(name string, args ...interface{}) ([]byte, error) {
-		return nil, nil
-	})
+	l2Relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
 
-	convey.Convey("Failed to send setL2BaseFee tx to layer2", t, func() {
-		targetErr := errors.New("failed to send setL2BaseFee tx to layer2 error")
-		patchGuard.ApplyMethodFunc(relayer.gasOracleSender, "SendTransaction", func(ContextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (hash common.Hash, err error) {
-			return common.Hash{}, targetErr
+	batchOrm := orm.NewBatch(db)
+	batchHashes := make([]string, 4)
+	for i := range batchHashes {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   0,
+			EndChunkHash:    chunkHash1.Hex(),
+		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1}, batchMeta)
+		assert.NoError(t, err)
+		batchHashes[i] = batch.Hash
+	}
+
+	newTx := func(nonce uint64) *gethTypes.Transaction {
+		return gethTypes.NewTx(&gethTypes.DynamicFeeTx{
+			Nonce:     nonce,
+			To:        &common.Address{},
+			Gas:       21000,
+			Value:     big.NewInt(0),
+			ChainID:   big.NewInt(1),
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(10),
 		})
-		relayer.ProcessGasPriceOracle()
-	})
+	}
+	var (
+		committedTx       = newTx(0)
+		finalizeFailedTx  = newTx(1)
+		inFlightCommitTx  = newTx(2)
+		receiptMissingTx  = newTx(3)
+		pendingTxs        = []*gethTypes.Transaction{committedTx, finalizeFailedTx, inFlightCommitTx, receiptMissingTx}
+		senderTypes       = []types.SenderType{types.SenderTypeCommitBatch, types.SenderTypeFinalizeBatch, types.SenderTypeCommitBatch, types.SenderTypeFinalizeBatch}
+		rollupStatuses    = []types.RollupStatus{types.RollupCommitting, types.RollupFinalizing, types.RollupCommitting, types.RollupFinalizing}
+		pendingTxOrm      = orm.NewPendingTransaction(db)
+		confirmedTxHashes = []common.Hash{committedTx.Hash(), finalizeFailedTx.Hash(), receiptMissingTx.Hash()}
+	)
+	for i, tx := range pendingTxs {
+		senderMeta := &orm.SenderMeta{Name: "sender", Service: "l2_relayer", Address: common.HexToAddress("0x1"), Type: senderTypes[i]}
+		assert.NoError(t, pendingTxOrm.InsertPendingTransaction(context.Background(), batchHashes[i], senderMeta, tx, 0))
+		assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batchHashes[i], rollupStatuses[i]))
+	}
+	// the sender marked the txs as confirmed, then the relayer restarted before handling the confirmations.
+	for _, txHash := range confirmedTxHashes {
+		assert.NoError(t, pendingTxOrm.UpdatePendingTransactionStatusByTxHash(context.Background(), txHash, types.TxStatusConfirmed))
+	}
+	l2Relayer.Stop()
 
-	patchGuard.ApplyMethodFunc(relayer.gasOracleSender, "SendTransaction", func(ContextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (hash common.Hash, err error) {
-		return common.HexToHash("0x56789abcdef1234"), nil
+	receipts := map[common.Hash]*gethTypes.Receipt{
+		committedTx.Hash():      {Status: gethTypes.ReceiptStatusSuccessful, BlockNumber: big.NewInt(1)},
+		finalizeFailedTx.Hash(): {Status: gethTypes.ReceiptStatusFailed, BlockNumber: big.NewInt(1)},
+	}
+	patchGuard := gomonkey.ApplyMethodFunc(&sender.Sender{}, "GetTransactionReceipt", func(txHash common.Hash) (*gethTypes.Receipt, error) {
+		receipt, ok := receipts[txHash]
+		if !ok {
+			return nil, ethereum.NotFound
+		}
+		return receipt, nil
 	})
+	defer patchGuard.Reset()
 
-	convey.Convey("UpdateGasOracleStatusAndOracleTxHash failed", t, func() {
-		targetErr := errors.New("UpdateL2GasOracleStatusAndOracleTxHash error")
-		patchGuard.ApplyMethodFunc(batchOrm, "UpdateL2GasOracleStatusAndOracleTxHash", func(ctx context.Context, hash string, status types.GasOracleStatus, txHash string) error {
-			return targetErr
-		})
-		relayer.ProcessGasPriceOracle()
-	})
+	l2Relayer, err = NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+	defer l2Relayer.Stop()
 
-	patchGuard.ApplyMethodFunc(batchOrm, "UpdateL2GasOracleStatusAndOracleTxHash", func(ctx context.Context, hash string, status types.GasOracleStatus, txHash string) error {
-		return nil
-	})
-	relayer.ProcessGasPriceOracle()
-}
+	expected := []types.RollupStatus{
+		types.RollupCommitted,
+		types.RollupFinalizeFailed,
+		// left to the confirmation of the sender.
+		types.RollupCommitting,
+		// unknown outcome, left as it is.
+		types.RollupFinalizing,
+	}
+	statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), batchHashes)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, statuses)
 
-func mockChainMonitorServer(baseURL string) (*http.Server, error) {
-	router := gin.New()
-	r := router.Group("/v1")
-	r.GET("/batch_status", func(ctx *gin.Context) {
-		ctx.JSON(http.StatusOK, struct {
-			ErrCode int    `json:"errcode"`
-			ErrMsg  string `json:"errmsg"`
-			Data    bool   `json:"data"`
-		}{
-			ErrCode: 0,
-			ErrMsg:  "",
-			Data:    true,
-		})
-	})
-	return utils.StartHTTPServer(strings.Split(baseURL, "//")[1], router)
+	batches, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batchHashes[0]}, nil, 0)
+	assert.NoError(t, err)
+	assert.Len(t, batches, 1)
+	assert.Equal(t, committedTx.Hash().String(), batches[0].CommitTxHash)
 }
 
-func testGetBatchStatusByIndex(t *testing.T) {
+func testL2RelayerDryRun(t *testing.T) {
 	db := setupL2RelayerDB(t)
 	defer database.CloseDB(db)
 
-	l2BlockOrm := orm.NewL2Block(db)
-	err := l2BlockOrm.InsertL2Blocks(context.Background(), []*types.WrappedBlock{wrappedBlock1, wrappedBlock2})
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayerCfg.DryRun = true
+	_, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, true, ServiceTypeL2RollupRelayer, nil)
+	assert.Error(t, err)
+
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+	defer relayer.Stop()
+	gasOracleRelayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2GasOracle, nil)
 	assert.NoError(t, err)
+	defer gasOracleRelayer.Stop()
+
+	var sentCount int
+	patchGuard := gomonkey.ApplyMethodFunc(&sender.Sender{}, "SendTransaction", func(contextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (common.Hash, error) {
+		sentCount++
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+	defer patchGuard.Reset()
+
+	l2BlockOrm := orm.NewL2Block(db)
+	assert.NoError(t, l2BlockOrm.InsertL2Blocks(context.Background(), []*types.WrappedBlock{wrappedBlock1, wrappedBlock2}))
 	chunkOrm := orm.NewChunk(db)
 	dbChunk1, err := chunkOrm.InsertChunk(context.Background(), chunk1)
 	assert.NoError(t, err)
 	dbChunk2, err := chunkOrm.InsertChunk(context.Background(), chunk2)
 	assert.NoError(t, err)
-	batchMeta := &types.BatchMeta{
+	batchOrm := orm.NewBatch(db)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, &types.BatchMeta{
 		StartChunkIndex: 0,
 		StartChunkHash:  dbChunk1.Hash,
 		EndChunkIndex:   1,
 		EndChunkHash:    dbChunk2.Hash,
-	}
+	})
+	assert.NoError(t, err)
+
+	dryRuns := testutil.ToFloat64(relayer.metrics.rollupL2RelayerDryRunTxTotal)
+	relayer.ProcessPendingBatches()
+	assert.Equal(t, 0, sentCount)
+	assert.Equal(t, dryRuns+1, testutil.ToFloat64(relayer.metrics.rollupL2RelayerDryRunTxTotal))
+	statuses, err := batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RollupStatus{types.RollupPending}, statuses)
+
+	assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupCommitted))
+	assert.NoError(t, batchOrm.UpdateProofByHash(context.Background(), batch.Hash, &message.BatchProof{Proof: make([]byte, 32)}, 100))
+	assert.NoError(t, batchOrm.UpdateProvingStatus(context.Background(), batch.Hash, types.ProvingTaskVerified))
+	batches, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batch.Hash}, nil, 1)
+	assert.NoError(t, err)
+	assert.Len(t, batches, 1)
+	assert.NoError(t, relayer.finalizeBatch(batches[0], true))
+	assert.Equal(t, 0, sentCount)
+	assert.Equal(t, dryRuns+2, testutil.ToFloat64(relayer.metrics.rollupL2RelayerDryRunTxTotal))
+	statuses, err = batchOrm.GetRollupStatusByHashList(context.Background(), []string{batch.Hash})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RollupStatus{types.RollupCommitted}, statuses)
+
+	gasOracleRelayer.ProcessGasPriceOracle()
+	assert.Equal(t, 0, sentCount)
+	assert.Equal(t, dryRuns+3, testutil.ToFloat64(relayer.metrics.rollupL2RelayerDryRunTxTotal))
+	batches, err = batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batch.Hash}, nil, 1)
+	assert.NoError(t, err)
+	assert.Len(t, batches, 1)
+	assert.Equal(t, types.GasOraclePending, types.GasOracleStatus(batches[0].OracleStatus))
+	assert.Equal(t, "", batches[0].OracleTxHash)
+}
+
+func testL2RelayerFinalizeLatency(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	l2Relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+	defer l2Relayer.Stop()
+
 	batchOrm := orm.NewBatch(db)
-	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  chunkHash1.Hex(),
+		EndChunkIndex:   1,
+		EndChunkHash:    chunkHash2.Hex(),
+	})
 	assert.NoError(t, err)
+	l2Relayer.handleConfirmation(&sender.Confirmation{
+		ContextID:    batch.Hash,
+		IsSuccessful: true,
+		TxHash:       common.HexToHash("0x123456789abcdef"),
+		SenderType:   types.SenderTypeCommitBatch,
+	})
+	// the batch was committed an hour ago.
+	committedAt := utils.NowUTC().Add(-time.Hour)
+	assert.NoError(t, db.Model(&orm.Batch{}).Where("hash = ?", batch.Hash).Update("committed_at", committedAt).Error)
+
+	sample := func() (uint64, float64) {
+		var m dto.Metric
+		assert.NoError(t, l2Relayer.metrics.rollupL2BatchFinalizeLatency.Write(&m))
+		return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+	}
+	count, sum := sample()
+
+	// a failed finalization isn't recorded.
+	l2Relayer.handleConfirmation(&sender.Confirmation{
+		ContextID:    batch.Hash,
+		IsSuccessful: false,
+		TxHash:       common.HexToHash("0x123456789abcdef0"),
+		SenderType:   types.SenderTypeFinalizeBatch,
+	})
+	newCount, newSum := sample()
+	assert.Equal(t, count, newCount)
+	assert.Equal(t, sum, newSum)
+
+	l2Relayer.handleConfirmation(&sender.Confirmation{
+		ContextID:    batch.Hash,
+		IsSuccessful: true,
+		TxHash:       common.HexToHash("0x123456789abcdef1"),
+		SenderType:   types.SenderTypeFinalizeBatch,
+	})
+	newCount, newSum = sample()
+	assert.Equal(t, count+1, newCount)
+	latency := newSum - sum
+	assert.GreaterOrEqual(t, latency, time.Hour.Seconds())
+	assert.Less(t, latency, (time.Hour + time.Minute).Seconds())
+}
+
+func testL2RelayerFinalizeBatchParentNotFinalized(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
+
+	relayerCfg := *cfg.L2Config.RelayerConfig
+	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, &relayerCfg, false, ServiceTypeL2RollupRelayer, nil)
+	assert.NoError(t, err)
+
+	batchOrm := orm.NewBatch(db)
+	proof := &message.BatchProof{
+		Proof: []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+	}
+	var hashes []string
+	for i := 0; i < 4; i++ {
+		batchMeta := &types.BatchMeta{
+			StartChunkIndex: 0,
+			StartChunkHash:  chunkHash1.Hex(),
+			EndChunkIndex:   1,
+			EndChunkHash:    chunkHash2.Hex(),
+		}
+		batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, batchMeta)
+		assert.NoError(t, err)
+		assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), batch.Hash, types.RollupCommitted))
+		assert.NoError(t, batchOrm.UpdateProvingStatus(context.Background(), batch.Hash, types.ProvingTaskVerified))
+		assert.NoError(t, batchOrm.UpdateProofByHash(context.Background(), batch.Hash, proof, 100))
+		hashes = append(hashes, batch.Hash)
+	}
+
+	var sentCount int
+	patchGuard := gomonkey.ApplyMethodFunc(relayer.finalizeSender, "SendTransaction", func(contextID string, target *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (common.Hash, error) {
+		sentCount++
+		return common.HexToHash("0x56789abcdef1234"), nil
+	})
+	defer patchGuard.Reset()
+
+	getBatch := func(hash string) *orm.Batch {
+		batches, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": hash}, nil, 1)
+		assert.NoError(t, err)
+		assert.Len(t, batches, 1)
+		return batches[0]
+	}
+
+	convey.Convey("parent batch is finalized, finalize tx is sent", t, func() {
+		assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), hashes[0], types.RollupFinalized))
+		assert.NoError(t, relayer.finalizeBatch(getBatch(hashes[1]), true))
+		assert.Equal(t, 1, sentCount)
+		assert.Equal(t, types.RollupFinalizing, types.RollupStatus(getBatch(hashes[1]).RollupStatus))
+	})
+
+	convey.Convey("parent batch is not finalized, finalize tx is deferred", t, func() {
+		deferred := testutil.ToFloat64(relayer.metrics.rollupL2FinalizeParentNotFinalizedTotal)
+		err := relayer.finalizeBatch(getBatch(hashes[3]), true)
+		assert.ErrorIs(t, err, errParentBatchNotFinalized)
+		assert.Equal(t, 1, sentCount)
+		assert.Equal(t, deferred+1, testutil.ToFloat64(relayer.metrics.rollupL2FinalizeParentNotFinalizedTotal))
+
+		batch := getBatch(hashes[3])
+		assert.Equal(t, types.RollupCommitted, types.RollupStatus(batch.RollupStatus))
+		assert.Equal(t, types.FinalizeSkipReasonParentNotFinalized, types.FinalizeSkipReason(batch.FinalizeSkipReason))
+	})
+
+	convey.Convey("parent batch is skipped, finalize tx is deferred", t, func() {
+		assert.NoError(t, batchOrm.UpdateRollupStatus(context.Background(), hashes[2], types.RollupProofRejected))
+		// the batch whose parent is skipped is the earliest committed batch.
+		relayer.ProcessCommittedBatches()
+		assert.Equal(t, 1, sentCount)
+		assert.Equal(t, types.RollupCommitted, types.RollupStatus(getBatch(hashes[3]).RollupStatus))
+	})
+}
+
+func testL2RelayerCancelPendingCommit(t *testing.T) {
+	db := setupL2RelayerDB(t)
+	defer database.CloseDB(db)
 
-	cfg.L2Config.RelayerConfig.ChainMonitor.Enabled = true
 	relayer, err := NewLayer2Relayer(context.Background(), l2Cli, db, cfg.L2Config.RelayerConfig, false, ServiceTypeL2RollupRelayer, nil)
 	assert.NoError(t, err)
-	assert.NotNil(t, relayer)
+	defer relayer.Stop()
 
-	status, err := relayer.getBatchStatusByIndex(batch)
+	batchOrm := orm.NewBatch(db)
+	batch, err := batchOrm.InsertBatch(context.Background(), []*types.Chunk{chunk1, chunk2}, &types.BatchMeta{
+		StartChunkIndex: 0,
+		StartChunkHash:  chunkHash1.Hex(),
+		EndChunkIndex:   1,
+		EndChunkHash:    chunkHash2.Hex(),
+	})
 	assert.NoError(t, err)
-	assert.Equal(t, true, status)
+
+	// the commit tx of the batch is in flight.
+	commitTxHash, err := relayer.commitSender.SendTransaction(batch.Hash, &common.Address{}, big.NewInt(0), []byte{1, 2, 3}, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, batchOrm.UpdateCommitTxHashAndRollupStatus(context.Background(), batch.Hash, commitTxHash.String(), types.RollupCommitting))
+
+	getBatch := func() *orm.Batch {
+		batches, err := batchOrm.GetBatches(context.Background(), map[string]interface{}{"hash": batch.Hash}, nil, 1)
+		assert.NoError(t, err)
+		assert.Len(t, batches, 1)
+		return batches[0]
+	}
+
+	convey.Convey("unknown batch hash", t, func() {
+		assert.Error(t, relayer.CancelPendingCommit(common.HexToHash("0x1234").Hex()))
+		assert.Equal(t, types.RollupCommitting, types.RollupStatus(getBatch().RollupStatus))
+	})
+
+	convey.Convey("known batch hash", t, func() {
+		cancelled := testutil.ToFloat64(relayer.metrics.rollupL2CommitCancelledTotal)
+		assert.NoError(t, relayer.CancelPendingCommit(batch.Hash))
+		assert.Equal(t, cancelled+1, testutil.ToFloat64(relayer.metrics.rollupL2CommitCancelledTotal))
+		// the batch stays committing until the cancel tx or the original commit tx is confirmed.
+		assert.Equal(t, types.RollupCommitting, types.RollupStatus(getBatch().RollupStatus))
+		assert.Equal(t, commitTxHash.String(), getBatch().CommitTxHash)
+
+		// the cancel tx isn't cancelled again.
+		assert.Error(t, relayer.CancelPendingCommit(batch.Hash))
+
+		txs, err := orm.NewPendingTransaction(db).GetTransactionsByContextID(context.Background(), types.SenderTypeCommitBatch, batch.Hash)
+		assert.NoError(t, err)
+		assert.Len(t, txs, 2)
+		var cancelTxHash string
+		for _, tx := range txs {
+			if tx.Hash == commitTxHash.String() {
+				assert.Equal(t, types.TxStatusReplaced, tx.Status)
+			} else {
+				assert.Equal(t, types.TxStatusPending, tx.Status)
+				cancelTxHash = tx.Hash
+			}
+		}
+
+		// the confirmation of the cancel tx resets the batch to pending.
+		relayer.handleConfirmation(&sender.Confirmation{
+			ContextID:    batch.Hash,
+			IsSuccessful: true,
+			TxHash:       common.HexToHash(cancelTxHash),
+			SenderType:   types.SenderTypeCommitBatch,
+			IsCancelled:  true,
+		})
+		assert.Equal(t, types.RollupPending, types.RollupStatus(getBatch().RollupStatus))
+		assert.Empty(t, getBatch().CommitTxHash)
+
+		// the batch has no commit tx in flight anymore.
+		assert.Error(t, relayer.CancelPendingCommit(batch.Hash))
+	})
 }
@@ -0,0 +1,26 @@
+package relayer
+
+import (
+	"context"
+
+	"scroll-tech/common/types/message"
+)
+
+// FinalizedBatchProof is the proof bundle of a finalized batch, it carries everything
+// an external verifier needs to check the batch independently.
+type FinalizedBatchProof struct {
+	BatchIndex      uint64              `json:"batch_index"`
+	BatchHash       string              `json:"batch_hash"`
+	ParentBatchHash string              `json:"parent_batch_hash"`
+	StateRoot       string              `json:"state_root"`
+	WithdrawRoot    string              `json:"withdraw_root"`
+	BatchHeader     []byte              `json:"batch_header"`
+	FinalizeTxHash  string              `json:"finalize_tx_hash"`
+	Proof           *message.BatchProof `json:"proof"`
+}
+
+// ProofPublisher publishes the proofs of finalized batches to a public feed, e.g. IPFS or an HTTP endpoint.
+type ProofPublisher interface {
+	// PublishProof publishes the proof bundle of a finalized batch.
+	PublishProof(ctx context.Context, bundle *FinalizedBatchProof) error
+}
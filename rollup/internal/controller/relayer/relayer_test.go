@@ -109,13 +109,55 @@ func TestFunctions(t *testing.T) {
 
 	// Run l2 relayer test cases.
 	t.Run("TestCreateNewRelayer", testCreateNewRelayer)
+	t.Run("TestCreateNewRelayerCheckContractCode", testCreateNewRelayerCheckContractCode)
 	t.Run("TestL2RelayerProcessPendingBatches", testL2RelayerProcessPendingBatches)
+	t.Run("TestL2RelayerProcessPendingBatchesVerifyParentHash", testL2RelayerProcessPendingBatchesVerifyParentHash)
+	t.Run("TestL2RelayerProcessPendingBatchesRederiveHeader", testL2RelayerProcessPendingBatchesRederiveHeader)
+	t.Run("TestL2RelayerProcessPendingBatchesCommitFailedRetry", testL2RelayerProcessPendingBatchesCommitFailedRetry)
 	t.Run("TestL2RelayerProcessCommittedBatches", testL2RelayerProcessCommittedBatches)
+	t.Run("TestL2RelayerProcessCommittedBatchesMultipleFinalizeSenders", testL2RelayerProcessCommittedBatchesMultipleFinalizeSenders)
+	t.Run("TestL2RelayerProcessCommittedBatchesFinalizeSkipAlert", testL2RelayerProcessCommittedBatchesFinalizeSkipAlert)
+	t.Run("TestL2RelayerProcessCommittedBatchesProofMissing", testL2RelayerProcessCommittedBatchesProofMissing)
+	t.Run("TestL2RelayerProcessCommittedBatchesFinalizeSkipReason", testL2RelayerProcessCommittedBatchesFinalizeSkipReason)
+	t.Run("TestL2RelayerProcessCommittedBatchesSimulateFinalize", testL2RelayerProcessCommittedBatchesSimulateFinalize)
+	t.Run("TestL2RelayerRollupTxPriority", testL2RelayerRollupTxPriority)
 	t.Run("TestL2RelayerFinalizeTimeoutBatches", testL2RelayerFinalizeTimeoutBatches)
 	t.Run("TestL2RelayerCommitConfirm", testL2RelayerCommitConfirm)
+	t.Run("TestL2RelayerConfirmRecordL1Cost", testL2RelayerConfirmRecordL1Cost)
 	t.Run("TestL2RelayerFinalizeConfirm", testL2RelayerFinalizeConfirm)
+	t.Run("TestL2RelayerFinalizeConfirmVerifyEvent", testL2RelayerFinalizeConfirmVerifyEvent)
+	t.Run("TestL2RelayerFinalizeConfirmVerifyReceipt", testL2RelayerFinalizeConfirmVerifyReceipt)
+	t.Run("TestL2RelayerFinalizeConfirmPublishProof", testL2RelayerFinalizeConfirmPublishProof)
 	t.Run("TestL2RelayerGasOracleConfirm", testL2RelayerGasOracleConfirm)
+	t.Run("TestL2RelayerRecoverImportingGasOracle", testL2RelayerRecoverImportingGasOracle)
 	t.Run("TestLayer2RelayerProcessGasPriceOracle", testLayer2RelayerProcessGasPriceOracle)
+	t.Run("TestLayer2RelayerProcessGasPriceOracleMinInterval", testLayer2RelayerProcessGasPriceOracleMinInterval)
+	t.Run("TestLayer2RelayerProcessGasPriceOracleSkipReads", testLayer2RelayerProcessGasPriceOracleSkipReads)
 	// test getBatchStatusByIndex
 	t.Run("TestGetBatchStatusByIndex", testGetBatchStatusByIndex)
+	t.Run("TestL2RelayerExportState", testL2RelayerExportState)
+	t.Run("TestL2RelayerCircuitBreaker", testL2RelayerCircuitBreaker)
+	t.Run("TestL2RelayerPauseOnStaleL2Head", testL2RelayerPauseOnStaleL2Head)
+	t.Run("TestL2RelayerFinalizeBatchVerifyProofPublicInputs", testL2RelayerFinalizeBatchVerifyProofPublicInputs)
+	t.Run("TestL2RelayerConfirmationLatency", testL2RelayerConfirmationLatency)
+	t.Run("TestL2RelayerSharedSenderKeys", testL2RelayerSharedSenderKeys)
+	t.Run("TestL2RelayerFinalizeConfirmQuorum", testL2RelayerFinalizeConfirmQuorum)
+	t.Run("TestL2RelayerFinalizeIndexRegression", testL2RelayerFinalizeIndexRegression)
+	t.Run("TestL2RelayerGasOracleRetry", testL2RelayerGasOracleRetry)
+	t.Run("TestL2RelayerRecoverUnknownConfirmation", testL2RelayerRecoverUnknownConfirmation)
+	t.Run("TestL2RelayerStop", testL2RelayerStop)
+	t.Run("TestL2RelayerFinalizeInterval", testL2RelayerFinalizeInterval)
+	t.Run("TestLayer2RelayerProcessGasPriceOracleMaxGasPrice", testLayer2RelayerProcessGasPriceOracleMaxGasPrice)
+	t.Run("TestL2RelayerCommitHeartbeat", testL2RelayerCommitHeartbeat)
+	t.Run("TestL2RelayerVerifyGasOracleValue", testL2RelayerVerifyGasOracleValue)
+	t.Run("TestL2RelayerFinalizeBatchMalformedProof", testL2RelayerFinalizeBatchMalformedProof)
+	t.Run("TestLayer2RelayerProcessGasPriceOracleLowerBaseFee", testLayer2RelayerProcessGasPriceOracleLowerBaseFee)
+	t.Run("TestL2RelayerConfirmationDBRetry", testL2RelayerConfirmationDBRetry)
+	t.Run("TestL2RelayerHealthCheck", testL2RelayerHealthCheck)
+	t.Run("TestLayer2RelayerProcessGasPriceOracleDiffPrecision", testLayer2RelayerProcessGasPriceOracleDiffPrecision)
+	t.Run("TestL2RelayerRecoverRollupConfirmations", testL2RelayerRecoverRollupConfirmations)
+	t.Run("TestL2RelayerDryRun", testL2RelayerDryRun)
+	t.Run("TestL2RelayerFinalizeLatency", testL2RelayerFinalizeLatency)
+	t.Run("TestL2RelayerFinalizeBatchParentNotFinalized", testL2RelayerFinalizeBatchParentNotFinalized)
+	t.Run("TestL2RelayerCancelPendingCommit", testL2RelayerCancelPendingCommit)
 }
@@ -10,11 +10,17 @@ import (
 	"github.com/scroll-tech/go-ethereum/log"
 )
 
-func (s *Sender) estimateLegacyGas(to *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64) (*FeeData, error) {
-	gasPrice, err := s.client.SuggestGasPrice(s.ctx)
-	if err != nil {
-		log.Error("estimateLegacyGas SuggestGasPrice failure", "error", err)
-		return nil, err
+func (s *Sender) estimateLegacyGas(to *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64, baseFee uint64) (*FeeData, error) {
+	var gasPrice *big.Int
+	if estimated := s.estimateFee(baseFee); estimated != nil && estimated.GasPrice != nil {
+		gasPrice = estimated.GasPrice
+	} else {
+		var err error
+		gasPrice, err = s.client.SuggestGasPrice(s.ctx)
+		if err != nil {
+			log.Error("estimateLegacyGas SuggestGasPrice failure", "error", err)
+			return nil, err
+		}
 	}
 	gasLimit, _, err := s.estimateGasLimit(to, data, gasPrice, nil, nil, value, false)
 	if err != nil {
@@ -34,13 +40,20 @@ func (s *Sender) estimateLegacyGas(to *common.Address, value *big.Int, data []by
 }
 
 func (s *Sender) estimateDynamicGas(to *common.Address, value *big.Int, data []byte, fallbackGasLimit uint64, baseFee uint64) (*FeeData, error) {
-	gasTipCap, err := s.client.SuggestGasTipCap(s.ctx)
-	if err != nil {
-		log.Error("estimateDynamicGas SuggestGasTipCap failure", "error", err)
-		return nil, err
+	var gasTipCap, gasFeeCap *big.Int
+	if estimated := s.estimateFee(baseFee); estimated != nil && estimated.GasTipCap != nil {
+		gasTipCap, gasFeeCap = estimated.GasTipCap, estimated.GasFeeCap
+	} else {
+		var err error
+		gasTipCap, err = s.client.SuggestGasTipCap(s.ctx)
+		if err != nil {
+			log.Error("estimateDynamicGas SuggestGasTipCap failure", "error", err)
+			return nil, err
+		}
+	}
+	if gasFeeCap == nil {
+		gasFeeCap = new(big.Int).Add(gasTipCap, new(big.Int).Mul(new(big.Int).SetUint64(baseFee), big.NewInt(2)))
 	}
-
-	gasFeeCap := new(big.Int).Add(gasTipCap, new(big.Int).Mul(new(big.Int).SetUint64(baseFee), big.NewInt(2)))
 	gasLimit, accessList, err := s.estimateGasLimit(to, data, nil, gasTipCap, gasFeeCap, value, true)
 	if err != nil {
 		log.Error("estimateDynamicGas estimateGasLimit failure",
@@ -0,0 +1,126 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/rpc"
+
+	"scroll-tech/rollup/internal/config"
+)
+
+const (
+	// FeeEstimatorTypeFeeHistory estimates the gas price from the eth_feeHistory of the chain.
+	FeeEstimatorTypeFeeHistory = "fee_history"
+
+	defaultFeeHistoryBlocks     = 20
+	defaultFeeHistoryPercentile = 50
+)
+
+// GasPrice is the gas pricing suggested by a FeeEstimator, GasPrice is used by LegacyTx and AccessListTx,
+// GasTipCap and GasFeeCap by DynamicFeeTx. A nil field falls back to the default gas policy of the sender.
+type GasPrice struct {
+	GasPrice  *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// FeeEstimator suggests the gas price of the transactions sent by a sender, it's consulted before
+// sending a new transaction, the default gas policy of the sender is used if it fails.
+type FeeEstimator interface {
+	EstimateFee(ctx context.Context, baseFee uint64) (*GasPrice, error)
+}
+
+// NewFeeEstimator creates the fee estimator of the given config.
+func NewFeeEstimator(cfg *config.FeeEstimatorConfig, endpoint string) (FeeEstimator, error) {
+	switch cfg.Type {
+	case FeeEstimatorTypeFeeHistory:
+		client, err := rpc.Dial(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial fee history endpoint, err: %w", err)
+		}
+		return NewFeeHistoryEstimator(client, cfg.BlockCount, cfg.RewardPercentile), nil
+	default:
+		return nil, fmt.Errorf("unsupported fee estimator type: %s", cfg.Type)
+	}
+}
+
+// SetFeeEstimator sets the fee estimator consulted before sending a new transaction, nil means the default gas policy.
+func (s *Sender) SetFeeEstimator(estimator FeeEstimator) {
+	s.feeEstimator = estimator
+}
+
+// estimateFee consults the fee estimator, it returns nil if there is no estimator or it fails.
+func (s *Sender) estimateFee(baseFee uint64) *GasPrice {
+	if s.feeEstimator == nil {
+		return nil
+	}
+	gasPrice, err := s.feeEstimator.EstimateFee(s.ctx, baseFee)
+	if err != nil {
+		s.metrics.feeEstimatorFailureTotal.WithLabelValues(s.service, s.name).Inc()
+		log.Warn("fee estimator failed, fall back to the default gas policy", "service", s.service, "name", s.name, "err", err)
+		return nil
+	}
+	return gasPrice
+}
+
+// feeHistoryResult is the result of eth_feeHistory.
+type feeHistoryResult struct {
+	BaseFee []*hexutil.Big   `json:"baseFeePerGas"`
+	Reward  [][]*hexutil.Big `json:"reward"`
+}
+
+// FeeHistoryEstimator suggests the tip as the median of the given reward percentile over the recent blocks,
+// and the fee cap as the tip plus twice the base fee of the next block.
+type FeeHistoryEstimator struct {
+	client     *rpc.Client
+	blockCount uint64
+	percentile float64
+}
+
+// NewFeeHistoryEstimator creates a FeeHistoryEstimator, zero block count and percentile mean the defaults.
+func NewFeeHistoryEstimator(client *rpc.Client, blockCount uint64, percentile float64) *FeeHistoryEstimator {
+	if blockCount == 0 {
+		blockCount = defaultFeeHistoryBlocks
+	}
+	if percentile == 0 {
+		percentile = defaultFeeHistoryPercentile
+	}
+	return &FeeHistoryEstimator{client: client, blockCount: blockCount, percentile: percentile}
+}
+
+// EstimateFee implements FeeEstimator.
+func (e *FeeHistoryEstimator) EstimateFee(ctx context.Context, baseFee uint64) (*GasPrice, error) {
+	var result feeHistoryResult
+	if err := e.client.CallContext(ctx, &result, "eth_feeHistory", hexutil.Uint64(e.blockCount), "latest", []float64{e.percentile}); err != nil {
+		return nil, fmt.Errorf("failed to get fee history, err: %w", err)
+	}
+
+	var rewards []*big.Int
+	for _, reward := range result.Reward {
+		if len(reward) > 0 && reward[0] != nil {
+			rewards = append(rewards, reward[0].ToInt())
+		}
+	}
+	if len(rewards) == 0 {
+		return nil, errors.New("empty fee history reward")
+	}
+	sort.Slice(rewards, func(i, j int) bool { return rewards[i].Cmp(rewards[j]) < 0 })
+	tip := new(big.Int).Set(rewards[len(rewards)/2])
+
+	// the last base fee is the one of the next block.
+	nextBaseFee := new(big.Int).SetUint64(baseFee)
+	if len(result.BaseFee) > 0 && result.BaseFee[len(result.BaseFee)-1] != nil {
+		nextBaseFee = result.BaseFee[len(result.BaseFee)-1].ToInt()
+	}
+	return &GasPrice{
+		GasPrice:  new(big.Int).Add(tip, nextBaseFee),
+		GasTipCap: tip,
+		GasFeeCap: new(big.Int).Add(tip, new(big.Int).Mul(nextBaseFee, big.NewInt(2))),
+	}, nil
+}
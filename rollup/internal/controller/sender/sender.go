@@ -8,15 +8,18 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
 	"github.com/scroll-tech/go-ethereum/common"
 	gethTypes "github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/ethclient"
 	"github.com/scroll-tech/go-ethereum/ethclient/gethclient"
 	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/params"
 	"github.com/scroll-tech/go-ethereum/rlp"
 	"github.com/scroll-tech/go-ethereum/rpc"
 	"gorm.io/gorm"
@@ -39,12 +42,18 @@ const (
 	LegacyTxType = "LegacyTx"
 )
 
+// ErrNoPendingTransaction is returned when the sender has no in-flight transaction of the context ID to cancel.
+var ErrNoPendingTransaction = errors.New("no pending transaction")
+
 // Confirmation struct used to indicate transaction confirmation details
 type Confirmation struct {
 	ContextID    string
 	IsSuccessful bool
 	TxHash       common.Hash
 	SenderType   types.SenderType
+	Sender       common.Address // the account sending the transaction
+	Receipt      *gethTypes.Receipt
+	IsCancelled  bool // the confirmed transaction is the replacement sent by CancelTransaction
 }
 
 // FeeData fee struct used to estimate gas price
@@ -58,6 +67,14 @@ type FeeData struct {
 	gasLimit uint64
 }
 
+// NonceInfo the locally tracked nonce and the on-chain nonces of the sender account
+type NonceInfo struct {
+	Account      common.Address
+	LocalNonce   uint64
+	PendingNonce uint64
+	LatestNonce  uint64
+}
+
 // Sender Transaction sender to send transaction to l1/l2 geth
 type Sender struct {
 	config     *config.SenderConfig
@@ -77,6 +94,12 @@ type Sender struct {
 	confirmCh chan *Confirmation
 	stopCh    chan struct{}
 
+	// serializes CancelTransaction with checkPendingTransaction, both replace the pending transactions.
+	pendingMu sync.Mutex
+
+	// consulted for the gas price of new transactions, nil means the default gas policy.
+	feeEstimator FeeEstimator
+
 	metrics *senderMetrics
 }
 
@@ -136,6 +159,11 @@ func (s *Sender) GetChainID() *big.Int {
 	return s.chainID
 }
 
+// GetAccount returns the account the sender sends transactions from.
+func (s *Sender) GetAccount() common.Address {
+	return s.auth.From
+}
+
 // Stop stop the sender module.
 func (s *Sender) Stop() {
 	close(s.stopCh)
@@ -157,7 +185,7 @@ func (s *Sender) getFeeData(target *common.Address, value *big.Int, data []byte,
 	if s.config.TxType == DynamicFeeTxType {
 		return s.estimateDynamicGas(target, value, data, fallbackGasLimit, baseFee)
 	}
-	return s.estimateLegacyGas(target, value, data, fallbackGasLimit)
+	return s.estimateLegacyGas(target, value, data, fallbackGasLimit, baseFee)
 }
 
 // SendTransaction send a signed L2tL1 transaction.
@@ -181,7 +209,14 @@ func (s *Sender) SendTransaction(contextID string, target *common.Address, value
 		return common.Hash{}, fmt.Errorf("failed to get fee data, err: %w", err)
 	}
 
-	if tx, err = s.createAndSendTx(feeData, target, value, data, nil); err != nil {
+	tx, err = s.createAndSendTx(feeData, target, value, data, nil)
+	if err != nil && isNonceGapError(err) {
+		// the nonce has been re-synced by createAndSendTx, e.g. the account was used out-of-band, retry once with it.
+		s.metrics.sendTransactionNonceResyncTotal.WithLabelValues(s.service, s.name).Inc()
+		log.Warn("nonce out of sync, retry with the re-synced nonce", "from", s.auth.From.String(), "nonce", s.auth.Nonce.Uint64(), "err", err)
+		tx, err = s.createAndSendTx(feeData, target, value, data, nil)
+	}
+	if err != nil {
 		s.metrics.sendTransactionFailureSendTx.WithLabelValues(s.service, s.name).Inc()
 		log.Error("failed to create and send tx (non-resubmit case)", "from", s.auth.From.String(), "nonce", s.auth.Nonce.Uint64(), "err", err)
 		return common.Hash{}, fmt.Errorf("failed to create and send transaction, err: %w", err)
@@ -194,6 +229,53 @@ func (s *Sender) SendTransaction(contextID string, target *common.Address, value
 	return tx.Hash(), nil
 }
 
+// IsContract checks whether there is code deployed at the address at the latest block.
+func (s *Sender) IsContract(addr common.Address) (bool, error) {
+	code, err := s.client.CodeAt(s.ctx, addr, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to get code at %s, err: %w", addr.Hex(), err)
+	}
+	return len(code) > 0, nil
+}
+
+// GetTransactionReceipt fetches the receipt of the transaction from layer1.
+func (s *Sender) GetTransactionReceipt(txHash common.Hash) (*gethTypes.Receipt, error) {
+	receipt, err := s.client.TransactionReceipt(s.ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt of tx %s, err: %w", txHash.Hex(), err)
+	}
+	return receipt, nil
+}
+
+// SimulateTransaction executes the transaction by eth_call against the latest block,
+// it returns the error with revert reason if the execution reverts.
+func (s *Sender) SimulateTransaction(target *common.Address, value *big.Int, data []byte) error {
+	msg := ethereum.CallMsg{
+		From:  s.auth.From,
+		To:    target,
+		Value: value,
+		Data:  data,
+	}
+	if _, err := s.client.CallContract(s.ctx, msg, nil); err != nil {
+		return fmt.Errorf("failed to simulate transaction, err: %w", err)
+	}
+	return nil
+}
+
+// CallContract executes a read-only call against the given block, nil means the latest block.
+func (s *Sender) CallContract(target *common.Address, data []byte, blockNumber *big.Int) ([]byte, error) {
+	msg := ethereum.CallMsg{
+		From: s.auth.From,
+		To:   target,
+		Data: data,
+	}
+	result, err := s.client.CallContract(s.ctx, msg, blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call contract %s, err: %w", target.Hex(), err)
+	}
+	return result, nil
+}
+
 func (s *Sender) createAndSendTx(feeData *FeeData, target *common.Address, value *big.Int, data []byte, overrideNonce *uint64) (*gethTypes.Transaction, error) {
 	var (
 		nonce  = s.auth.Nonce.Uint64()
@@ -259,10 +341,12 @@ func (s *Sender) createAndSendTx(feeData *FeeData, target *common.Address, value
 
 	if err = s.client.SendTransaction(s.ctx, tx); err != nil {
 		log.Error("failed to send tx", "tx hash", tx.Hash().String(), "from", s.auth.From.String(), "nonce", tx.Nonce(), "err", err)
-		// Check if contain nonce, and reset nonce
-		// only reset nonce when it is not from resubmit
+		// Check if contain nonce, and resync nonce
+		// only resync nonce when it is not from resubmit
 		if strings.Contains(err.Error(), "nonce") && overrideNonce == nil {
-			s.resetNonce(context.Background())
+			if resyncErr := s.ResyncNonce(context.Background()); resyncErr != nil {
+				log.Warn("failed to resync nonce", "address", s.auth.From.String(), "err", resyncErr)
+			}
 		}
 		return nil, err
 	}
@@ -288,17 +372,82 @@ func (s *Sender) createAndSendTx(feeData *FeeData, target *common.Address, value
 	return tx, nil
 }
 
-// resetNonce reset nonce if send signed tx failed.
-func (s *Sender) resetNonce(ctx context.Context) {
+// ResyncNonce re-reads the pending nonce of the sender account from the node and uses it for the next transaction.
+// Unlike ResetNonce it doesn't check the in-flight transactions, it's called when the node reports the local
+// nonce is out of sync, e.g. the account was used out-of-band.
+func (s *Sender) ResyncNonce(ctx context.Context) error {
 	nonce, err := s.client.PendingNonceAt(ctx, s.auth.From)
 	if err != nil {
-		log.Warn("failed to reset nonce", "address", s.auth.From.String(), "err", err)
-		return
+		return fmt.Errorf("failed to get pending nonce for address %s, err: %w", s.auth.From.Hex(), err)
+	}
+	if nonce != s.auth.Nonce.Uint64() {
+		log.Info("resync nonce", "address", s.auth.From.String(), "local nonce", s.auth.Nonce.Uint64(), "pending nonce", nonce)
+	}
+	s.auth.Nonce = big.NewInt(int64(nonce))
+	return nil
+}
+
+// isNonceGapError checks whether the node rejected the transaction because its nonce is behind or ahead of the account.
+func isNonceGapError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "nonce too high")
+}
+
+// GetNonceInfo returns the locally tracked nonce and the on-chain nonces of the sender account.
+func (s *Sender) GetNonceInfo(ctx context.Context) (*NonceInfo, error) {
+	pendingNonce, err := s.client.PendingNonceAt(ctx, s.auth.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending nonce for address %s, err: %w", s.auth.From.Hex(), err)
 	}
+	latestNonce, err := s.client.NonceAt(ctx, s.auth.From, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest nonce for address %s, err: %w", s.auth.From.Hex(), err)
+	}
+	return &NonceInfo{
+		Account:      s.auth.From,
+		LocalNonce:   s.auth.Nonce.Uint64(),
+		PendingNonce: pendingNonce,
+		LatestNonce:  latestNonce,
+	}, nil
+}
+
+// ResetNonce re-syncs the locally tracked nonce to the on-chain pending nonce.
+// It refuses to reset while the sender still has in-flight transactions, since
+// they are resubmitted with their original nonces.
+func (s *Sender) ResetNonce(ctx context.Context) error {
+	txs, err := s.pendingTransactionOrm.GetPendingOrReplacedTransactionsBySenderAddress(ctx, s.senderType, s.auth.From.String(), 1)
+	if err != nil {
+		return fmt.Errorf("failed to load pending transactions, err: %w", err)
+	}
+	if len(txs) > 0 {
+		return fmt.Errorf("cannot reset nonce of address %s with in-flight transactions, context ID: %s, nonce: %d", s.auth.From.Hex(), txs[0].ContextID, txs[0].Nonce)
+	}
+
+	nonce, err := s.client.PendingNonceAt(ctx, s.auth.From)
+	if err != nil {
+		return fmt.Errorf("failed to get pending nonce for address %s, err: %w", s.auth.From.Hex(), err)
+	}
+	log.Info("reset nonce", "address", s.auth.From.String(), "local nonce", s.auth.Nonce.Uint64(), "pending nonce", nonce)
 	s.auth.Nonce = big.NewInt(int64(nonce))
+	return nil
 }
 
 func (s *Sender) resubmitTransaction(tx *gethTypes.Transaction, baseFee uint64) (*gethTypes.Transaction, error) {
+	feeData := s.escalateFeeData(tx, baseFee)
+
+	nonce := tx.Nonce()
+	s.metrics.resubmitTransactionTotal.WithLabelValues(s.service, s.name).Inc()
+	tx, err := s.createAndSendTx(feeData, tx.To(), tx.Value(), tx.Data(), &nonce)
+	if err != nil {
+		log.Error("failed to create and send tx (resubmit case)", "from", s.auth.From.String(), "nonce", nonce, "err", err)
+		return nil, err
+	}
+	return tx, nil
+}
+
+// escalateFeeData bumps the fees of the transaction by EscalateMultipleNum/EscalateMultipleDen for a replacement
+// transaction with the same nonce, the gas limit of the transaction is kept.
+func (s *Sender) escalateFeeData(tx *gethTypes.Transaction, baseFee uint64) *FeeData {
 	escalateMultipleNum := new(big.Int).SetUint64(s.config.EscalateMultipleNum)
 	escalateMultipleDen := new(big.Int).SetUint64(s.config.EscalateMultipleDen)
 	maxGasPrice := new(big.Int).SetUint64(s.config.MaxGasPrice)
@@ -376,21 +525,101 @@ func (s *Sender) resubmitTransaction(tx *gethTypes.Transaction, baseFee uint64)
 	}
 
 	log.Info("Transaction gas adjustment details", "service", s.service, "name", s.name, "txInfo", txInfo)
+	return &feeData
+}
+
+// CancelTransaction replaces the in-flight transaction of the context ID with a zero-value transfer from the sender
+// account to itself with the same nonce and escalated fees, so that the original transaction is dropped once the
+// replacement is mined. The replacement is tracked under the same context ID and its confirmation is flagged as
+// IsCancelled.
+func (s *Sender) CancelTransaction(contextID string) (common.Hash, error) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	txs, err := s.pendingTransactionOrm.GetTransactionsByContextID(s.ctx, s.senderType, contextID)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to load transactions of context ID %s, err: %w", contextID, err)
+	}
+	var pendingTx *orm.PendingTransaction
+	for i := range txs {
+		if txs[i].Status == types.TxStatusPending && txs[i].SenderAddress == s.auth.From.String() {
+			pendingTx = &txs[i]
+		}
+	}
+	if pendingTx == nil {
+		return common.Hash{}, fmt.Errorf("%w, context ID: %s", ErrNoPendingTransaction, contextID)
+	}
+
+	tx := new(gethTypes.Transaction)
+	if err = tx.DecodeRLP(rlp.NewStream(bytes.NewReader(pendingTx.RLPEncoding), 0)); err != nil {
+		return common.Hash{}, fmt.Errorf("failed to decode RLP of transaction %s, err: %w", pendingTx.Hash, err)
+	}
+	if s.isCancelTransaction(tx) {
+		return common.Hash{}, fmt.Errorf("transaction %s is already cancelled, context ID: %s", tx.Hash().String(), contextID)
+	}
+
+	blockNumber, baseFee, err := s.getBlockNumberAndBaseFee(s.ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	feeData := s.escalateFeeData(tx, baseFee)
+	feeData.gasLimit = params.TxGas
 
 	nonce := tx.Nonce()
-	s.metrics.resubmitTransactionTotal.WithLabelValues(s.service, s.name).Inc()
-	tx, err := s.createAndSendTx(&feeData, tx.To(), tx.Value(), tx.Data(), &nonce)
+	cancelTx, err := s.createAndSendTx(feeData, &s.auth.From, big.NewInt(0), nil, &nonce)
 	if err != nil {
-		log.Error("failed to create and send tx (resubmit case)", "from", s.auth.From.String(), "nonce", nonce, "err", err)
-		return nil, err
+		return common.Hash{}, fmt.Errorf("failed to send cancel transaction, context ID: %s, nonce: %d, err: %w", contextID, nonce, err)
 	}
-	return tx, nil
+
+	err = s.db.Transaction(func(dbTX *gorm.DB) error {
+		if err := s.pendingTransactionOrm.UpdatePendingTransactionStatusByTxHash(s.ctx, tx.Hash(), types.TxStatusReplaced, dbTX); err != nil {
+			return fmt.Errorf("failed to update status of transaction with hash %s to TxStatusReplaced, err: %w", tx.Hash().String(), err)
+		}
+		if err := s.pendingTransactionOrm.InsertPendingTransaction(s.ctx, contextID, s.getSenderMeta(), cancelTx, blockNumber, dbTX); err != nil {
+			return fmt.Errorf("failed to insert cancel transaction with context ID: %s, nonce: %d, hash: %v, err: %w", contextID, nonce, cancelTx.Hash().String(), err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("db transaction failed after sending cancel transaction", "context ID", contextID, "hash", cancelTx.Hash().String(), "err", err)
+		return common.Hash{}, err
+	}
+
+	s.metrics.cancelTransactionTotal.WithLabelValues(s.service, s.name).Inc()
+	log.Info("cancel transaction", "service", s.service, "name", s.name, "context ID", contextID, "original hash", tx.Hash().String(),
+		"cancel hash", cancelTx.Hash().String(), "from", s.auth.From.String(), "nonce", nonce)
+	return cancelTx.Hash(), nil
+}
+
+// isCancelTransaction checks whether the transaction is a zero-value transfer to the sender account itself,
+// which is how CancelTransaction replaces a transaction.
+func (s *Sender) isCancelTransaction(tx *gethTypes.Transaction) bool {
+	return tx.To() != nil && *tx.To() == s.auth.From && tx.Value().Sign() == 0 && len(tx.Data()) == 0
+}
+
+// PendingCount returns the number of in-flight transactions of the sender, one per nonce waiting for confirmation.
+func (s *Sender) PendingCount(ctx context.Context) (int64, error) {
+	return s.pendingTransactionOrm.GetPendingTransactionCountBySenderAddress(ctx, s.senderType, s.auth.From.String())
+}
+
+// updatePendingCount refreshes the gauge of the in-flight transactions of the sender.
+func (s *Sender) updatePendingCount() {
+	count, err := s.PendingCount(s.ctx)
+	if err != nil {
+		log.Warn("failed to count pending transactions", "sender meta", s.getSenderMeta(), "err", err)
+		return
+	}
+	s.metrics.pendingTransactionCount.WithLabelValues(s.service, s.name).Set(float64(count))
 }
 
 // checkPendingTransaction checks the confirmation status of pending transactions against the latest confirmed block number.
 // If a transaction hasn't been confirmed after a certain number of blocks, it will be resubmitted with an increased gas price.
 func (s *Sender) checkPendingTransaction() {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
 	s.metrics.senderCheckPendingTransactionTotal.WithLabelValues(s.service, s.name).Inc()
+	defer s.updatePendingCount()
 
 	blockNumber, baseFee, err := s.getBlockNumberAndBaseFee(s.ctx)
 	if err != nil {
@@ -398,7 +627,7 @@ func (s *Sender) checkPendingTransaction() {
 		return
 	}
 
-	transactionsToCheck, err := s.pendingTransactionOrm.GetPendingOrReplacedTransactionsBySenderType(s.ctx, s.senderType, 100)
+	transactionsToCheck, err := s.pendingTransactionOrm.GetPendingOrReplacedTransactionsBySenderAddress(s.ctx, s.senderType, s.auth.From.String(), 100)
 	if err != nil {
 		log.Error("failed to load pending transactions", "sender meta", s.getSenderMeta(), "err", err)
 		return
@@ -444,10 +673,13 @@ func (s *Sender) checkPendingTransaction() {
 					IsSuccessful: receipt.Status == gethTypes.ReceiptStatusSuccessful,
 					TxHash:       tx.Hash(),
 					SenderType:   s.senderType,
+					Sender:       s.auth.From,
+					Receipt:      receipt,
+					IsCancelled:  s.isCancelTransaction(tx),
 				}
 			}
 		} else if txnToCheck.Status == types.TxStatusPending && // Only try resubmitting a new transaction based on gas price of the last transaction (status pending) with same ContextID.
-			s.config.EscalateBlocks+txnToCheck.SubmitBlockNumber <= blockNumber {
+			s.shouldEscalate(&txnToCheck, blockNumber) {
 			// It's possible that the pending transaction was marked as failed earlier in this loop (e.g., if one of its replacements has already been confirmed).
 			// Therefore, we fetch the current transaction status again for accuracy before proceeding.
 			status, err := s.pendingTransactionOrm.GetTxStatusByTxHash(s.ctx, tx.Hash())
@@ -494,6 +726,15 @@ func (s *Sender) checkPendingTransaction() {
 	}
 }
 
+// shouldEscalate checks whether the pending transaction waited EscalateBlocks blocks or EscalateIntervalSec seconds
+// since it was submitted, a replacement transaction restarts the wait.
+func (s *Sender) shouldEscalate(txn *orm.PendingTransaction, blockNumber uint64) bool {
+	if s.config.EscalateBlocks+txn.SubmitBlockNumber <= blockNumber {
+		return true
+	}
+	return s.config.EscalateIntervalSec > 0 && time.Since(txn.CreatedAt) >= time.Duration(s.config.EscalateIntervalSec)*time.Second
+}
+
 // Loop is the main event loop
 func (s *Sender) loop(ctx context.Context) {
 	checkTick := time.NewTicker(time.Duration(s.config.CheckPendingTime) * time.Second)
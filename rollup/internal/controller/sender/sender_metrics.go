@@ -12,12 +12,16 @@ type senderMetrics struct {
 	sendTransactionTotal               *prometheus.CounterVec
 	sendTransactionFailureGetFee       *prometheus.CounterVec
 	sendTransactionFailureSendTx       *prometheus.CounterVec
+	sendTransactionNonceResyncTotal    *prometheus.CounterVec
 	resubmitTransactionTotal           *prometheus.CounterVec
 	resubmitTransactionFailedTotal     *prometheus.CounterVec
+	cancelTransactionTotal             *prometheus.CounterVec
 	currentGasFeeCap                   *prometheus.GaugeVec
 	currentGasTipCap                   *prometheus.GaugeVec
 	currentGasPrice                    *prometheus.GaugeVec
 	currentGasLimit                    *prometheus.GaugeVec
+	feeEstimatorFailureTotal           *prometheus.CounterVec
+	pendingTransactionCount            *prometheus.GaugeVec
 }
 
 var (
@@ -36,10 +40,18 @@ func initSenderMetrics(reg prometheus.Registerer) *senderMetrics {
 				Name: "rollup_sender_send_transaction_get_fee_failure_total",
 				Help: "The total number of sending transactions failure for getting fee.",
 			}, []string{"service", "name"}),
+			feeEstimatorFailureTotal: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+				Name: "rollup_sender_fee_estimator_failure_total",
+				Help: "The total number of fee estimator failures falling back to the default gas policy.",
+			}, []string{"service", "name"}),
 			sendTransactionFailureSendTx: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 				Name: "rollup_sender_send_transaction_send_tx_failure_total",
 				Help: "The total number of sending transactions failure for sending tx.",
 			}, []string{"service", "name"}),
+			sendTransactionNonceResyncTotal: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+				Name: "rollup_sender_send_transaction_nonce_resync_total",
+				Help: "The total number of sending transactions retried after re-syncing an out of sync nonce.",
+			}, []string{"service", "name"}),
 			resubmitTransactionTotal: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 				Name: "rollup_sender_send_transaction_resubmit_send_transaction_total",
 				Help: "The total number of resubmit transactions.",
@@ -48,6 +60,10 @@ func initSenderMetrics(reg prometheus.Registerer) *senderMetrics {
 				Name: "rollup_sender_send_transaction_resubmit_send_transaction_failed_total",
 				Help: "The total number of failed resubmit transactions.",
 			}, []string{"service", "name"}),
+			cancelTransactionTotal: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+				Name: "rollup_sender_cancel_transaction_total",
+				Help: "The total number of transactions cancelled by a zero-value replacement.",
+			}, []string{"service", "name"}),
 			currentGasFeeCap: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
 				Name: "rollup_sender_gas_fee_cap",
 				Help: "The gas fee cap of current transaction.",
@@ -68,6 +84,10 @@ func initSenderMetrics(reg prometheus.Registerer) *senderMetrics {
 				Name: "rollup_sender_check_pending_transaction_total",
 				Help: "The total number of check pending transaction.",
 			}, []string{"service", "name"}),
+			pendingTransactionCount: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+				Name: "rollup_sender_pending_transaction_count",
+				Help: "The number of in-flight transactions waiting for confirmation, refreshed on each pending transaction check.",
+			}, []string{"service", "name"}),
 		}
 	})
 
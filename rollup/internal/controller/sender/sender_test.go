@@ -1,6 +1,7 @@
 package sender
 
 import (
+	"bytes"
 	"context"
 	"crypto/ecdsa"
 	"errors"
@@ -8,14 +9,18 @@ import (
 	"math/big"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/agiledragon/gomonkey/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
 	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
 	gethTypes "github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/crypto"
 	"github.com/scroll-tech/go-ethereum/ethclient"
 	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/rlp"
 	"github.com/scroll-tech/go-ethereum/rpc"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
@@ -27,6 +32,7 @@ import (
 
 	bridgeAbi "scroll-tech/rollup/abi"
 	"scroll-tech/rollup/internal/config"
+	"scroll-tech/rollup/internal/orm"
 	"scroll-tech/rollup/mock_bridge"
 )
 
@@ -107,6 +113,13 @@ func TestSender(t *testing.T) {
 	t.Run("test check pending transaction resubmit tx confirmed", testCheckPendingTransactionResubmitTxConfirmed)
 	t.Run("test check pending transaction replaced tx confirmed", testCheckPendingTransactionReplacedTxConfirmed)
 	t.Run("test check pending transaction multiple times with only one transaction pending", testCheckPendingTransactionTxMultipleTimesWithOnlyOneTxPending)
+	t.Run("test check pending transaction escalate interval", testCheckPendingTransactionEscalateInterval)
+	t.Run("test get nonce info and reset nonce", testGetNonceInfoAndResetNonce)
+	t.Run("test is contract", testIsContract)
+	t.Run("test fee estimator", testFeeEstimator)
+	t.Run("test pending count", testPendingCount)
+	t.Run("test resync nonce on nonce gap error", testResyncNonceOnNonceGapError)
+	t.Run("test cancel transaction", testCancelTransaction)
 }
 
 func testNewSender(t *testing.T) {
@@ -548,3 +561,350 @@ func testCheckPendingTransactionTxMultipleTimesWithOnlyOneTxPending(t *testing.T
 		patchGuard.Reset()
 	}
 }
+
+func testGetNonceInfoAndResetNonce(t *testing.T) {
+	for _, txType := range txTypes {
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+		assert.NoError(t, migrate.ResetDB(sqlDB))
+
+		cfgCopy := *cfg.L1Config.RelayerConfig.SenderConfig
+		cfgCopy.TxType = txType
+		s, err := NewSender(context.Background(), &cfgCopy, privateKey, "test", "test", types.SenderTypeCommitBatch, db, nil)
+		assert.NoError(t, err)
+
+		nonceInfo, err := s.GetNonceInfo(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, s.auth.From, nonceInfo.Account)
+		assert.Equal(t, nonceInfo.PendingNonce, nonceInfo.LocalNonce)
+
+		// refuse to reset nonce with in-flight transactions.
+		_, err = s.SendTransaction("test", &common.Address{}, big.NewInt(0), nil, 0)
+		assert.NoError(t, err)
+		s.auth.Nonce = big.NewInt(int64(nonceInfo.LocalNonce + 10))
+		err = s.ResetNonce(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, nonceInfo.LocalNonce+10, s.auth.Nonce.Uint64())
+
+		// reset the drifted nonce to the on-chain pending nonce.
+		patchGuard := gomonkey.ApplyMethodFunc(s.pendingTransactionOrm, "GetPendingOrReplacedTransactionsBySenderAddress", func(ctx context.Context, senderType types.SenderType, senderAddress string, limit int) ([]orm.PendingTransaction, error) {
+			return nil, nil
+		})
+		err = s.ResetNonce(context.Background())
+		patchGuard.Reset()
+		assert.NoError(t, err)
+
+		nonceInfo, err = s.GetNonceInfo(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, nonceInfo.PendingNonce, nonceInfo.LocalNonce)
+		s.Stop()
+	}
+}
+
+func testIsContract(t *testing.T) {
+	s, err := NewSender(context.Background(), cfg.L1Config.RelayerConfig.SenderConfig, privateKey, "test", "test", types.SenderTypeUnknown, db, nil)
+	assert.NoError(t, err)
+	defer s.Stop()
+
+	isContract, err := s.IsContract(mockL1ContractsAddress)
+	assert.NoError(t, err)
+	assert.True(t, isContract)
+
+	isContract, err = s.IsContract(common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678"))
+	assert.NoError(t, err)
+	assert.False(t, isContract)
+}
+
+type fakeFeeEstimator struct {
+	gasPrice *GasPrice
+	err      error
+}
+
+func (e *fakeFeeEstimator) EstimateFee(ctx context.Context, baseFee uint64) (*GasPrice, error) {
+	return e.gasPrice, e.err
+}
+
+func testFeeEstimator(t *testing.T) {
+	for _, txType := range txTypes {
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+		assert.NoError(t, migrate.ResetDB(sqlDB))
+
+		cfgCopy := *cfg.L1Config.RelayerConfig.SenderConfig
+		cfgCopy.TxType = txType
+		s, err := NewSender(context.Background(), &cfgCopy, privateKey, "test", "test", types.SenderTypeUnknown, db, nil)
+		assert.NoError(t, err)
+
+		var sentTxs []*gethTypes.Transaction
+		patchGuard := gomonkey.ApplyMethodFunc(s.client, "SendTransaction", func(ctx context.Context, tx *gethTypes.Transaction) error {
+			sentTxs = append(sentTxs, tx)
+			return nil
+		})
+
+		// the estimated gas price is applied.
+		estimated := &GasPrice{
+			GasPrice:  big.NewInt(123456789000),
+			GasTipCap: big.NewInt(2000000000),
+			GasFeeCap: big.NewInt(234567890000),
+		}
+		s.SetFeeEstimator(&fakeFeeEstimator{gasPrice: estimated})
+		_, err = s.SendTransaction("0", &common.Address{}, big.NewInt(0), nil, 100000)
+		assert.NoError(t, err)
+		assert.Len(t, sentTxs, 1)
+		if txType == DynamicFeeTxType {
+			assert.Equal(t, estimated.GasTipCap, sentTxs[0].GasTipCap())
+			assert.Equal(t, estimated.GasFeeCap, sentTxs[0].GasFeeCap())
+		} else {
+			assert.Equal(t, estimated.GasPrice, sentTxs[0].GasPrice())
+		}
+
+		// the default gas policy is used when the estimator fails.
+		s.SetFeeEstimator(&fakeFeeEstimator{err: errors.New("fee estimator error")})
+		_, err = s.SendTransaction("1", &common.Address{}, big.NewInt(0), nil, 100000)
+		assert.NoError(t, err)
+		assert.Len(t, sentTxs, 2)
+		if txType == DynamicFeeTxType {
+			gasTipCap, err := s.client.SuggestGasTipCap(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, gasTipCap, sentTxs[1].GasTipCap())
+		} else {
+			gasPrice, err := s.client.SuggestGasPrice(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, gasPrice, sentTxs[1].GasPrice())
+		}
+
+		s.Stop()
+		patchGuard.Reset()
+	}
+}
+
+type feeHistoryService struct {
+	result *feeHistoryResult
+}
+
+func (s *feeHistoryService) FeeHistory(blockCount hexutil.Uint64, lastBlock string, percentiles []float64) (*feeHistoryResult, error) {
+	if s.result == nil {
+		return nil, errors.New("fee history not available")
+	}
+	return s.result, nil
+}
+
+func TestFeeHistoryEstimator(t *testing.T) {
+	service := &feeHistoryService{}
+	server := rpc.NewServer()
+	assert.NoError(t, server.RegisterName("eth", service))
+	defer server.Stop()
+	estimator := NewFeeHistoryEstimator(rpc.DialInProc(server), 0, 0)
+	assert.Equal(t, uint64(defaultFeeHistoryBlocks), estimator.blockCount)
+	assert.Equal(t, float64(defaultFeeHistoryPercentile), estimator.percentile)
+
+	_, err := estimator.EstimateFee(context.Background(), 100)
+	assert.Error(t, err)
+
+	service.result = &feeHistoryResult{}
+	_, err = estimator.EstimateFee(context.Background(), 100)
+	assert.Error(t, err)
+
+	// the tip is the median reward, the next base fee is the last one of the fee history.
+	service.result = &feeHistoryResult{
+		BaseFee: []*hexutil.Big{(*hexutil.Big)(big.NewInt(90)), (*hexutil.Big)(big.NewInt(110))},
+		Reward:  [][]*hexutil.Big{{(*hexutil.Big)(big.NewInt(30))}, {(*hexutil.Big)(big.NewInt(10))}, {(*hexutil.Big)(big.NewInt(20))}},
+	}
+	gasPrice, err := estimator.EstimateFee(context.Background(), 100)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(20), gasPrice.GasTipCap)
+	assert.Equal(t, big.NewInt(130), gasPrice.GasPrice)
+	assert.Equal(t, big.NewInt(240), gasPrice.GasFeeCap)
+
+	// the base fee of the latest block is used without the next base fee.
+	service.result.BaseFee = nil
+	gasPrice, err = estimator.EstimateFee(context.Background(), 100)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(120), gasPrice.GasPrice)
+	assert.Equal(t, big.NewInt(220), gasPrice.GasFeeCap)
+}
+
+func testPendingCount(t *testing.T) {
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	assert.NoError(t, migrate.ResetDB(sqlDB))
+
+	cfgCopy := *cfg.L1Config.RelayerConfig.SenderConfig
+	s, err := NewSender(context.Background(), &cfgCopy, privateKey, "test", "pending_count", types.SenderTypeCommitBatch, db, nil)
+	assert.NoError(t, err)
+	defer s.Stop()
+
+	for i := 0; i < 3; i++ {
+		_, err = s.SendTransaction(fmt.Sprintf("test-%d", i), &common.Address{}, big.NewInt(0), nil, 0)
+		assert.NoError(t, err)
+	}
+	count, err := s.PendingCount(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	s.updatePendingCount()
+	assert.Equal(t, float64(3), testutil.ToFloat64(s.metrics.pendingTransactionCount.WithLabelValues("test", "pending_count")))
+
+	// the gauge is drained once the transactions are confirmed.
+	patchGuard := gomonkey.ApplyMethodFunc(s.client, "TransactionReceipt", func(_ context.Context, hash common.Hash) (*gethTypes.Receipt, error) {
+		return &gethTypes.Receipt{TxHash: hash, BlockNumber: big.NewInt(0), Status: gethTypes.ReceiptStatusSuccessful}, nil
+	})
+	defer patchGuard.Reset()
+	s.checkPendingTransaction()
+
+	count, err = s.PendingCount(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, float64(0), testutil.ToFloat64(s.metrics.pendingTransactionCount.WithLabelValues("test", "pending_count")))
+}
+
+func testCheckPendingTransactionEscalateInterval(t *testing.T) {
+	for _, txType := range txTypes {
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+		assert.NoError(t, migrate.ResetDB(sqlDB))
+
+		cfgCopy := *cfg.L1Config.RelayerConfig.SenderConfig
+		cfgCopy.TxType = txType
+		cfgCopy.EscalateBlocks = 1000000
+		cfgCopy.EscalateIntervalSec = 60
+		s, err := NewSender(context.Background(), &cfgCopy, privateKey, "test", "test", types.SenderTypeFinalizeBatch, db, nil)
+		assert.NoError(t, err)
+
+		originTxHash, err := s.SendTransaction("test", &common.Address{}, big.NewInt(0), nil, 0)
+		assert.NoError(t, err)
+
+		// the tx is never mined.
+		patchGuard := gomonkey.ApplyMethodFunc(s.client, "TransactionReceipt", func(_ context.Context, hash common.Hash) (*gethTypes.Receipt, error) {
+			return nil, fmt.Errorf("simulated transaction receipt error")
+		})
+
+		// neither EscalateBlocks nor EscalateIntervalSec is reached.
+		s.checkPendingTransaction()
+		txs, err := s.pendingTransactionOrm.GetPendingOrReplacedTransactionsBySenderType(context.Background(), s.senderType, 2)
+		assert.NoError(t, err)
+		assert.Len(t, txs, 1)
+
+		// the tx was submitted before EscalateIntervalSec, it's replaced by one with the same nonce and a bumped fee.
+		assert.NoError(t, db.Model(&orm.PendingTransaction{}).Where("hash = ?", originTxHash.String()).
+			Update("created_at", time.Now().Add(-61*time.Second)).Error)
+		s.checkPendingTransaction()
+
+		txs, err = s.pendingTransactionOrm.GetPendingOrReplacedTransactionsBySenderType(context.Background(), s.senderType, 2)
+		assert.NoError(t, err)
+		assert.Len(t, txs, 2)
+		assert.Equal(t, originTxHash.String(), txs[0].Hash)
+		assert.Equal(t, types.TxStatusReplaced, txs[0].Status)
+		assert.Equal(t, types.TxStatusPending, txs[1].Status)
+		assert.Equal(t, "test", txs[1].ContextID)
+		assert.Equal(t, txs[0].Nonce, txs[1].Nonce)
+		assert.Greater(t, txs[1].GasFeeCap, txs[0].GasFeeCap)
+
+		s.Stop()
+		patchGuard.Reset()
+	}
+}
+
+func testResyncNonceOnNonceGapError(t *testing.T) {
+	for _, txType := range txTypes {
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+		assert.NoError(t, migrate.ResetDB(sqlDB))
+
+		cfgCopy := *cfg.L1Config.RelayerConfig.SenderConfig
+		cfgCopy.TxType = txType
+		s, err := NewSender(context.Background(), &cfgCopy, privateKey, "test", "nonce_resync", types.SenderTypeCommitBatch, db, nil)
+		assert.NoError(t, err)
+
+		nonceInfo, err := s.GetNonceInfo(context.Background())
+		assert.NoError(t, err)
+		// the local nonce drifts from the chain, e.g. the account was used out-of-band.
+		s.auth.Nonce = big.NewInt(int64(nonceInfo.PendingNonce + 5))
+
+		var sentNonces []uint64
+		patchGuard := gomonkey.ApplyMethodFunc(s.client, "SendTransaction", func(_ context.Context, tx *gethTypes.Transaction) error {
+			sentNonces = append(sentNonces, tx.Nonce())
+			if len(sentNonces) == 1 {
+				return errors.New("nonce too high")
+			}
+			return nil
+		})
+
+		resyncs := testutil.ToFloat64(s.metrics.sendTransactionNonceResyncTotal.WithLabelValues("test", "nonce_resync"))
+		hash, err := s.SendTransaction("test", &common.Address{}, big.NewInt(0), nil, 0)
+		patchGuard.Reset()
+		assert.NoError(t, err)
+		assert.NotEqual(t, common.Hash{}, hash)
+		assert.Equal(t, []uint64{nonceInfo.PendingNonce + 5, nonceInfo.PendingNonce}, sentNonces)
+		assert.Equal(t, nonceInfo.PendingNonce+1, s.auth.Nonce.Uint64())
+		assert.Equal(t, resyncs+1, testutil.ToFloat64(s.metrics.sendTransactionNonceResyncTotal.WithLabelValues("test", "nonce_resync")))
+		s.Stop()
+	}
+}
+
+func testCancelTransaction(t *testing.T) {
+	for _, txType := range txTypes {
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+		assert.NoError(t, migrate.ResetDB(sqlDB))
+
+		cfgCopy := *cfg.L1Config.RelayerConfig.SenderConfig
+		cfgCopy.TxType = txType
+		s, err := NewSender(context.Background(), &cfgCopy, privateKey, "test", "cancel", types.SenderTypeCommitBatch, db, nil)
+		assert.NoError(t, err)
+
+		originTxHash, err := s.SendTransaction("test", &common.Address{}, big.NewInt(0), []byte{1, 2, 3}, 0)
+		assert.NoError(t, err)
+
+		_, err = s.CancelTransaction("unknown")
+		assert.ErrorIs(t, err, ErrNoPendingTransaction)
+
+		cancelTxHash, err := s.CancelTransaction("test")
+		assert.NoError(t, err)
+		assert.NotEqual(t, originTxHash, cancelTxHash)
+
+		status, err := s.pendingTransactionOrm.GetTxStatusByTxHash(context.Background(), originTxHash)
+		assert.NoError(t, err)
+		assert.Equal(t, types.TxStatusReplaced, status)
+
+		txs, err := s.pendingTransactionOrm.GetTransactionsByContextID(context.Background(), s.senderType, "test")
+		assert.NoError(t, err)
+		assert.Len(t, txs, 2)
+		var cancelTx *gethTypes.Transaction
+		for _, txn := range txs {
+			if txn.Hash == cancelTxHash.String() {
+				cancelTx = new(gethTypes.Transaction)
+				assert.NoError(t, cancelTx.DecodeRLP(rlp.NewStream(bytes.NewReader(txn.RLPEncoding), 0)))
+				assert.Equal(t, types.TxStatusPending, txn.Status)
+			}
+		}
+		assert.NotNil(t, cancelTx)
+		// a zero-value transfer to the sender account itself with the nonce of the original tx.
+		assert.Equal(t, s.auth.From, *cancelTx.To())
+		assert.Zero(t, cancelTx.Value().Sign())
+		assert.Empty(t, cancelTx.Data())
+		assert.Equal(t, txs[0].Nonce, cancelTx.Nonce())
+
+		// the cancel tx isn't cancelled again.
+		_, err = s.CancelTransaction("test")
+		assert.ErrorContains(t, err, "already cancelled")
+
+		patchGuard := gomonkey.ApplyMethodFunc(s.client, "TransactionReceipt", func(_ context.Context, hash common.Hash) (*gethTypes.Receipt, error) {
+			if hash == cancelTxHash {
+				return &gethTypes.Receipt{TxHash: hash, BlockNumber: big.NewInt(0), Status: gethTypes.ReceiptStatusSuccessful}, nil
+			}
+			return nil, fmt.Errorf("simulated transaction receipt error")
+		})
+		s.checkPendingTransaction()
+		patchGuard.Reset()
+
+		cfm := <-s.ConfirmChan()
+		assert.Equal(t, "test", cfm.ContextID)
+		assert.Equal(t, cancelTxHash, cfm.TxHash)
+		assert.True(t, cfm.IsCancelled)
+
+		// the cancel tx is confirmed, nothing is left to cancel.
+		_, err = s.CancelTransaction("test")
+		assert.ErrorIs(t, err, ErrNoPendingTransaction)
+		s.Stop()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"time"
 
 	"scroll-tech/common/types"
@@ -49,10 +50,19 @@ type Batch struct {
 	FinalizeTxHash string     `json:"finalize_tx_hash" gorm:"column:finalize_tx_hash;default:NULL"`
 	FinalizedAt    *time.Time `json:"finalized_at" gorm:"column:finalized_at;default:NULL"`
 
+	// finalize skip reason
+	FinalizeSkipReason int16 `json:"finalize_skip_reason" gorm:"column:finalize_skip_reason;default:0"`
+
 	// gas oracle
 	OracleStatus int16  `json:"oracle_status" gorm:"column:oracle_status;default:1"`
 	OracleTxHash string `json:"oracle_tx_hash" gorm:"column:oracle_tx_hash;default:NULL"`
 
+	// l1 cost, fees are in wei
+	CommitL1GasUsed   uint64 `json:"commit_l1_gas_used" gorm:"column:commit_l1_gas_used;default:0"`
+	CommitL1Fee       string `json:"commit_l1_fee" gorm:"column:commit_l1_fee;default:0;type:decimal(78)"`
+	FinalizeL1GasUsed uint64 `json:"finalize_l1_gas_used" gorm:"column:finalize_l1_gas_used;default:0"`
+	FinalizeL1Fee     string `json:"finalize_l1_fee" gorm:"column:finalize_l1_fee;default:0;type:decimal(78)"`
+
 	// metadata
 	TotalL1CommitGas          uint64         `json:"total_l1_commit_gas" gorm:"column:total_l1_commit_gas;default:0"`
 	TotalL1CommitCalldataSize uint32         `json:"total_l1_commit_calldata_size" gorm:"column:total_l1_commit_calldata_size;default:0"`
@@ -145,6 +155,23 @@ func (o *Batch) GetLatestBatch(ctx context.Context) (*Batch, error) {
 	return &latestBatch, nil
 }
 
+// GetLatestFinalizedBatch retrieves the finalized batch of the highest index, nil if no batch is finalized.
+func (o *Batch) GetLatestFinalizedBatch(ctx context.Context) (*Batch, error) {
+	db := o.db.WithContext(ctx)
+	db = db.Model(&Batch{})
+	db = db.Where("rollup_status = ?", types.RollupFinalized)
+	db = db.Order("index desc")
+
+	var latestBatch Batch
+	if err := db.First(&latestBatch).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Batch.GetLatestFinalizedBatch error: %w", err)
+	}
+	return &latestBatch, nil
+}
+
 // GetFirstUnbatchedChunkIndex retrieves the first unbatched chunk index.
 func (o *Batch) GetFirstUnbatchedChunkIndex(ctx context.Context) (uint64, error) {
 	// Get the latest batch
@@ -226,6 +253,28 @@ func (o *Batch) GetBatchByIndex(ctx context.Context, index uint64) (*Batch, erro
 	return &batch, nil
 }
 
+// GetBatchesInRange retrieves up to limit batches with index in [startIndex, endIndex].
+// The returned batches are sorted in ascending order by their index.
+func (o *Batch) GetBatchesInRange(ctx context.Context, startIndex uint64, endIndex uint64, limit int) ([]*Batch, error) {
+	if startIndex > endIndex {
+		return nil, fmt.Errorf("Batch.GetBatchesInRange: start index should be less than or equal to end index, start index: %v, end index: %v", startIndex, endIndex)
+	}
+
+	db := o.db.WithContext(ctx)
+	db = db.Model(&Batch{})
+	db = db.Where("index >= ? AND index <= ?", startIndex, endIndex)
+	db = db.Order("index ASC")
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+
+	var batches []*Batch
+	if err := db.Find(&batches).Error; err != nil {
+		return nil, fmt.Errorf("Batch.GetBatchesInRange error: %w, start index: %v, end index: %v", err, startIndex, endIndex)
+	}
+	return batches, nil
+}
+
 // InsertBatch inserts a new batch into the database.
 func (o *Batch) InsertBatch(ctx context.Context, chunks []*types.Chunk, batchMeta *types.BatchMeta, dbTX ...*gorm.DB) (*Batch, error) {
 	if len(chunks) == 0 {
@@ -413,6 +462,78 @@ func (o *Batch) UpdateFinalizeTxHashAndRollupStatus(ctx context.Context, hash st
 	return nil
 }
 
+// UpdateCommitL1Cost updates the l1 gas used and fee paid by the confirmed commit transaction of a batch.
+func (o *Batch) UpdateCommitL1Cost(ctx context.Context, hash string, gasUsed uint64, fee *big.Int, dbTX ...*gorm.DB) error {
+	updateFields := make(map[string]interface{})
+	updateFields["commit_l1_gas_used"] = gasUsed
+	updateFields["commit_l1_fee"] = fee.String()
+
+	db := o.db
+	if len(dbTX) > 0 && dbTX[0] != nil {
+		db = dbTX[0]
+	}
+	db = db.WithContext(ctx)
+	db = db.Model(&Batch{})
+	db = db.Where("hash", hash)
+
+	if err := db.Updates(updateFields).Error; err != nil {
+		return fmt.Errorf("Batch.UpdateCommitL1Cost error: %w, batch hash: %v, gas used: %v, fee: %v", err, hash, gasUsed, fee)
+	}
+	return nil
+}
+
+// UpdateFinalizeL1Cost updates the l1 gas used and fee paid by the confirmed finalize transaction of a batch.
+func (o *Batch) UpdateFinalizeL1Cost(ctx context.Context, hash string, gasUsed uint64, fee *big.Int, dbTX ...*gorm.DB) error {
+	updateFields := make(map[string]interface{})
+	updateFields["finalize_l1_gas_used"] = gasUsed
+	updateFields["finalize_l1_fee"] = fee.String()
+
+	db := o.db
+	if len(dbTX) > 0 && dbTX[0] != nil {
+		db = dbTX[0]
+	}
+	db = db.WithContext(ctx)
+	db = db.Model(&Batch{})
+	db = db.Where("hash", hash)
+
+	if err := db.Updates(updateFields).Error; err != nil {
+		return fmt.Errorf("Batch.UpdateFinalizeL1Cost error: %w, batch hash: %v, gas used: %v, fee: %v", err, hash, gasUsed, fee)
+	}
+	return nil
+}
+
+// UpdateFinalizeSkipReason updates the reason why a batch was last skipped by finalization.
+func (o *Batch) UpdateFinalizeSkipReason(ctx context.Context, hash string, reason types.FinalizeSkipReason, dbTX ...*gorm.DB) error {
+	db := o.db
+	if len(dbTX) > 0 && dbTX[0] != nil {
+		db = dbTX[0]
+	}
+	db = db.WithContext(ctx)
+	db = db.Model(&Batch{})
+	db = db.Where("hash", hash)
+
+	if err := db.Update("finalize_skip_reason", int(reason)).Error; err != nil {
+		return fmt.Errorf("Batch.UpdateFinalizeSkipReason error: %w, batch hash: %v, reason: %v", err, hash, reason.String())
+	}
+	return nil
+}
+
+// UpdateBatchHeader updates the encoded batch header of a batch.
+func (o *Batch) UpdateBatchHeader(ctx context.Context, hash string, batchHeader []byte, dbTX ...*gorm.DB) error {
+	db := o.db
+	if len(dbTX) > 0 && dbTX[0] != nil {
+		db = dbTX[0]
+	}
+	db = db.WithContext(ctx)
+	db = db.Model(&Batch{})
+	db = db.Where("hash", hash)
+
+	if err := db.Update("batch_header", batchHeader).Error; err != nil {
+		return fmt.Errorf("Batch.UpdateBatchHeader error: %w, batch hash: %v", err, hash)
+	}
+	return nil
+}
+
 // UpdateProofByHash updates the batch proof by hash.
 // for unit test.
 func (o *Batch) UpdateProofByHash(ctx context.Context, hash string, proof *message.BatchProof, proofTimeSec uint64) error {
@@ -261,6 +261,18 @@ func TestBatchOrm(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, uint64(2), count)
 
+	batches, err := batchOrm.GetBatchesInRange(context.Background(), 0, 1, 0)
+	assert.NoError(t, err)
+	assert.Len(t, batches, 2)
+	assert.Equal(t, hash1, batches[0].Hash)
+	assert.Equal(t, hash2, batches[1].Hash)
+	batches, err = batchOrm.GetBatchesInRange(context.Background(), 1, 5, 1)
+	assert.NoError(t, err)
+	assert.Len(t, batches, 1)
+	assert.Equal(t, hash2, batches[0].Hash)
+	_, err = batchOrm.GetBatchesInRange(context.Background(), 1, 0, 0)
+	assert.Error(t, err)
+
 	err = batchOrm.UpdateRollupStatus(context.Background(), batchHash1, types.RollupCommitFailed)
 	assert.NoError(t, err)
 
@@ -281,6 +293,10 @@ func TestBatchOrm(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, dbProof)
 
+	finalizedBatch, err := batchOrm.GetLatestFinalizedBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, finalizedBatch)
+
 	err = batchOrm.UpdateProvingStatus(context.Background(), batchHash2, types.ProvingTaskVerified)
 	assert.NoError(t, err)
 	err = batchOrm.UpdateRollupStatus(context.Background(), batchHash2, types.RollupFinalized)
@@ -288,6 +304,11 @@ func TestBatchOrm(t *testing.T) {
 	err = batchOrm.UpdateL2GasOracleStatusAndOracleTxHash(context.Background(), batchHash2, types.GasOracleImported, "oracleTxHash")
 	assert.NoError(t, err)
 
+	finalizedBatch, err = batchOrm.GetLatestFinalizedBatch(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, finalizedBatch)
+	assert.Equal(t, batchHash2, finalizedBatch.Hash)
+
 	updatedBatch, err := batchOrm.GetLatestBatch(context.Background())
 	assert.NoError(t, err)
 	assert.NotNil(t, updatedBatch)
@@ -312,6 +333,32 @@ func TestBatchOrm(t *testing.T) {
 	assert.NotNil(t, updatedBatch)
 	assert.Equal(t, "finalizeTxHash", updatedBatch.FinalizeTxHash)
 	assert.Equal(t, types.RollupFinalizeFailed, types.RollupStatus(updatedBatch.RollupStatus))
+	assert.Equal(t, uint64(0), updatedBatch.CommitL1GasUsed)
+	assert.Equal(t, "0", updatedBatch.CommitL1Fee)
+
+	// the fee doesn't fit into an uint64
+	commitL1Fee, ok := new(big.Int).SetString("123456789012345678901234567890", 10)
+	assert.True(t, ok)
+	err = batchOrm.UpdateCommitL1Cost(context.Background(), batchHash2, 200000, commitL1Fee)
+	assert.NoError(t, err)
+	err = batchOrm.UpdateFinalizeL1Cost(context.Background(), batchHash2, 300000, big.NewInt(3000000))
+	assert.NoError(t, err)
+
+	updatedBatch, err = batchOrm.GetLatestBatch(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, updatedBatch)
+	assert.Equal(t, uint64(200000), updatedBatch.CommitL1GasUsed)
+	assert.Equal(t, commitL1Fee.String(), updatedBatch.CommitL1Fee)
+	assert.Equal(t, uint64(300000), updatedBatch.FinalizeL1GasUsed)
+	assert.Equal(t, "3000000", updatedBatch.FinalizeL1Fee)
+
+	assert.Equal(t, types.FinalizeSkipReasonUndefined, types.FinalizeSkipReason(updatedBatch.FinalizeSkipReason))
+	err = batchOrm.UpdateFinalizeSkipReason(context.Background(), batchHash2, types.FinalizeSkipReasonProofFailed)
+	assert.NoError(t, err)
+	updatedBatch, err = batchOrm.GetLatestBatch(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, updatedBatch)
+	assert.Equal(t, types.FinalizeSkipReasonProofFailed, types.FinalizeSkipReason(updatedBatch.FinalizeSkipReason))
 }
 
 func TestTransactionOrm(t *testing.T) {
@@ -363,6 +410,13 @@ func TestTransactionOrm(t *testing.T) {
 	err = pendingTransactionOrm.UpdatePendingTransactionStatusByTxHash(context.Background(), tx0.Hash(), types.TxStatusReplaced)
 	assert.NoError(t, err)
 
+	count, err := pendingTransactionOrm.GetPendingTransactionCountBySenderTypes(context.Background(), []types.SenderType{types.SenderTypeCommitBatch, types.SenderTypeFinalizeBatch})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	count, err = pendingTransactionOrm.GetPendingTransactionCountBySenderTypes(context.Background(), []types.SenderType{types.SenderTypeFinalizeBatch})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
 	txs, err := pendingTransactionOrm.GetPendingOrReplacedTransactionsBySenderType(context.Background(), senderMeta.Type, 2)
 	assert.NoError(t, err)
 	assert.Len(t, txs, 2)
@@ -377,6 +431,13 @@ func TestTransactionOrm(t *testing.T) {
 	assert.Equal(t, senderMeta.Address.String(), txs[1].SenderAddress)
 	assert.Equal(t, senderMeta.Type, txs[1].SenderType)
 
+	txs, err = pendingTransactionOrm.GetPendingOrReplacedTransactionsBySenderAddress(context.Background(), senderMeta.Type, senderMeta.Address.String(), 2)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 2)
+	txs, err = pendingTransactionOrm.GetPendingOrReplacedTransactionsBySenderAddress(context.Background(), senderMeta.Type, common.HexToAddress("0x1234").String(), 2)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 0)
+
 	err = pendingTransactionOrm.UpdatePendingTransactionStatusByTxHash(context.Background(), tx1.Hash(), types.TxStatusConfirmed)
 	assert.NoError(t, err)
 
@@ -394,4 +455,24 @@ func TestTransactionOrm(t *testing.T) {
 	status, err := pendingTransactionOrm.GetTxStatusByTxHash(context.Background(), tx0.Hash())
 	assert.NoError(t, err)
 	assert.Equal(t, types.TxStatusConfirmedFailed, status)
+
+	tx, err := pendingTransactionOrm.GetTransactionByTxHash(context.Background(), tx1.Hash())
+	assert.NoError(t, err)
+	assert.NotNil(t, tx)
+	assert.Equal(t, "test", tx.ContextID)
+	assert.Equal(t, senderMeta.Type, tx.SenderType)
+	tx, err = pendingTransactionOrm.GetTransactionByTxHash(context.Background(), common.HexToHash("0x1234"))
+	assert.NoError(t, err)
+	assert.Nil(t, tx)
+
+	txs, err = pendingTransactionOrm.GetTransactionsByContextID(context.Background(), senderMeta.Type, "test")
+	assert.NoError(t, err)
+	assert.Len(t, txs, 2)
+	assert.Equal(t, tx0.Hash().String(), txs[0].Hash)
+	assert.Equal(t, types.TxStatusConfirmedFailed, txs[0].Status)
+	assert.Equal(t, tx1.Hash().String(), txs[1].Hash)
+	assert.Equal(t, types.TxStatusConfirmed, txs[1].Status)
+	txs, err = pendingTransactionOrm.GetTransactionsByContextID(context.Background(), types.SenderTypeFinalizeBatch, "test")
+	assert.NoError(t, err)
+	assert.Len(t, txs, 0)
 }
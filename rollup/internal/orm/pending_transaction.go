@@ -3,6 +3,7 @@ package orm
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -69,6 +70,21 @@ func (o *PendingTransaction) GetTxStatusByTxHash(ctx context.Context, hash commo
 	return status, nil
 }
 
+// GetTransactionByTxHash retrieves the transaction of the given hash, nil if it's not found.
+func (o *PendingTransaction) GetTransactionByTxHash(ctx context.Context, hash common.Hash) (*PendingTransaction, error) {
+	var transaction PendingTransaction
+	db := o.db.WithContext(ctx)
+	db = db.Model(&PendingTransaction{})
+	db = db.Where("hash = ?", hash.String())
+	if err := db.First(&transaction).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get transaction by hash, hash: %v, err: %w", hash, err)
+	}
+	return &transaction, nil
+}
+
 // GetPendingOrReplacedTransactionsBySenderType retrieves pending or replaced transactions filtered by sender type, ordered by nonce, then gas_fee_cap (gas_price in legacy tx), and limited to a specified count.
 func (o *PendingTransaction) GetPendingOrReplacedTransactionsBySenderType(ctx context.Context, senderType types.SenderType, limit int) ([]PendingTransaction, error) {
 	var transactions []PendingTransaction
@@ -85,6 +101,67 @@ func (o *PendingTransaction) GetPendingOrReplacedTransactionsBySenderType(ctx co
 	return transactions, nil
 }
 
+// GetPendingOrReplacedTransactionsBySenderAddress retrieves pending or replaced transactions filtered by sender type and sender address, ordered by nonce, then gas_fee_cap (gas_price in legacy tx), and limited to a specified count.
+func (o *PendingTransaction) GetPendingOrReplacedTransactionsBySenderAddress(ctx context.Context, senderType types.SenderType, senderAddress string, limit int) ([]PendingTransaction, error) {
+	var transactions []PendingTransaction
+	db := o.db.WithContext(ctx)
+	db = db.Model(&PendingTransaction{})
+	db = db.Where("sender_type = ?", senderType)
+	db = db.Where("sender_address = ?", senderAddress)
+	db = db.Where("status = ? OR status = ?", types.TxStatusPending, types.TxStatusReplaced)
+	db = db.Order("nonce asc")
+	db = db.Order("gas_fee_cap asc")
+	db = db.Limit(limit)
+	if err := db.Find(&transactions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pending or replaced transactions by sender address, error: %w", err)
+	}
+	return transactions, nil
+}
+
+// GetTransactionsByContextID retrieves all the transactions sent for a context ID by the sender type, ordered by nonce, then gas_fee_cap (gas_price in legacy tx).
+func (o *PendingTransaction) GetTransactionsByContextID(ctx context.Context, senderType types.SenderType, contextID string) ([]PendingTransaction, error) {
+	var transactions []PendingTransaction
+	db := o.db.WithContext(ctx)
+	db = db.Model(&PendingTransaction{})
+	db = db.Where("sender_type = ?", senderType)
+	db = db.Where("context_id = ?", contextID)
+	db = db.Order("nonce asc")
+	db = db.Order("gas_fee_cap asc")
+	if err := db.Find(&transactions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get transactions by context ID, context ID: %v, error: %w", contextID, err)
+	}
+	return transactions, nil
+}
+
+// GetPendingTransactionCountBySenderTypes counts the pending transactions of the given sender types,
+// replaced transactions are not counted since each in-flight context has exactly one pending transaction.
+func (o *PendingTransaction) GetPendingTransactionCountBySenderTypes(ctx context.Context, senderTypes []types.SenderType) (int64, error) {
+	var count int64
+	db := o.db.WithContext(ctx)
+	db = db.Model(&PendingTransaction{})
+	db = db.Where("sender_type IN ?", senderTypes)
+	db = db.Where("status = ?", types.TxStatusPending)
+	if err := db.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to get pending transaction count by sender types, error: %w", err)
+	}
+	return count, nil
+}
+
+// GetPendingTransactionCountBySenderAddress counts the pending transactions of the given sender type and sender address,
+// replaced transactions are not counted.
+func (o *PendingTransaction) GetPendingTransactionCountBySenderAddress(ctx context.Context, senderType types.SenderType, senderAddress string) (int64, error) {
+	var count int64
+	db := o.db.WithContext(ctx)
+	db = db.Model(&PendingTransaction{})
+	db = db.Where("sender_type = ?", senderType)
+	db = db.Where("sender_address = ?", senderAddress)
+	db = db.Where("status = ?", types.TxStatusPending)
+	if err := db.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to get pending transaction count by sender address, error: %w", err)
+	}
+	return count, nil
+}
+
 // InsertPendingTransaction creates a new pending transaction record and stores it in the database.
 func (o *PendingTransaction) InsertPendingTransaction(ctx context.Context, contextID string, senderMeta *SenderMeta, tx *gethTypes.Transaction, submitBlockNumber uint64, dbTX ...*gorm.DB) error {
 	rlp := new(bytes.Buffer)